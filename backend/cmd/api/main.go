@@ -1,9 +1,11 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -12,6 +14,7 @@ import (
 	"github.com/yourusername/folda-finances/internal/database"
 	"github.com/yourusername/folda-finances/internal/handlers"
 	authmiddleware "github.com/yourusername/folda-finances/internal/middleware"
+	"github.com/yourusername/folda-finances/internal/storage"
 )
 
 func main() {
@@ -46,6 +49,39 @@ func main() {
 		log.Fatalf("Failed to seed categories: %v", err)
 	}
 
+	// Record memberships for users who joined a budget before memberships
+	if err := database.BackfillBudgetMembers(db); err != nil {
+		log.Fatalf("Failed to backfill budget members: %v", err)
+	}
+
+	// Record net worth history in the background
+	snapshotInterval, err := time.ParseDuration(getEnv("NET_WORTH_SNAPSHOT_INTERVAL", "24h"))
+	if err != nil {
+		log.Fatalf("Invalid NET_WORTH_SNAPSHOT_INTERVAL: %v", err)
+	}
+	handlers.StartNetWorthSnapshots(context.Background(), db, snapshotInterval)
+
+	// Receipts and other attachments
+	store, err := storage.New(storage.Config{
+		Driver:            getEnv("STORAGE_DRIVER", "local"),
+		LocalPath:         getEnv("STORAGE_LOCAL_PATH", "./uploads"),
+		S3Endpoint:        getEnv("S3_ENDPOINT", ""),
+		S3Region:          getEnv("S3_REGION", "us-east-1"),
+		S3Bucket:          getEnv("S3_BUCKET", ""),
+		S3AccessKeyID:     getEnv("S3_ACCESS_KEY_ID", ""),
+		S3SecretAccessKey: getEnv("S3_SECRET_ACCESS_KEY", ""),
+	})
+	if err != nil {
+		log.Fatalf("Failed to set up file storage: %v", err)
+	}
+
+	// Purge deleted budgets once their grace period has passed
+	purgeInterval, err := time.ParseDuration(getEnv("BUDGET_PURGE_INTERVAL", "24h"))
+	if err != nil {
+		log.Fatalf("Invalid BUDGET_PURGE_INTERVAL: %v", err)
+	}
+	handlers.StartBudgetPurges(context.Background(), db, store, purgeInterval)
+
 	// Initialize handlers
 	userHandler := handlers.NewUserHandler(db)
 	categoryHandler := handlers.NewCategoryHandler(db)
@@ -55,12 +91,153 @@ func main() {
 	budgetHandler := handlers.NewBudgetHandler(db)
 	incomeHandler := handlers.NewIncomeHandler(db)
 	invitationHandler := handlers.NewInvitationHandler(db)
+	memberHandler := handlers.NewMemberHandler(db)
+	goalHandler := handlers.NewGoalHandler(db)
+	debtHandler := handlers.NewDebtHandler(db)
+	netWorthHandler := handlers.NewNetWorthHandler(db)
+	priceHandler := handlers.NewPriceHandler(db)
+	tagHandler := handlers.NewTagHandler(db)
+	attachmentHandler := handlers.NewAttachmentHandler(db, store)
+	reimbursementHandler := handlers.NewReimbursementHandler(db)
+	templateHandler := handlers.NewTemplateHandler(db)
 
 	// Initialize auth middleware
 	jwtSecret := getEnv("SUPABASE_JWT_SECRET", "your-secret-key")
 	supabaseURL := getEnv("SUPABASE_URL", "")
 	authMiddleware := authmiddleware.NewAuthMiddleware(jwtSecret, supabaseURL)
 
+	// Endpoints acting on one budget: the {budgetId} in the path, the
+	// X-Budget-ID header, or else the user's default budget
+	budgetMiddleware := authmiddleware.NewBudgetMiddleware(db)
+	budgetRoutes := func(r chi.Router) {
+		r.Use(budgetMiddleware.SelectBudget)
+		r.Use(authmiddleware.RequireWriteAccess)
+
+		// Spending endpoints (CORE FEATURE)
+		r.Route("/spending", func(r chi.Router) {
+			r.Get("/available", spendingHandler.GetSpendingAvailable)
+		})
+
+		// Category endpoints
+		r.Route("/categories", func(r chi.Router) {
+			r.Get("/", categoryHandler.GetCategories)
+			r.Post("/", categoryHandler.CreateCategory)
+			r.Put("/{id}", categoryHandler.UpdateCategory)
+			r.Delete("/{id}", categoryHandler.DeleteCategory)
+			r.Post("/{id}/hide", categoryHandler.HideCategory)
+			r.Delete("/{id}/hide", categoryHandler.UnhideCategory)
+			r.Post("/{id}/merge", categoryHandler.MergeCategory)
+			r.Put("/{id}/parent", categoryHandler.SetCategoryParent)
+		})
+
+		// Account endpoints
+		r.Route("/accounts", func(r chi.Router) {
+			r.Get("/", accountHandler.ListAccounts)
+			r.Post("/", accountHandler.CreateAccount)
+			r.Get("/{id}", accountHandler.GetAccount)
+			r.Put("/{id}", accountHandler.UpdateAccount)
+			r.Delete("/{id}", accountHandler.DeleteAccount)
+			r.Post("/{id}/close", accountHandler.CloseAccount)
+			r.Post("/{id}/reopen", accountHandler.ReopenAccount)
+			r.Get("/{id}/amortization", accountHandler.GetAmortizationSchedule)
+			r.Post("/{id}/loan-payments", accountHandler.CreateLoanPayment)
+			r.Delete("/{id}/loan-payments/{paymentId}", accountHandler.DeleteLoanPayment)
+			r.Get("/{id}/holdings", accountHandler.GetHoldings)
+			r.Get("/{id}/investment-transactions", accountHandler.ListInvestmentTransactions)
+			r.Post("/{id}/investment-transactions", accountHandler.CreateInvestmentTransaction)
+			r.Get("/{id}/statement", accountHandler.GetStatement)
+			r.Get("/{id}/statements", accountHandler.ListStatements)
+		})
+
+		// Security price endpoints
+		r.Route("/prices", func(r chi.Router) {
+			r.Get("/", priceHandler.ListPrices)
+			r.Post("/", priceHandler.UpdatePrices)
+			r.Post("/import", priceHandler.ImportPrices)
+		})
+
+		// Debt payoff planner
+		r.Get("/debt-payoff", debtHandler.GetPayoffPlan)
+
+		// Net worth endpoints
+		r.Get("/net-worth", netWorthHandler.GetNetWorth)
+		r.Get("/net-worth/history", netWorthHandler.GetNetWorthHistory)
+
+		// Transaction endpoints
+		r.Route("/transactions", func(r chi.Router) {
+			r.Get("/", transactionHandler.ListTransactions)
+			r.Post("/", transactionHandler.CreateTransaction)
+			r.Get("/{id}", transactionHandler.GetTransaction)
+			r.Put("/{id}", transactionHandler.UpdateTransaction)
+			r.Delete("/{id}", transactionHandler.DeleteTransaction)
+			r.Get("/{id}/attachments", attachmentHandler.ListAttachments)
+			r.Post("/{id}/attachments", attachmentHandler.UploadAttachment)
+			r.Get("/{id}/attachments/{attachmentId}", attachmentHandler.DownloadAttachment)
+			r.Get("/{id}/attachments/{attachmentId}/thumbnail", attachmentHandler.GetAttachmentThumbnail)
+			r.Delete("/{id}/attachments/{attachmentId}", attachmentHandler.DeleteAttachment)
+			r.Get("/{id}/reimbursements", reimbursementHandler.GetReimbursementStatus)
+			r.Post("/{id}/reimbursements", reimbursementHandler.CreateReimbursement)
+			r.Delete("/{id}/reimbursements/{reimbursementId}", reimbursementHandler.DeleteReimbursement)
+		})
+
+		// Reimbursable expenses still owed back
+		r.Get("/reimbursements/outstanding", reimbursementHandler.ListOutstandingReimbursements)
+
+		// Tag endpoints
+		r.Route("/tags", func(r chi.Router) {
+			r.Get("/", tagHandler.ListTags)
+			r.Post("/", tagHandler.CreateTag)
+			r.Get("/spending", tagHandler.GetTagSpending)
+			r.Put("/{id}", tagHandler.UpdateTag)
+			r.Delete("/{id}", tagHandler.DeleteTag)
+		})
+
+		// Category budget endpoints
+		r.Route("/category-budgets", func(r chi.Router) {
+			r.Get("/", budgetHandler.ListCategoryBudgets)
+			r.Post("/", budgetHandler.CreateCategoryBudget)
+			r.Put("/{id}", budgetHandler.UpdateCategoryBudget)
+			r.Delete("/{id}", budgetHandler.DeleteCategoryBudget)
+			r.Get("/{id}/amounts", budgetHandler.GetCategoryBudgetAmounts)
+			r.Get("/{id}/splits", budgetHandler.GetCategoryBudgetSplits)
+			r.Put("/{id}/splits", budgetHandler.UpdateCategoryBudgetSplits)
+		})
+
+		// Budget template endpoints
+		r.Route("/budget-templates", func(r chi.Router) {
+			r.Get("/", templateHandler.ListBudgetTemplates)
+			r.Post("/", templateHandler.SaveBudgetTemplate)
+			r.Delete("/{id}", templateHandler.DeleteBudgetTemplate)
+			r.Post("/{id}/apply", templateHandler.ApplyBudgetTemplate)
+		})
+
+		// Budget member endpoints
+		r.Get("/budget/members", budgetHandler.GetBudgetMembers)
+		r.Put("/budget/thresholds", budgetHandler.UpdateBudgetThresholds)
+		r.Put("/budget/currency", budgetHandler.UpdateBudgetCurrency)
+		r.Put("/budget/refunds", budgetHandler.UpdateBudgetRefunds)
+		r.Put("/budget/reimbursements", reimbursementHandler.UpdateReimbursementSettings)
+
+		// Expected income endpoints
+		r.Route("/expected-income", func(r chi.Router) {
+			r.Get("/", incomeHandler.ListExpectedIncome)
+			r.Post("/", incomeHandler.CreateExpectedIncome)
+			r.Put("/{id}", incomeHandler.UpdateExpectedIncome)
+			r.Delete("/{id}", incomeHandler.DeleteExpectedIncome)
+		})
+
+		// Savings goal endpoints
+		r.Route("/goals", func(r chi.Router) {
+			r.Get("/", goalHandler.ListGoals)
+			r.Post("/", goalHandler.CreateGoal)
+			r.Get("/{id}", goalHandler.GetGoal)
+			r.Put("/{id}", goalHandler.UpdateGoal)
+			r.Delete("/{id}", goalHandler.DeleteGoal)
+			r.Post("/{id}/contributions", goalHandler.CreateGoalContribution)
+			r.Delete("/{id}/contributions/{contributionId}", goalHandler.DeleteGoalContribution)
+		})
+	}
+
 	// Initialize router
 	r := chi.NewRouter()
 
@@ -71,7 +248,7 @@ func main() {
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"http://localhost:3000", "http://localhost:5173", "https://folda-finances.vercel.app"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", authmiddleware.BudgetHeader},
 		ExposedHeaders:   []string{"Link"},
 		AllowCredentials: true,
 		MaxAge:           300,
@@ -95,59 +272,27 @@ func main() {
 				r.Patch("/me", userHandler.UpdateUser)
 			})
 
-			// Spending endpoints (CORE FEATURE)
-			r.Route("/spending", func(r chi.Router) {
-				r.Get("/available", spendingHandler.GetSpendingAvailable)
-			})
-
-			// Category endpoints
-			r.Route("/categories", func(r chi.Router) {
-				r.Get("/", categoryHandler.GetCategories)
-				r.Post("/", categoryHandler.CreateCategory)
-			})
-
-			// Account endpoints
-			r.Route("/accounts", func(r chi.Router) {
-				r.Get("/", accountHandler.ListAccounts)
-				r.Post("/", accountHandler.CreateAccount)
-				r.Get("/{id}", accountHandler.GetAccount)
-				r.Put("/{id}", accountHandler.UpdateAccount)
-				r.Delete("/{id}", accountHandler.DeleteAccount)
-			})
-
-			// Transaction endpoints
-			r.Route("/transactions", func(r chi.Router) {
-				r.Get("/", transactionHandler.ListTransactions)
-				r.Post("/", transactionHandler.CreateTransaction)
-				r.Get("/{id}", transactionHandler.GetTransaction)
-				r.Put("/{id}", transactionHandler.UpdateTransaction)
-				r.Delete("/{id}", transactionHandler.DeleteTransaction)
-			})
-
-			// Category budget endpoints
-			r.Route("/category-budgets", func(r chi.Router) {
-				r.Get("/", budgetHandler.ListCategoryBudgets)
-				r.Post("/", budgetHandler.CreateCategoryBudget)
-				r.Put("/{id}", budgetHandler.UpdateCategoryBudget)
-				r.Delete("/{id}", budgetHandler.DeleteCategoryBudget)
-				r.Get("/{id}/splits", budgetHandler.GetCategoryBudgetSplits)
-				r.Put("/{id}/splits", budgetHandler.UpdateCategoryBudgetSplits)
-			})
-
-			// Budget member endpoints
-			r.Get("/budget/members", budgetHandler.GetBudgetMembers)
+			// Budgets the user belongs to
+			r.Get("/budgets", budgetHandler.ListBudgets)
+			r.Post("/budgets", budgetHandler.CreateBudget)
 
-			// Expected income endpoints
-			r.Route("/expected-income", func(r chi.Router) {
-				r.Get("/", incomeHandler.ListExpectedIncome)
-				r.Post("/", incomeHandler.CreateExpectedIncome)
-				r.Put("/{id}", incomeHandler.UpdateExpectedIncome)
-				r.Delete("/{id}", incomeHandler.DeleteExpectedIncome)
-			})
+			r.Group(budgetRoutes)
+			r.Route("/budgets/{budgetId}", func(r chi.Router) {
+				// Outside budgetRoutes so read-only members can leave too, and
+				// deleted budgets can be restored
+				r.Post("/leave", memberHandler.LeaveBudget)
+				r.Post("/restore", budgetHandler.RestoreBudget)
 
-			// Budget invitation endpoints
-			r.Route("/budgets/{budgetId}/invite", func(r chi.Router) {
-				r.Post("/", invitationHandler.InviteToBudget)
+				r.Group(func(r chi.Router) {
+					budgetRoutes(r)
+					r.Get("/", budgetHandler.GetBudget)
+					r.Patch("/", budgetHandler.UpdateBudget)
+					r.Delete("/", budgetHandler.DeleteBudget)
+					r.Post("/invite", invitationHandler.InviteToBudget)
+					r.Patch("/members/{userId}", memberHandler.UpdateBudgetMember)
+					r.Delete("/members/{userId}", memberHandler.RemoveBudgetMember)
+					r.Post("/transfer-ownership", memberHandler.TransferOwnership)
+				})
 			})
 
 			r.Route("/budget-invitations", func(r chi.Router) {
@@ -0,0 +1,82 @@
+// Command import-rates loads exchange rates into the database from a CSV file
+// (date,base_currency,quote_currency,rate) or an ECB reference rate XML file.
+//
+//	go run ./cmd/import-rates -file eurofxref-hist.xml
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/joho/godotenv"
+	"github.com/yourusername/folda-finances/internal/currency"
+	"github.com/yourusername/folda-finances/internal/database"
+	"github.com/yourusername/folda-finances/internal/models"
+)
+
+func main() {
+	file := flag.String("file", "", "path to the rates file")
+	format := flag.String("format", "", "csv or ecb, defaults to the file extension")
+	flag.Parse()
+
+	if *file == "" {
+		log.Fatal("-file is required")
+	}
+	if *format == "" {
+		*format = "csv"
+		if strings.EqualFold(filepath.Ext(*file), ".xml") {
+			*format = "ecb"
+		}
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using environment variables")
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		log.Fatalf("Failed to open rates file: %v", err)
+	}
+	defer f.Close()
+
+	var rates []models.ExchangeRate
+	switch *format {
+	case "csv":
+		rates, err = currency.ParseCSV(f)
+	case "ecb":
+		rates, err = currency.ParseECBXML(f)
+	default:
+		log.Fatalf("Unknown format %q, expected csv or ecb", *format)
+	}
+	if err != nil {
+		log.Fatalf("Failed to parse rates: %v", err)
+	}
+
+	db, err := database.Connect(database.Config{
+		Host:     getEnv("DB_HOST", "localhost"),
+		Port:     getEnv("DB_PORT", "5432"),
+		User:     getEnv("DB_USER", "postgres"),
+		Password: getEnv("DB_PASSWORD", ""),
+		DBName:   getEnv("DB_NAME", "folda_finances"),
+		SSLMode:  getEnv("DB_SSL_MODE", "disable"),
+	})
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	if err := currency.Save(db, rates); err != nil {
+		log.Fatalf("Failed to save rates: %v", err)
+	}
+
+	log.Printf("✓ Imported %d exchange rates", len(rates))
+}
+
+func getEnv(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return fallback
+}
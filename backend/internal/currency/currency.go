@@ -0,0 +1,126 @@
+package currency
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/yourusername/folda-finances/internal/models"
+	"gorm.io/gorm"
+)
+
+var ErrNoRate = errors.New("no exchange rate")
+
+// Normalize uppercases a currency code and checks it is three letters
+func Normalize(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != 3 {
+		return "", fmt.Errorf("invalid currency code %q", code)
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return "", fmt.Errorf("invalid currency code %q", code)
+		}
+	}
+	return code, nil
+}
+
+// Converter converts amounts between currencies at the latest stored rate on
+// or before a date, caching lookups for the life of the converter
+type Converter struct {
+	db    *gorm.DB
+	rates map[rateKey]float64
+}
+
+type rateKey struct {
+	from, to string
+	date     string
+}
+
+func NewConverter(db *gorm.DB) *Converter {
+	return &Converter{db: db, rates: make(map[rateKey]float64)}
+}
+
+// Convert converts cents in one currency to cents in another
+func (c *Converter) Convert(amount int, from, to string, date time.Time) (int, error) {
+	if from == to || amount == 0 {
+		return amount, nil
+	}
+	rate, err := c.Rate(from, to, date)
+	if err != nil {
+		return 0, err
+	}
+	return int(math.Round(float64(amount) * rate)), nil
+}
+
+// Rate finds the rate from one currency to another using a stored pair, its
+// inverse, or two pairs sharing a base currency such as the ECB's EUR rates
+func (c *Converter) Rate(from, to string, date time.Time) (float64, error) {
+	if from == to {
+		return 1, nil
+	}
+	key := rateKey{from: from, to: to, date: date.Format("2006-01-02")}
+	if rate, ok := c.rates[key]; ok {
+		return rate, nil
+	}
+
+	rate, err := c.lookup(from, to, date)
+	if err != nil {
+		return 0, err
+	}
+	c.rates[key] = rate
+	return rate, nil
+}
+
+func (c *Converter) lookup(from, to string, date time.Time) (float64, error) {
+	if rate, ok, err := c.stored(from, to, date); err != nil || ok {
+		return rate, err
+	}
+	if rate, ok, err := c.stored(to, from, date); err != nil || ok {
+		return 1 / rate, err
+	}
+
+	var bases []string
+	if err := c.db.Model(&models.ExchangeRate{}).Distinct("base_currency").
+		Where("quote_currency IN ?", []string{from, to}).Pluck("base_currency", &bases).Error; err != nil {
+		return 0, err
+	}
+	for _, base := range bases {
+		fromRate, ok, err := c.stored(base, from, date)
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			continue
+		}
+		toRate, ok, err := c.stored(base, to, date)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			return toRate / fromRate, nil
+		}
+	}
+
+	return 0, fmt.Errorf("%w from %s to %s on %s", ErrNoRate, from, to, date.Format("2006-01-02"))
+}
+
+// stored returns the latest rate for a pair on or before date; a currency is
+// worth exactly one of itself
+func (c *Converter) stored(base, quote string, date time.Time) (float64, bool, error) {
+	if base == quote {
+		return 1, true, nil
+	}
+	var rate models.ExchangeRate
+	err := c.db.Where("base_currency = ? AND quote_currency = ? AND date <= ?", base, quote, date).
+		Order("date DESC").First(&rate).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return rate.Rate, true, nil
+}
@@ -0,0 +1,112 @@
+package currency
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yourusername/folda-finances/internal/models"
+	"gorm.io/gorm"
+)
+
+// ParseCSV reads date,base_currency,quote_currency,rate rows, skipping a
+// header row
+func ParseCSV(r io.Reader) ([]models.ExchangeRate, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 4
+	reader.TrimLeadingSpace = true
+
+	var rates []models.ExchangeRate
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		if line == 1 && strings.EqualFold(strings.TrimSpace(record[0]), "date") {
+			continue
+		}
+
+		rate, err := newRate(record[0], record[1], record[2], record[3], "csv")
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		rates = append(rates, rate)
+	}
+	return rates, nil
+}
+
+// ecbEnvelope is the shape of the European Central Bank's euro foreign
+// exchange reference rate files, e.g. eurofxref-hist.xml
+type ecbEnvelope struct {
+	Days []struct {
+		Time  string `xml:"time,attr"`
+		Rates []struct {
+			Currency string `xml:"currency,attr"`
+			Rate     string `xml:"rate,attr"`
+		} `xml:"Cube"`
+	} `xml:"Cube>Cube"`
+}
+
+// ParseECBXML reads an ECB reference rate file, where every rate is quoted
+// against EUR
+func ParseECBXML(r io.Reader) ([]models.ExchangeRate, error) {
+	var envelope ecbEnvelope
+	if err := xml.NewDecoder(r).Decode(&envelope); err != nil {
+		return nil, fmt.Errorf("invalid ECB XML: %v", err)
+	}
+
+	var rates []models.ExchangeRate
+	for _, day := range envelope.Days {
+		for _, quote := range day.Rates {
+			rate, err := newRate(day.Time, "EUR", quote.Currency, quote.Rate, "ecb")
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %v", day.Time, quote.Currency, err)
+			}
+			rates = append(rates, rate)
+		}
+	}
+	return rates, nil
+}
+
+// Save stores rates, replacing any already stored for the same day and pair
+func Save(db *gorm.DB, rates []models.ExchangeRate) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, rate := range rates {
+			if err := tx.Where("date = ? AND base_currency = ? AND quote_currency = ?", rate.Date, rate.BaseCurrency, rate.QuoteCurrency).
+				Delete(&models.ExchangeRate{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Create(&rate).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func newRate(date, base, quote, value, source string) (models.ExchangeRate, error) {
+	parsedDate, err := time.Parse("2006-01-02", strings.TrimSpace(date))
+	if err != nil {
+		return models.ExchangeRate{}, fmt.Errorf("invalid date %q", date)
+	}
+	base, err = Normalize(base)
+	if err != nil {
+		return models.ExchangeRate{}, err
+	}
+	quote, err = Normalize(quote)
+	if err != nil {
+		return models.ExchangeRate{}, err
+	}
+	rate, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || rate <= 0 {
+		return models.ExchangeRate{}, fmt.Errorf("invalid rate %q", value)
+	}
+	return models.ExchangeRate{Date: parsedDate, BaseCurrency: base, QuoteCurrency: quote, Rate: rate, Source: source}, nil
+}
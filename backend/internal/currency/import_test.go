@@ -0,0 +1,48 @@
+package currency
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseECBXML(t *testing.T) {
+	body := `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<Cube>
+		<Cube time="2025-01-03">
+			<Cube currency="USD" rate="1.0299"/>
+			<Cube currency="GBP" rate="0.82900"/>
+		</Cube>
+		<Cube time="2025-01-02">
+			<Cube currency="USD" rate="1.0321"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+	rates, err := ParseECBXML(strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(rates) != 3 {
+		t.Fatalf("Expected 3 rates, got %d", len(rates))
+	}
+	first := rates[0]
+	if first.BaseCurrency != "EUR" || first.QuoteCurrency != "USD" || first.Rate != 1.0299 || first.Date.Format("2006-01-02") != "2025-01-03" || first.Source != "ecb" {
+		t.Errorf("Unexpected first rate: %+v", first)
+	}
+}
+
+func TestParseCSV(t *testing.T) {
+	rates, err := ParseCSV(strings.NewReader("date,base_currency,quote_currency,rate\n2025-01-02,usd,cad,1.44\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(rates) != 1 || rates[0].BaseCurrency != "USD" || rates[0].QuoteCurrency != "CAD" || rates[0].Rate != 1.44 {
+		t.Errorf("Unexpected rates: %+v", rates)
+	}
+
+	if _, err := ParseCSV(strings.NewReader("2025-01-02,USD,CADX,1.44\n")); err == nil {
+		t.Error("Expected an error for an invalid currency code")
+	}
+}
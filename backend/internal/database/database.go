@@ -53,18 +53,43 @@ func AutoMigrate(db *gorm.DB) error {
 	err := db.AutoMigrate(
 		&models.User{},
 		&models.Budget{},
+		&models.BudgetMember{},
 		&models.Category{},
 		&models.Account{},
 		&models.Transaction{},
 		&models.CategoryBudget{},
+		&models.CategoryBudgetAmount{},
 		&models.CategoryBudgetSplit{},
 		&models.ExpectedIncome{},
 		&models.BudgetInvitation{},
+		&models.Goal{},
+		&models.GoalContribution{},
+		&models.LoanPayment{},
+		&models.NetWorthSnapshot{},
+		&models.NetWorthSnapshotItem{},
+		&models.InvestmentLot{},
+		&models.InvestmentTransaction{},
+		&models.SecurityPrice{},
+		&models.ExchangeRate{},
+		&models.Tag{},
+		&models.TransactionTag{},
+		&models.Attachment{},
+		&models.Reimbursement{},
+		&models.HiddenCategory{},
+		&models.CategoryParent{},
+		&models.BudgetTemplate{},
+		&models.BudgetTemplateItem{},
 	)
 	if err != nil {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
 
+	// Users without a view period of their own follow their budget's, so
+	// the column no longer defaults to monthly
+	if err := db.Exec("ALTER TABLE users ALTER COLUMN view_period DROP DEFAULT").Error; err != nil {
+		return fmt.Errorf("failed to drop view_period default: %w", err)
+	}
+
 	// For users table, just ensure the columns we need exist
 	log.Println("✓ Database migrations completed")
 	return nil
@@ -75,28 +100,32 @@ func SeedDefaultCategories(db *gorm.DB) error {
 
 	categories := []models.Category{
 		// Expenses
-		{Name: "Housing", Color: "#8B5CF6", Icon: "🏠", IsSystem: true},
-		{Name: "Utilities", Color: "#3B82F6", Icon: "⚡", IsSystem: true},
-		{Name: "Groceries", Color: "#10B981", Icon: "🛒", IsSystem: true},
-		{Name: "Dining & Restaurants", Color: "#F59E0B", Icon: "🍽️", IsSystem: true},
-		{Name: "Transportation", Color: "#EF4444", Icon: "🚗", IsSystem: true},
-		{Name: "Healthcare", Color: "#EC4899", Icon: "🏥", IsSystem: true},
-		{Name: "Entertainment", Color: "#6366F1", Icon: "🎬", IsSystem: true},
-		{Name: "Shopping", Color: "#8B5CF6", Icon: "🛍️", IsSystem: true},
-		{Name: "Personal Care", Color: "#14B8A6", Icon: "💆", IsSystem: true},
-		{Name: "Education", Color: "#F97316", Icon: "📚", IsSystem: true},
-		{Name: "Subscriptions", Color: "#A855F7", Icon: "📱", IsSystem: true},
-		{Name: "Insurance", Color: "#06B6D4", Icon: "🛡️", IsSystem: true},
-		{Name: "Savings", Color: "#22C55E", Icon: "💰", IsSystem: true},
-		{Name: "Debt Payments", Color: "#DC2626", Icon: "💳", IsSystem: true},
-		{Name: "Gifts & Donations", Color: "#F472B6", Icon: "🎁", IsSystem: true},
-		{Name: "Miscellaneous", Color: "#6B7280", Icon: "📦", IsSystem: true},
+		{Name: "Housing", Kind: "expense", Color: "#8B5CF6", Icon: "🏠", IsSystem: true},
+		{Name: "Utilities", Kind: "expense", Color: "#3B82F6", Icon: "⚡", IsSystem: true},
+		{Name: "Groceries", Kind: "expense", Color: "#10B981", Icon: "🛒", IsSystem: true},
+		{Name: "Dining & Restaurants", Kind: "expense", Color: "#F59E0B", Icon: "🍽️", IsSystem: true},
+		{Name: "Transportation", Kind: "expense", Color: "#EF4444", Icon: "🚗", IsSystem: true},
+		{Name: "Healthcare", Kind: "expense", Color: "#EC4899", Icon: "🏥", IsSystem: true},
+		{Name: "Entertainment", Kind: "expense", Color: "#6366F1", Icon: "🎬", IsSystem: true},
+		{Name: "Shopping", Kind: "expense", Color: "#8B5CF6", Icon: "🛍️", IsSystem: true},
+		{Name: "Personal Care", Kind: "expense", Color: "#14B8A6", Icon: "💆", IsSystem: true},
+		{Name: "Education", Kind: "expense", Color: "#F97316", Icon: "📚", IsSystem: true},
+		{Name: "Subscriptions", Kind: "expense", Color: "#A855F7", Icon: "📱", IsSystem: true},
+		{Name: "Insurance", Kind: "expense", Color: "#06B6D4", Icon: "🛡️", IsSystem: true},
+		{Name: "Savings", Kind: "expense", Color: "#22C55E", Icon: "💰", IsSystem: true},
+		{Name: "Debt Payments", Kind: "expense", Color: "#DC2626", Icon: "💳", IsSystem: true},
+		{Name: "Interest & Fees", Kind: "expense", Color: "#B91C1C", Icon: "🏦", IsSystem: true},
+		{Name: "Gifts & Donations", Kind: "expense", Color: "#F472B6", Icon: "🎁", IsSystem: true},
+		{Name: "Miscellaneous", Kind: "expense", Color: "#6B7280", Icon: "📦", IsSystem: true},
 
 		// Income
-		{Name: "Salary", Color: "#059669", Icon: "💵", IsSystem: true},
-		{Name: "Freelance", Color: "#0891B2", Icon: "💼", IsSystem: true},
-		{Name: "Investments", Color: "#7C3AED", Icon: "📈", IsSystem: true},
-		{Name: "Other Income", Color: "#84CC16", Icon: "💸", IsSystem: true},
+		{Name: "Salary", Kind: "income", Color: "#059669", Icon: "💵", IsSystem: true},
+		{Name: "Freelance", Kind: "income", Color: "#0891B2", Icon: "💼", IsSystem: true},
+		{Name: "Investments", Kind: "income", Color: "#7C3AED", Icon: "📈", IsSystem: true},
+		{Name: "Other Income", Kind: "income", Color: "#84CC16", Icon: "💸", IsSystem: true},
+
+		// Transfers
+		{Name: "Transfers", Kind: "transfer", Color: "#64748B", Icon: "🔁", IsSystem: true},
 	}
 
 	for _, category := range categories {
@@ -107,9 +136,84 @@ func SeedDefaultCategories(db *gorm.DB) error {
 			if err := db.Create(&category).Error; err != nil {
 				return fmt.Errorf("failed to seed category %s: %w", category.Name, err)
 			}
+		} else if result.Error == nil && existing.Kind != category.Kind {
+			// Categories seeded before kinds existed all default to expense
+			if err := db.Model(&existing).Update("kind", category.Kind).Error; err != nil {
+				return fmt.Errorf("failed to update category %s: %w", category.Name, err)
+			}
 		}
 	}
 
 	log.Println("✓ Default categories seeded")
 	return nil
 }
+
+// BackfillBudgetMembers records a membership for users who joined a budget
+// before users could belong to several. Budget creators become owners, even
+// when accepting an invitation overwrote their budget_id.
+func BackfillBudgetMembers(db *gorm.DB) error {
+	var users []models.User
+	if err := db.Where("budget_id IS NOT NULL").Find(&users).Error; err != nil {
+		return fmt.Errorf("failed to fetch users: %w", err)
+	}
+
+	for _, user := range users {
+		var count int64
+		if err := db.Model(&models.BudgetMember{}).
+			Where("budget_id = ? AND user_id = ?", *user.BudgetID, user.ID).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to check membership: %w", err)
+		}
+		if count > 0 {
+			continue
+		}
+
+		var budget models.Budget
+		if err := db.First(&budget, "id = ?", *user.BudgetID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				continue
+			}
+			return fmt.Errorf("failed to fetch budget: %w", err)
+		}
+		role := user.BudgetRole
+		if budget.CreatedBy == user.ID {
+			role = models.BudgetRoleOwner
+		}
+		if err := db.Create(&models.BudgetMember{BudgetID: budget.ID, UserID: user.ID, Role: role}).Error; err != nil {
+			return fmt.Errorf("failed to record membership: %w", err)
+		}
+	}
+
+	// Budgets still without an owner belong to their creator
+	var budgets []models.Budget
+	if err := db.Where("id NOT IN (?)",
+		db.Model(&models.BudgetMember{}).Select("budget_id").Where("role = ?", models.BudgetRoleOwner)).Find(&budgets).Error; err != nil {
+		return fmt.Errorf("failed to fetch budgets without an owner: %w", err)
+	}
+	for _, budget := range budgets {
+		var creator models.User
+		if err := db.First(&creator, "id = ?", budget.CreatedBy).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				continue
+			}
+			return fmt.Errorf("failed to fetch budget creator: %w", err)
+		}
+
+		var member models.BudgetMember
+		err := db.First(&member, "budget_id = ? AND user_id = ?", budget.ID, creator.ID).Error
+		if err == gorm.ErrRecordNotFound {
+			member = models.BudgetMember{BudgetID: budget.ID, UserID: creator.ID, Role: models.BudgetRoleOwner}
+			if err := db.Create(&member).Error; err != nil {
+				return fmt.Errorf("failed to record owner: %w", err)
+			}
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to check membership: %w", err)
+		}
+		if err := db.Model(&models.BudgetMember{}).Where("budget_id = ? AND user_id = ?", budget.ID, creator.ID).
+			Update("role", models.BudgetRoleOwner).Error; err != nil {
+			return fmt.Errorf("failed to record owner: %w", err)
+		}
+	}
+	return nil
+}
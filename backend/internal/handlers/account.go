@@ -2,9 +2,13 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/yourusername/folda-finances/internal/currency"
 	"github.com/yourusername/folda-finances/internal/middleware"
 	"github.com/yourusername/folda-finances/internal/models"
 	"gorm.io/gorm"
@@ -18,21 +22,48 @@ func NewAccountHandler(db *gorm.DB) *AccountHandler {
 	return &AccountHandler{db: db}
 }
 
+var validAccountTypes = map[string]bool{
+	"checking":    true,
+	"savings":     true,
+	"credit_card": true,
+	"loan":        true,
+	"mortgage":    true,
+	"cash":        true,
+	"investment":  true,
+	"other":       true,
+}
+
 type CreateAccountRequest struct {
-	Name     string `json:"name"`
-	Type     string `json:"type"`
-	Balance  int    `json:"balance"`
-	Currency string `json:"currency"`
-	Notes    string `json:"notes"`
+	Name           string   `json:"name"`
+	Type           string   `json:"type"`
+	Balance        *int     `json:"balance"`
+	Currency       string   `json:"currency"`
+	Notes          string   `json:"notes"`
+	APR            *float64 `json:"apr"`
+	MinimumPayment *int     `json:"minimum_payment"`
+	PaymentDueDay  *int     `json:"payment_due_day"`
+	LoanPrincipal  *int     `json:"loan_principal"`
+	LoanTermMonths *int     `json:"loan_term_months"`
+	LoanStartDate  string   `json:"loan_start_date"` // YYYY-MM-DD
+
+	StatementClosingDay *int `json:"statement_closing_day"`
 }
 
 type UpdateAccountRequest struct {
-	Name     *string `json:"name"`
-	Type     *string `json:"type"`
-	Balance  *int    `json:"balance"`
-	Currency *string `json:"currency"`
-	IsActive *bool   `json:"is_active"`
-	Notes    *string `json:"notes"`
+	Name           *string  `json:"name"`
+	Type           *string  `json:"type"`
+	Balance        *int     `json:"balance"`
+	Currency       *string  `json:"currency"`
+	IsActive       *bool    `json:"is_active"`
+	Notes          *string  `json:"notes"`
+	APR            *float64 `json:"apr"`
+	MinimumPayment *int     `json:"minimum_payment"`
+	PaymentDueDay  *int     `json:"payment_due_day"`
+	LoanPrincipal  *int     `json:"loan_principal"`
+	LoanTermMonths *int     `json:"loan_term_months"`
+	LoanStartDate  *string  `json:"loan_start_date"`
+
+	StatementClosingDay *int `json:"statement_closing_day"`
 }
 
 func (h *AccountHandler) ListAccounts(w http.ResponseWriter, r *http.Request) {
@@ -42,8 +73,8 @@ func (h *AccountHandler) ListAccounts(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var user models.User
-	if err := h.db.First(&user, "id = ?", userID).Error; err != nil {
+	user, err := requestUser(h.db, r, userID)
+	if err != nil {
 		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch user"})
 		return
 	}
@@ -53,8 +84,14 @@ func (h *AccountHandler) ListAccounts(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Closed accounts are left out unless asked for
+	query := h.db.Where("budget_id = ?", user.BudgetID)
+	if r.URL.Query().Get("include_closed") != "true" {
+		query = query.Where("is_active = ?", true)
+	}
+
 	var accounts []models.Account
-	if err := h.db.Where("budget_id = ?", user.BudgetID).Order("created_at DESC").Find(&accounts).Error; err != nil {
+	if err := query.Order("created_at DESC").Find(&accounts).Error; err != nil {
 		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch accounts"})
 		return
 	}
@@ -75,8 +112,8 @@ func (h *AccountHandler) CreateAccount(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var user models.User
-	if err := h.db.First(&user, "id = ?", userID).Error; err != nil {
+	user, err := requestUser(h.db, r, userID)
+	if err != nil {
 		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch user"})
 		return
 	}
@@ -87,32 +124,67 @@ func (h *AccountHandler) CreateAccount(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Validate account type
-	validTypes := map[string]bool{
-		"checking":   true,
-		"savings":    true,
-		"credit_card": true,
-		"cash":       true,
-		"investment": true,
-		"other":      true,
-	}
-	if !validTypes[req.Type] {
+	if !validAccountTypes[req.Type] {
 		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid account type"})
 		return
 	}
 
-	currency := req.Currency
-	if currency == "" {
-		currency = "USD"
+	// Default to the budget's base currency
+	accountCurrency := req.Currency
+	if accountCurrency == "" {
+		var budget models.Budget
+		if err := h.db.First(&budget, "id = ?", user.BudgetID).Error; err != nil {
+			respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch budget"})
+			return
+		}
+		accountCurrency = budget.BaseCurrency
+	}
+	accountCurrency, err = currency.Normalize(accountCurrency)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
 	}
 
 	account := models.Account{
-		BudgetID: *user.BudgetID,
-		Name:     req.Name,
-		Type:     req.Type,
-		Balance:  req.Balance,
-		Currency: currency,
-		IsActive: true,
-		Notes:    req.Notes,
+		BudgetID:       *user.BudgetID,
+		Name:           req.Name,
+		Type:           req.Type,
+		Currency:       accountCurrency,
+		IsActive:       true,
+		Notes:          req.Notes,
+		APR:            req.APR,
+		MinimumPayment: req.MinimumPayment,
+		PaymentDueDay:  req.PaymentDueDay,
+		LoanPrincipal:  req.LoanPrincipal,
+		LoanTermMonths: req.LoanTermMonths,
+
+		StatementClosingDay: req.StatementClosingDay,
+	}
+	if req.LoanStartDate != "" {
+		startDate, err := time.Parse("2006-01-02", req.LoanStartDate)
+		if err != nil {
+			respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid loan_start_date format"})
+			return
+		}
+		account.LoanStartDate = &startDate
+	}
+	if err := validateLiabilityDetails(account); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if err := validateLoanDetails(account); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	// Loans default to the balance the schedule says is still owed
+	if req.Balance != nil {
+		account.Balance = *req.Balance
+	} else if isLoan(account.Type) {
+		account.Balance = -scheduledLoanBalance(account, time.Now())
+	}
+	if isLoan(account.Type) {
+		applyLoanDefaults(&account)
 	}
 
 	if err := h.db.Create(&account).Error; err != nil {
@@ -141,8 +213,8 @@ func (h *AccountHandler) GetAccount(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Verify user has access
-	var user models.User
-	if err := h.db.First(&user, "id = ?", userID).Error; err != nil {
+	user, err := requestUser(h.db, r, userID)
+	if err != nil {
 		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch user"})
 		return
 	}
@@ -170,8 +242,8 @@ func (h *AccountHandler) UpdateAccount(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Verify user has access
-	var user models.User
-	if err := h.db.First(&user, "id = ?", userID).Error; err != nil {
+	user, err := requestUser(h.db, r, userID)
+	if err != nil {
 		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch user"})
 		return
 	}
@@ -192,15 +264,7 @@ func (h *AccountHandler) UpdateAccount(w http.ResponseWriter, r *http.Request) {
 		updates["name"] = *req.Name
 	}
 	if req.Type != nil {
-		validTypes := map[string]bool{
-			"checking":   true,
-			"savings":    true,
-			"credit_card": true,
-			"cash":       true,
-			"investment": true,
-			"other":      true,
-		}
-		if !validTypes[*req.Type] {
+		if !validAccountTypes[*req.Type] {
 			respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid account type"})
 			return
 		}
@@ -210,15 +274,105 @@ func (h *AccountHandler) UpdateAccount(w http.ResponseWriter, r *http.Request) {
 		updates["balance"] = *req.Balance
 	}
 	if req.Currency != nil {
-		updates["currency"] = *req.Currency
+		accountCurrency, err := currency.Normalize(*req.Currency)
+		if err != nil {
+			respondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		updates["currency"] = accountCurrency
 	}
-	if req.IsActive != nil {
+	if req.IsActive != nil && *req.IsActive != account.IsActive {
 		updates["is_active"] = *req.IsActive
+		if *req.IsActive {
+			updates["closed_at"] = nil
+		} else {
+			closedAt := currentDate()
+			if status, msg := validateClosingDate(h.db, account.ID, closedAt); status != 0 {
+				respondJSON(w, status, map[string]string{"error": msg})
+				return
+			}
+			updates["closed_at"] = closedAt
+		}
 	}
 	if req.Notes != nil {
 		updates["notes"] = *req.Notes
 	}
 
+	// Validate the liability details as they will be after the update
+	if req.Type != nil {
+		account.Type = *req.Type
+		if !isLiability(account.Type) {
+			account.APR, account.MinimumPayment, account.PaymentDueDay = nil, nil, nil
+			updates["apr"], updates["minimum_payment"], updates["payment_due_day"] = nil, nil, nil
+		}
+		if account.Type != "credit_card" {
+			account.StatementClosingDay = nil
+			updates["statement_closing_day"] = nil
+		}
+		if !isLoan(account.Type) {
+			account.LoanPrincipal, account.LoanTermMonths, account.LoanStartDate = nil, nil, nil
+			updates["loan_principal"], updates["loan_term_months"], updates["loan_start_date"] = nil, nil, nil
+		}
+	}
+	loanTermsChanged := false
+	if req.LoanPrincipal != nil {
+		account.LoanPrincipal = req.LoanPrincipal
+		loanTermsChanged = true
+	}
+	if req.LoanTermMonths != nil {
+		account.LoanTermMonths = req.LoanTermMonths
+		loanTermsChanged = true
+	}
+	if req.LoanStartDate != nil {
+		startDate, err := time.Parse("2006-01-02", *req.LoanStartDate)
+		if err != nil {
+			respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid loan_start_date format"})
+			return
+		}
+		account.LoanStartDate = &startDate
+		loanTermsChanged = true
+	}
+	if req.APR != nil && isLoan(account.Type) {
+		loanTermsChanged = true
+	}
+	if req.APR != nil {
+		account.APR = req.APR
+		updates["apr"] = *req.APR
+	}
+	if req.MinimumPayment != nil {
+		account.MinimumPayment = req.MinimumPayment
+		updates["minimum_payment"] = *req.MinimumPayment
+	}
+	if req.PaymentDueDay != nil {
+		account.PaymentDueDay = req.PaymentDueDay
+		updates["payment_due_day"] = *req.PaymentDueDay
+	}
+	if req.StatementClosingDay != nil {
+		account.StatementClosingDay = req.StatementClosingDay
+		updates["statement_closing_day"] = *req.StatementClosingDay
+	}
+	if err := validateLiabilityDetails(account); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if err := validateLoanDetails(account); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	// New loan terms mean a new monthly payment unless one was given
+	if isLoan(account.Type) && (loanTermsChanged || req.Type != nil) {
+		if req.MinimumPayment == nil && loanTermsChanged {
+			account.MinimumPayment = nil
+		}
+		applyLoanDefaults(&account)
+		updates["loan_principal"] = account.LoanPrincipal
+		updates["loan_term_months"] = account.LoanTermMonths
+		updates["loan_start_date"] = account.LoanStartDate
+		updates["minimum_payment"] = account.MinimumPayment
+		updates["payment_due_day"] = account.PaymentDueDay
+	}
+
 	if err := h.db.Model(&account).Updates(updates).Error; err != nil {
 		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to update account"})
 		return
@@ -235,38 +389,314 @@ func (h *AccountHandler) UpdateAccount(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// AccountDependents counts the records that reference an account
+type AccountDependents struct {
+	Transactions           int64 `json:"transactions"`
+	Goals                  int64 `json:"goals"`
+	LoanPayments           int64 `json:"loan_payments"`
+	InvestmentLots         int64 `json:"investment_lots"`
+	InvestmentTransactions int64 `json:"investment_transactions"`
+}
+
+// movable reports whether every dependent can be moved to another account.
+// Loan payments and investment records belong to the account's own schedule
+// and holdings.
+func (d AccountDependents) movable() bool {
+	return d.LoanPayments == 0 && d.InvestmentLots == 0 && d.InvestmentTransactions == 0
+}
+
+func (d AccountDependents) empty() bool {
+	return d.movable() && d.Transactions == 0 && d.Goals == 0
+}
+
+// DeleteAccount deletes an account nothing references. With reassign_to, its
+// transactions and goals move to that account first.
 func (h *AccountHandler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
-	userID, err := middleware.GetUserID(r)
+	account, user, ok := h.authorizedAccount(w, r)
+	if !ok {
+		return
+	}
+
+	var target *models.Account
+	if targetID := r.URL.Query().Get("reassign_to"); targetID != "" {
+		var reassignTo models.Account
+		if err := h.db.First(&reassignTo, "id = ?", targetID).Error; err != nil {
+			respondJSON(w, http.StatusBadRequest, map[string]string{"error": "reassign_to account not found"})
+			return
+		}
+		if reassignTo.BudgetID != *user.BudgetID {
+			respondJSON(w, http.StatusForbidden, map[string]string{"error": "access denied"})
+			return
+		}
+		if reassignTo.ID == account.ID {
+			respondJSON(w, http.StatusBadRequest, map[string]string{"error": "cannot reassign to the account being deleted"})
+			return
+		}
+		if !reassignTo.IsActive {
+			respondJSON(w, http.StatusBadRequest, map[string]string{"error": "cannot reassign to a closed account"})
+			return
+		}
+		target = &reassignTo
+	}
+
+	dependents, err := accountDependents(h.db, account.ID)
 	if err != nil {
-		respondJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to check account dependents"})
+		return
+	}
+	if !dependents.empty() && (target == nil || !dependents.movable()) {
+		message := "account has dependent records; reassign them with reassign_to or close the account instead"
+		if target != nil {
+			message = "loan payments and investment records cannot be reassigned; close the account instead"
+		}
+		respondJSON(w, http.StatusConflict, map[string]interface{}{
+			"error":      message,
+			"dependents": dependents,
+		})
 		return
 	}
 
-	accountID := chi.URLParam(r, "id")
-	var account models.Account
-	if err := h.db.First(&account, "id = ?", accountID).Error; err != nil {
-		respondJSON(w, http.StatusNotFound, map[string]string{"error": "account not found"})
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if target != nil {
+			if err := tx.Model(&models.Transaction{}).Where("account_id = ?", account.ID).Update("account_id", target.ID).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.Goal{}).Where("account_id = ?", account.ID).Update("account_id", target.ID).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&account).Error
+	})
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to delete account"})
 		return
 	}
 
-	// Verify user has access
-	var user models.User
-	if err := h.db.First(&user, "id = ?", userID).Error; err != nil {
-		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch user"})
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"message": "Account deleted successfully",
+	})
+}
+
+type CloseAccountRequest struct {
+	ClosedAt string `json:"closed_at"` // YYYY-MM-DD, defaults to today
+}
+
+// CloseAccount archives an account as of a closing date. It stays in history
+// but is hidden from account lists and takes no later transactions.
+func (h *AccountHandler) CloseAccount(w http.ResponseWriter, r *http.Request) {
+	account, _, ok := h.authorizedAccount(w, r)
+	if !ok {
 		return
 	}
 
-	if user.BudgetID == nil || *user.BudgetID != account.BudgetID {
-		respondJSON(w, http.StatusForbidden, map[string]string{"error": "access denied"})
+	var req CloseAccountRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+			return
+		}
+	}
+
+	if !account.IsActive {
+		respondJSON(w, http.StatusConflict, map[string]string{"error": "account is already closed"})
 		return
 	}
 
-	if err := h.db.Delete(&account).Error; err != nil {
-		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to delete account"})
+	closedAt := currentDate()
+	if req.ClosedAt != "" {
+		date, err := time.Parse("2006-01-02", req.ClosedAt)
+		if err != nil {
+			respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid closed_at format"})
+			return
+		}
+		closedAt = date
+	}
+
+	if status, msg := validateClosingDate(h.db, account.ID, closedAt); status != 0 {
+		respondJSON(w, status, map[string]string{"error": msg})
+		return
+	}
+
+	if err := h.db.Model(&account).Updates(map[string]interface{}{"is_active": false, "closed_at": closedAt}).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to close account"})
 		return
 	}
+	account.IsActive, account.ClosedAt = false, &closedAt
 
 	respondJSON(w, http.StatusOK, map[string]interface{}{
-		"message": "Account deleted successfully",
+		"data":    account,
+		"message": "Account closed successfully",
+	})
+}
+
+// validateClosingDate checks that nothing was recorded against an account
+// after the date it closes
+func validateClosingDate(db *gorm.DB, accountID uuid.UUID, closedAt time.Time) (int, string) {
+	var later int64
+	if err := db.Model(&models.Transaction{}).Where("account_id = ? AND date > ?", accountID, closedAt).Count(&later).Error; err != nil {
+		return http.StatusInternalServerError, "failed to check transactions"
+	}
+	if later > 0 {
+		return http.StatusBadRequest, "account has transactions after the closing date"
+	}
+	return 0, ""
+}
+
+// ReopenAccount undoes CloseAccount
+func (h *AccountHandler) ReopenAccount(w http.ResponseWriter, r *http.Request) {
+	account, _, ok := h.authorizedAccount(w, r)
+	if !ok {
+		return
+	}
+
+	if account.IsActive {
+		respondJSON(w, http.StatusConflict, map[string]string{"error": "account is not closed"})
+		return
+	}
+
+	if err := h.db.Model(&account).Updates(map[string]interface{}{"is_active": true, "closed_at": nil}).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to reopen account"})
+		return
+	}
+	account.IsActive, account.ClosedAt = true, nil
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data":    account,
+		"message": "Account reopened successfully",
 	})
 }
+
+func accountDependents(db *gorm.DB, accountID uuid.UUID) (AccountDependents, error) {
+	var dependents AccountDependents
+	counts := []struct {
+		model interface{}
+		count *int64
+	}{
+		{&models.Transaction{}, &dependents.Transactions},
+		{&models.Goal{}, &dependents.Goals},
+		{&models.LoanPayment{}, &dependents.LoanPayments},
+		{&models.InvestmentLot{}, &dependents.InvestmentLots},
+		{&models.InvestmentTransaction{}, &dependents.InvestmentTransactions},
+	}
+	for _, c := range counts {
+		if err := db.Model(c.model).Where("account_id = ?", accountID).Count(c.count).Error; err != nil {
+			return dependents, err
+		}
+	}
+	return dependents, nil
+}
+
+// accountOpenOn checks a transaction on date can be recorded against an
+// account, which it cannot after the account's closing date. A non-zero
+// status is the error response to send.
+func accountOpenOn(db *gorm.DB, accountID uuid.UUID, date time.Time) (int, string) {
+	var account models.Account
+	if err := db.First(&account, "id = ?", accountID).Error; err != nil {
+		return http.StatusBadRequest, "account not found"
+	}
+	if account.ClosedAt != nil && date.After(*account.ClosedAt) {
+		return http.StatusBadRequest, "account was closed on " + account.ClosedAt.Format("2006-01-02")
+	}
+	return 0, ""
+}
+
+// authorizedAccount loads the {id} account and checks the user shares its
+// budget, writing the error response when not
+func (h *AccountHandler) authorizedAccount(w http.ResponseWriter, r *http.Request) (models.Account, models.User, bool) {
+	var account models.Account
+	var user models.User
+
+	userID, err := middleware.GetUserID(r)
+	if err != nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return account, user, false
+	}
+
+	if err := h.db.First(&account, "id = ?", chi.URLParam(r, "id")).Error; err != nil {
+		respondJSON(w, http.StatusNotFound, map[string]string{"error": "account not found"})
+		return account, user, false
+	}
+
+	user, err = requestUser(h.db, r, userID)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch user"})
+		return account, user, false
+	}
+
+	if user.BudgetID == nil || *user.BudgetID != account.BudgetID {
+		respondJSON(w, http.StatusForbidden, map[string]string{"error": "access denied"})
+		return account, user, false
+	}
+
+	return account, user, true
+}
+
+// currentDate is today at midnight UTC, the way dates are stored
+func currentDate() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// isLiability reports whether an account type holds money owed rather than
+// money owned
+func isLiability(accountType string) bool {
+	return accountType == "credit_card" || isLoan(accountType)
+}
+
+// isLoan reports whether an account type is paid down on a fixed schedule
+func isLoan(accountType string) bool {
+	return accountType == "loan" || accountType == "mortgage"
+}
+
+// validateLiabilityDetails checks the APR, minimum payment and due day, which
+// only apply to liability accounts
+func validateLiabilityDetails(account models.Account) error {
+	if account.StatementClosingDay != nil {
+		if account.Type != "credit_card" {
+			return errors.New("statement_closing_day only applies to credit_card accounts")
+		}
+		if *account.StatementClosingDay < 1 || *account.StatementClosingDay > 31 {
+			return errors.New("statement_closing_day must be between 1 and 31")
+		}
+	}
+	if account.APR == nil && account.MinimumPayment == nil && account.PaymentDueDay == nil {
+		return nil
+	}
+	if !isLiability(account.Type) {
+		return errors.New("apr, minimum_payment and payment_due_day only apply to liability accounts")
+	}
+	if account.APR != nil && (*account.APR < 0 || *account.APR > 100) {
+		return errors.New("apr must be between 0 and 100")
+	}
+	if account.MinimumPayment != nil && *account.MinimumPayment < 0 {
+		return errors.New("minimum_payment cannot be negative")
+	}
+	if account.PaymentDueDay != nil && (*account.PaymentDueDay < 1 || *account.PaymentDueDay > 31) {
+		return errors.New("payment_due_day must be between 1 and 31")
+	}
+	return nil
+}
+
+// validateLoanDetails checks the loan terms, which loan and mortgage accounts
+// require and other accounts cannot have
+func validateLoanDetails(account models.Account) error {
+	if !isLoan(account.Type) {
+		if account.LoanPrincipal != nil || account.LoanTermMonths != nil || account.LoanStartDate != nil {
+			return errors.New("loan_principal, loan_term_months and loan_start_date only apply to loan and mortgage accounts")
+		}
+		return nil
+	}
+	if account.LoanPrincipal == nil || *account.LoanPrincipal <= 0 {
+		return errors.New("loan_principal must be greater than 0")
+	}
+	if account.LoanTermMonths == nil || *account.LoanTermMonths <= 0 || *account.LoanTermMonths > maxPayoffMonths {
+		return errors.New("loan_term_months must be between 1 and 600")
+	}
+	if account.LoanStartDate == nil {
+		return errors.New("loan_start_date is required")
+	}
+	if account.APR == nil {
+		return errors.New("apr is required for loans")
+	}
+	return nil
+}
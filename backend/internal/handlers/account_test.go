@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/yourusername/folda-finances/internal/models"
+)
+
+func TestCloseAccount(t *testing.T) {
+	db := setupTestDB(t)
+	handler := NewAccountHandler(db)
+	transactionHandler := NewTransactionHandler(db)
+
+	user, budget := createTestUser(t, db, "test@example.com")
+	category := createTestCategory(t, db, budget.ID, "Groceries")
+	account := &models.Account{ID: uuid.New(), BudgetID: budget.ID, Name: "Old Checking", Type: "checking", IsActive: true}
+	db.Create(account)
+	db.Create(&models.Transaction{ID: uuid.New(), UserID: user.ID, BudgetID: budget.ID, AccountID: &account.ID, CategoryID: category.ID, Amount: -2000, Date: testDate("2025-03-10")})
+
+	// Transactions after the closing date block it
+	w := httptest.NewRecorder()
+	handler.CloseAccount(w, testRequest("POST", "/accounts/"+account.ID.String()+"/close", CloseAccountRequest{ClosedAt: "2025-03-01"}, user.ID, map[string]string{"id": account.ID.String()}))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	handler.CloseAccount(w, testRequest("POST", "/accounts/"+account.ID.String()+"/close", CloseAccountRequest{ClosedAt: "2025-03-31"}, user.ID, map[string]string{"id": account.ID.String()}))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Closed accounts are hidden from the list unless asked for
+	for query, expected := range map[string]int{"": 0, "?include_closed=true": 1} {
+		w = httptest.NewRecorder()
+		handler.ListAccounts(w, testRequest("GET", "/accounts"+query, nil, user.ID, nil))
+		var response struct {
+			Data []models.Account `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+		if len(response.Data) != expected {
+			t.Errorf("%q: expected %d accounts, got %d", query, expected, len(response.Data))
+		}
+	}
+
+	// History can still be recorded up to the closing date, but not after
+	for date, expected := range map[string]int{"2025-03-31": http.StatusCreated, "2025-04-01": http.StatusBadRequest} {
+		body, _ := json.Marshal(CreateTransactionRequest{Amount: -500, CategoryID: category.ID.String(), AccountID: account.ID.String(), Date: date})
+		req := httptest.NewRequest("POST", "/transactions", bytes.NewBuffer(body))
+		req = req.WithContext(setUserIDContext(req, user.ID))
+		w = httptest.NewRecorder()
+		transactionHandler.CreateTransaction(w, req)
+		if w.Code != expected {
+			t.Errorf("%s: expected status %d, got %d: %s", date, expected, w.Code, w.Body.String())
+		}
+	}
+
+	w = httptest.NewRecorder()
+	handler.ReopenAccount(w, testRequest("POST", "/accounts/"+account.ID.String()+"/reopen", nil, user.ID, map[string]string{"id": account.ID.String()}))
+	var reopened models.Account
+	db.First(&reopened, "id = ?", account.ID)
+	if w.Code != http.StatusOK || !reopened.IsActive || reopened.ClosedAt != nil {
+		t.Errorf("Expected the account reopened, got %d: %+v", w.Code, reopened)
+	}
+
+	// Closing through an update is held to the same rule, as of today
+	db.Create(&models.Transaction{ID: uuid.New(), UserID: user.ID, BudgetID: budget.ID, AccountID: &account.ID, CategoryID: category.ID, Amount: -2000, Date: currentDate().AddDate(0, 0, 7)})
+	inactive := false
+	w = httptest.NewRecorder()
+	handler.UpdateAccount(w, testRequest("PUT", "/accounts/"+account.ID.String(), UpdateAccountRequest{IsActive: &inactive}, user.ID, map[string]string{"id": account.ID.String()}))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 closing with a later transaction, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestDeleteAccount(t *testing.T) {
+	db := setupTestDB(t)
+	handler := NewAccountHandler(db)
+
+	user, budget := createTestUser(t, db, "test@example.com")
+	category := createTestCategory(t, db, budget.ID, "Groceries")
+	account := &models.Account{ID: uuid.New(), BudgetID: budget.ID, Name: "Old Checking", Type: "checking", IsActive: true}
+	target := &models.Account{ID: uuid.New(), BudgetID: budget.ID, Name: "New Checking", Type: "checking", IsActive: true}
+	db.Create(account)
+	db.Create(target)
+	db.Create(&models.Transaction{ID: uuid.New(), UserID: user.ID, BudgetID: budget.ID, AccountID: &account.ID, CategoryID: category.ID, Amount: -2000, Date: testDate("2025-03-10")})
+	db.Create(&models.Goal{ID: uuid.New(), BudgetID: budget.ID, Name: "Holiday", TargetAmount: 100000, AccountID: &account.ID})
+
+	// Refused while records still point at the account
+	w := httptest.NewRecorder()
+	handler.DeleteAccount(w, testRequest("DELETE", "/accounts/"+account.ID.String(), nil, user.ID, map[string]string{"id": account.ID.String()}))
+	if w.Code != http.StatusConflict {
+		t.Fatalf("Expected status 409, got %d: %s", w.Code, w.Body.String())
+	}
+	var conflict struct {
+		Dependents AccountDependents `json:"dependents"`
+	}
+	json.NewDecoder(w.Body).Decode(&conflict)
+	if conflict.Dependents.Transactions != 1 || conflict.Dependents.Goals != 1 {
+		t.Errorf("Expected 1 transaction and 1 goal listed, got %+v", conflict.Dependents)
+	}
+
+	w = httptest.NewRecorder()
+	handler.DeleteAccount(w, testRequest("DELETE", "/accounts/"+account.ID.String()+"?reassign_to="+target.ID.String(), nil, user.ID, map[string]string{"id": account.ID.String()}))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var moved, goals, remaining int64
+	db.Model(&models.Transaction{}).Where("account_id = ?", target.ID).Count(&moved)
+	db.Model(&models.Goal{}).Where("account_id = ?", target.ID).Count(&goals)
+	db.Model(&models.Account{}).Where("id = ?", account.ID).Count(&remaining)
+	if moved != 1 || goals != 1 || remaining != 0 {
+		t.Errorf("Expected the transaction and goal moved and the account gone, got %d, %d, %d", moved, goals, remaining)
+	}
+}
+
+func TestAccountCurrency(t *testing.T) {
+	db := setupTestDB(t)
+	handler := NewAccountHandler(db)
+
+	user, budget := createTestUser(t, db, "test@example.com")
+	db.Model(budget).Update("base_currency", "EUR")
+
+	create := func(body CreateAccountRequest) (int, models.Account) {
+		w := httptest.NewRecorder()
+		handler.CreateAccount(w, testRequest("POST", "/accounts", body, user.ID, nil))
+		var response struct {
+			Data models.Account `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+		return w.Code, response.Data
+	}
+
+	// Accounts default to the budget's base currency, and codes are normalized
+	for requested, expected := range map[string]string{"": "EUR", " gbp ": "GBP"} {
+		code, account := create(CreateAccountRequest{Name: "Checking", Type: "checking", Currency: requested})
+		if code != http.StatusCreated || account.Currency != expected {
+			t.Errorf("%q: expected %s, got %d %q", requested, expected, code, account.Currency)
+		}
+	}
+	if code, _ := create(CreateAccountRequest{Name: "Checking", Type: "checking", Currency: "euro"}); code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid currency, got %d", code)
+	}
+
+	account := &models.Account{ID: uuid.New(), BudgetID: budget.ID, Name: "Savings", Type: "savings", Currency: "EUR", IsActive: true}
+	db.Create(account)
+	update := func(currency string) int {
+		w := httptest.NewRecorder()
+		handler.UpdateAccount(w, testRequest("PUT", "/accounts/"+account.ID.String(), UpdateAccountRequest{Currency: &currency}, user.ID, map[string]string{"id": account.ID.String()}))
+		return w.Code
+	}
+	if code := update("usd"); code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+	if code := update("US"); code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid currency, got %d", code)
+	}
+	var updated models.Account
+	db.First(&updated, "id = ?", account.ID)
+	if updated.Currency != "USD" {
+		t.Errorf("Expected USD, got %q", updated.Currency)
+	}
+}
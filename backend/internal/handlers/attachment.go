@@ -0,0 +1,333 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // register decoders for thumbnails
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/yourusername/folda-finances/internal/models"
+	"github.com/yourusername/folda-finances/internal/storage"
+	"gorm.io/gorm"
+)
+
+const (
+	maxAttachmentSize = 10 << 20 // 10 MB
+	thumbnailSize     = 256      // longest side, in pixels
+	maxThumbnailInput = 50e6     // pixels; larger images get no thumbnail
+)
+
+// Attachment types are sniffed from the file contents, not taken from the
+// client
+var allowedAttachmentTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+}
+
+type AttachmentHandler struct {
+	db    *gorm.DB
+	store storage.Store
+}
+
+func NewAttachmentHandler(db *gorm.DB, store storage.Store) *AttachmentHandler {
+	return &AttachmentHandler{db: db, store: store}
+}
+
+func (h *AttachmentHandler) ListAttachments(w http.ResponseWriter, r *http.Request) {
+	transaction, _, ok := authorizedTransaction(h.db, w, r)
+	if !ok {
+		return
+	}
+
+	var attachments []models.Attachment
+	if err := h.db.Where("transaction_id = ?", transaction.ID).Order("created_at ASC").Find(&attachments).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch attachments"})
+		return
+	}
+	for i := range attachments {
+		setAttachmentURLs(&attachments[i])
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"data": attachments})
+}
+
+// UploadAttachment stores the multipart "file" field against a transaction,
+// with a JPEG thumbnail for images Go can decode
+func (h *AttachmentHandler) UploadAttachment(w http.ResponseWriter, r *http.Request) {
+	transaction, userID, ok := authorizedTransaction(h.db, w, r)
+	if !ok {
+		return
+	}
+
+	// Leave room for the multipart headers around the file
+	r.Body = http.MaxBytesReader(w, r.Body, maxAttachmentSize+64<<10)
+	if err := r.ParseMultipartForm(1 << 20); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondJSON(w, http.StatusRequestEntityTooLarge, map[string]string{"error": "file exceeds the 10 MB limit"})
+			return
+		}
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid multipart form"})
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "file is required"})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxAttachmentSize+1))
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "failed to read file"})
+		return
+	}
+	if len(data) > maxAttachmentSize {
+		respondJSON(w, http.StatusRequestEntityTooLarge, map[string]string{"error": "file exceeds the 10 MB limit"})
+		return
+	}
+	if len(data) == 0 {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "file is empty"})
+		return
+	}
+
+	contentType := http.DetectContentType(data)
+	if !allowedAttachmentTypes[contentType] {
+		respondJSON(w, http.StatusUnsupportedMediaType, map[string]string{"error": "unsupported file type; upload a JPEG, PNG, GIF, WebP or PDF"})
+		return
+	}
+
+	attachment := models.Attachment{
+		ID:            uuid.New(),
+		BudgetID:      transaction.BudgetID,
+		TransactionID: transaction.ID,
+		UploadedBy:    userID,
+		FileName:      attachmentFileName(header.Filename),
+		ContentType:   contentType,
+		Size:          int64(len(data)),
+	}
+	attachment.StorageKey = fmt.Sprintf("budgets/%s/attachments/%s", attachment.BudgetID, attachment.ID)
+
+	ctx := r.Context()
+	if err := h.store.Put(ctx, attachment.StorageKey, bytes.NewReader(data), attachment.Size, contentType); err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to store file"})
+		return
+	}
+
+	// A missing thumbnail is not worth failing the upload over
+	if thumbnail, ok := makeThumbnail(data); ok {
+		thumbnailKey := attachment.StorageKey + "-thumbnail"
+		if err := h.store.Put(ctx, thumbnailKey, bytes.NewReader(thumbnail), int64(len(thumbnail)), "image/jpeg"); err == nil {
+			attachment.ThumbnailKey = thumbnailKey
+		}
+	}
+
+	if err := h.db.Create(&attachment).Error; err != nil {
+		h.deleteBlobs(r, attachment)
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to save attachment"})
+		return
+	}
+	setAttachmentURLs(&attachment)
+
+	respondJSON(w, http.StatusCreated, map[string]interface{}{
+		"data":    attachment,
+		"message": "Attachment uploaded successfully",
+	})
+}
+
+// DownloadAttachment streams the original file to a member of the budget
+func (h *AttachmentHandler) DownloadAttachment(w http.ResponseWriter, r *http.Request) {
+	attachment, ok := h.authorizedAttachment(w, r)
+	if !ok {
+		return
+	}
+
+	disposition := "attachment"
+	if attachment.ContentType != "application/pdf" {
+		disposition = "inline"
+	}
+	h.serveBlob(w, r, attachment.StorageKey, attachment.ContentType, attachment.Size,
+		mime.FormatMediaType(disposition, map[string]string{"filename": attachment.FileName}))
+}
+
+func (h *AttachmentHandler) GetAttachmentThumbnail(w http.ResponseWriter, r *http.Request) {
+	attachment, ok := h.authorizedAttachment(w, r)
+	if !ok {
+		return
+	}
+
+	if attachment.ThumbnailKey == "" {
+		respondJSON(w, http.StatusNotFound, map[string]string{"error": "attachment has no thumbnail"})
+		return
+	}
+	h.serveBlob(w, r, attachment.ThumbnailKey, "image/jpeg", 0, "inline")
+}
+
+// DeleteAttachment removes an attachment; only its uploader or the
+// transaction's owner may
+func (h *AttachmentHandler) DeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	transaction, userID, ok := authorizedTransaction(h.db, w, r)
+	if !ok {
+		return
+	}
+
+	var attachment models.Attachment
+	if err := h.db.First(&attachment, "id = ? AND transaction_id = ?", chi.URLParam(r, "attachmentId"), transaction.ID).Error; err != nil {
+		respondJSON(w, http.StatusNotFound, map[string]string{"error": "attachment not found"})
+		return
+	}
+
+	if attachment.UploadedBy != userID && transaction.UserID != userID {
+		respondJSON(w, http.StatusForbidden, map[string]string{"error": "can only delete your own attachments"})
+		return
+	}
+
+	if err := h.deleteBlobs(r, attachment); err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to delete file"})
+		return
+	}
+	if err := h.db.Delete(&attachment).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to delete attachment"})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"message": "Attachment deleted successfully",
+	})
+}
+
+func (h *AttachmentHandler) authorizedAttachment(w http.ResponseWriter, r *http.Request) (models.Attachment, bool) {
+	var attachment models.Attachment
+
+	transaction, _, ok := authorizedTransaction(h.db, w, r)
+	if !ok {
+		return attachment, false
+	}
+
+	if err := h.db.First(&attachment, "id = ? AND transaction_id = ?", chi.URLParam(r, "attachmentId"), transaction.ID).Error; err != nil {
+		respondJSON(w, http.StatusNotFound, map[string]string{"error": "attachment not found"})
+		return attachment, false
+	}
+
+	return attachment, true
+}
+
+func (h *AttachmentHandler) serveBlob(w http.ResponseWriter, r *http.Request, key, contentType string, size int64, disposition string) {
+	body, err := h.store.Get(r.Context(), key)
+	if errors.Is(err, storage.ErrNotFound) {
+		respondJSON(w, http.StatusNotFound, map[string]string{"error": "attachment file not found"})
+		return
+	}
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch file"})
+		return
+	}
+	defer body.Close()
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", disposition)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "private, max-age=3600")
+	if size > 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+	}
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, body)
+}
+
+func (h *AttachmentHandler) deleteBlobs(r *http.Request, attachment models.Attachment) error {
+	if attachment.ThumbnailKey != "" {
+		if err := h.store.Delete(r.Context(), attachment.ThumbnailKey); err != nil {
+			return err
+		}
+	}
+	return h.store.Delete(r.Context(), attachment.StorageKey)
+}
+
+// setAttachmentURLs points an attachment at the API routes that serve it;
+// they check budget membership like every other endpoint
+func setAttachmentURLs(attachment *models.Attachment) {
+	base := fmt.Sprintf("/api/transactions/%s/attachments/%s", attachment.TransactionID, attachment.ID)
+	attachment.DownloadURL = base
+	if attachment.ThumbnailKey != "" {
+		attachment.ThumbnailURL = base + "/thumbnail"
+	}
+}
+
+// attachmentFileName keeps the base name of an uploaded file, without any
+// directory the client sent
+func attachmentFileName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == "/" {
+		return "attachment"
+	}
+	if len(name) > 255 {
+		name = name[len(name)-255:]
+	}
+	return name
+}
+
+// makeThumbnail scales an image down to fit thumbnailSize, averaging the
+// pixels each thumbnail pixel covers, and encodes it as JPEG. The standard
+// library has no WebP decoder, so WebP images get no thumbnail.
+func makeThumbnail(data []byte) ([]byte, bool) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || config.Width == 0 || config.Height == 0 || config.Width*config.Height > maxThumbnailInput {
+		return nil, false
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, false
+	}
+
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	scale := float64(thumbnailSize) / float64(max(width, height))
+	if scale > 1 {
+		scale = 1
+	}
+	dstWidth, dstHeight := max(int(float64(width)*scale), 1), max(int(float64(height)*scale), 1)
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := 0; y < dstHeight; y++ {
+		y0, y1 := y*height/dstHeight, max((y+1)*height/dstHeight, y*height/dstHeight+1)
+		for x := 0; x < dstWidth; x++ {
+			x0, x1 := x*width/dstWidth, max((x+1)*width/dstWidth, x*width/dstWidth+1)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(bounds.Min.X+sx, bounds.Min.Y+sy).RGBA()
+					r, g, b, a, n = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca), n+1
+				}
+			}
+			// JPEG has no alpha, so transparent areas go white
+			white := 0xffff - a/n
+			dst.Set(x, y, color.RGBA64{R: uint16(r/n + white), G: uint16(g/n + white), B: uint16(b/n + white), A: 0xffff})
+		}
+	}
+
+	var out bytes.Buffer
+	if err := jpeg.Encode(&out, dst, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, false
+	}
+	return out.Bytes(), true
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/yourusername/folda-finances/internal/models"
+	"github.com/yourusername/folda-finances/internal/storage"
+)
+
+func attachmentRequest(method string, body *bytes.Buffer, contentType string, userID uuid.UUID, transactionID, attachmentID string) *http.Request {
+	if body == nil {
+		body = &bytes.Buffer{}
+	}
+	req := httptest.NewRequest(method, "/transactions/"+transactionID+"/attachments", body)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req = req.WithContext(setUserIDContext(req, userID))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", transactionID)
+	rctx.URLParams.Add("attachmentId", attachmentID)
+	return req.WithContext(setRouteContext(req, rctx))
+}
+
+func multipartFile(t *testing.T, name string, data []byte) (*bytes.Buffer, string) {
+	t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", name)
+	if err != nil {
+		t.Fatalf("Failed to create form file: %v", err)
+	}
+	part.Write(data)
+	writer.Close()
+	return &body, writer.FormDataContentType()
+}
+
+func TestAttachments(t *testing.T) {
+	db := setupTestDB(t)
+	store, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	handler := NewAttachmentHandler(db, store)
+	transactionHandler := NewTransactionHandler(db)
+
+	user, budget := createTestUser(t, db, "test@example.com")
+	outsider, _ := createTestUser(t, db, "outsider@example.com")
+	category := createTestCategory(t, db, budget.ID, "Groceries")
+	transaction := &models.Transaction{ID: uuid.New(), UserID: user.ID, BudgetID: budget.ID, CategoryID: category.ID, Amount: -4599, Date: testDate("2025-03-10")}
+	db.Create(transaction)
+	transactionID := transaction.ID.String()
+
+	// A 600x300 receipt photo
+	photo := image.NewRGBA(image.Rect(0, 0, 600, 300))
+	for x := 0; x < 600; x++ {
+		for y := 0; y < 300; y++ {
+			photo.Set(x, y, color.RGBA{R: 200, G: 50, B: 50, A: 255})
+		}
+	}
+	var photoData bytes.Buffer
+	png.Encode(&photoData, photo)
+
+	body, contentType := multipartFile(t, "C:\\Users\\me\\receipt.png", photoData.Bytes())
+	w := httptest.NewRecorder()
+	handler.UploadAttachment(w, attachmentRequest("POST", body, contentType, user.ID, transactionID, ""))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var response struct {
+		Data models.Attachment `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&response)
+	attachment := response.Data
+	if attachment.FileName != "receipt.png" || attachment.ContentType != "image/png" || attachment.ThumbnailURL == "" {
+		t.Errorf("Unexpected attachment: %+v", attachment)
+	}
+
+	// Members can download the file and its thumbnail
+	w = httptest.NewRecorder()
+	handler.DownloadAttachment(w, attachmentRequest("GET", nil, "", user.ID, transactionID, attachment.ID.String()))
+	if w.Code != http.StatusOK || !bytes.Equal(w.Body.Bytes(), photoData.Bytes()) {
+		t.Errorf("Expected the original file back, got %d with %d bytes", w.Code, w.Body.Len())
+	}
+
+	w = httptest.NewRecorder()
+	handler.GetAttachmentThumbnail(w, attachmentRequest("GET", nil, "", user.ID, transactionID, attachment.ID.String()))
+	thumbnail, _, err := image.DecodeConfig(w.Body)
+	if err != nil || thumbnail.Width != 256 || thumbnail.Height != 128 {
+		t.Errorf("Expected a 256x128 thumbnail, got %+v (%v)", thumbnail, err)
+	}
+
+	// Other budgets cannot
+	w = httptest.NewRecorder()
+	handler.DownloadAttachment(w, attachmentRequest("GET", nil, "", outsider.ID, transactionID, attachment.ID.String()))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for another budget, got %d", w.Code)
+	}
+
+	// Only allowed types and sizes are accepted
+	body, contentType = multipartFile(t, "notes.txt", []byte("not a receipt"))
+	w = httptest.NewRecorder()
+	handler.UploadAttachment(w, attachmentRequest("POST", body, contentType, user.ID, transactionID, ""))
+	if w.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("Expected status 415 for text, got %d", w.Code)
+	}
+
+	large := append([]byte("%PDF-1.4\n"), make([]byte, maxAttachmentSize)...)
+	body, contentType = multipartFile(t, "statement.pdf", large)
+	w = httptest.NewRecorder()
+	handler.UploadAttachment(w, attachmentRequest("POST", body, contentType, user.ID, transactionID, ""))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status 413 for an oversized file, got %d", w.Code)
+	}
+
+	// The transaction keeps its attachments until they are deleted
+	w = httptest.NewRecorder()
+	transactionHandler.DeleteTransaction(w, attachmentRequest("DELETE", nil, "", user.ID, transactionID, ""))
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 deleting a transaction with attachments, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.DeleteAttachment(w, attachmentRequest("DELETE", nil, "", user.ID, transactionID, attachment.ID.String()))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if _, err := store.Get(t.Context(), "budgets/"+budget.ID.String()+"/attachments/"+attachment.ID.String()); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected the stored file removed, got %v", err)
+	}
+}
@@ -2,10 +2,14 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"github.com/yourusername/folda-finances/internal/currency"
 	"github.com/yourusername/folda-finances/internal/middleware"
 	"github.com/yourusername/folda-finances/internal/models"
 	"gorm.io/gorm"
@@ -20,14 +24,74 @@ func NewBudgetHandler(db *gorm.DB) *BudgetHandler {
 }
 
 type CreateCategoryBudgetRequest struct {
-	CategoryID     string  `json:"category_id"`
-	Amount         int     `json:"amount"` // monthly amount in cents
-	AllocationType *string `json:"allocation_type"`
+	CategoryID          string   `json:"category_id"`
+	Amount              int      `json:"amount"` // monthly amount in cents
+	AllocationType      *string  `json:"allocation_type"`
+	WarningThreshold    *float64 `json:"warning_threshold"`
+	OverBudgetThreshold *float64 `json:"over_budget_threshold"`
+	IsFixedExpense      *bool    `json:"is_fixed_expense"`
+	BudgetType          *string  `json:"budget_type"`   // standard (default) or sinking_fund
+	TargetAmount        *int     `json:"target_amount"` // sinking fund target in cents
+	TargetDate          *string  `json:"target_date"`   // sinking fund due date, YYYY-MM-DD
 }
 
 type UpdateCategoryBudgetRequest struct {
-	Amount         *int    `json:"amount"`
-	AllocationType *string `json:"allocation_type"`
+	Amount              *int     `json:"amount"`
+	AmountMode          *string  `json:"amount_mode"`     // forward (default) or this_month
+	EffectiveMonth      *string  `json:"effective_month"` // YYYY-MM, defaults to the current month
+	AllocationType      *string  `json:"allocation_type"`
+	WarningThreshold    *float64 `json:"warning_threshold"`
+	OverBudgetThreshold *float64 `json:"over_budget_threshold"`
+	IsFixedExpense      *bool    `json:"is_fixed_expense"`
+	BudgetType          *string  `json:"budget_type"`
+	TargetAmount        *int     `json:"target_amount"`
+	TargetDate          *string  `json:"target_date"`
+}
+
+type UpdateBudgetThresholdsRequest struct {
+	WarningThreshold    *float64 `json:"warning_threshold"`
+	OverBudgetThreshold *float64 `json:"over_budget_threshold"`
+}
+
+type UpdateBudgetCurrencyRequest struct {
+	BaseCurrency string `json:"base_currency"`
+}
+
+type UpdateBudgetRefundsRequest struct {
+	RefundPeriod string `json:"refund_period"` // refund or original
+}
+
+type CreateBudgetRequest struct {
+	Name         string `json:"name"`
+	BaseCurrency string `json:"base_currency"` // defaults to USD
+}
+
+type UpdateBudgetRequest struct {
+	Name              *string `json:"name"`
+	BaseCurrency      *string `json:"base_currency"`
+	DefaultViewPeriod *string `json:"default_view_period"` // weekly, biweekly or monthly
+	WeekStartDay      *int    `json:"week_start_day"`      // 0 Sunday to 6 Saturday
+}
+
+// DeletedBudgetResponse is a deleted budget with when its data is purged
+type DeletedBudgetResponse struct {
+	models.Budget
+	PurgeAt time.Time `json:"purge_at"`
+}
+
+// UserBudget is a budget the user belongs to, with their role in it
+type UserBudget struct {
+	models.Budget
+	Role      string `json:"role"`
+	IsDefault bool   `json:"is_default"` // used by requests that select no budget
+}
+
+type BudgetMemberResponse struct {
+	UserID     string    `json:"user_id"`
+	Name       string    `json:"name"`
+	Email      string    `json:"email"`
+	BudgetRole string    `json:"budget_role"`
+	JoinedAt   time.Time `json:"joined_at"`
 }
 
 type CategoryBudgetSplitInput struct {
@@ -47,8 +111,8 @@ func (h *BudgetHandler) ListCategoryBudgets(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	var user models.User
-	if err := h.db.First(&user, "id = ?", userID).Error; err != nil {
+	user, err := requestUser(h.db, r, userID)
+	if err != nil {
 		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch user"})
 		return
 	}
@@ -64,6 +128,24 @@ func (h *BudgetHandler) ListCategoryBudgets(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	// Report the amounts that were in force in a past or future month
+	if monthParam := r.URL.Query().Get("month"); monthParam != "" {
+		month, err := time.Parse("2006-01", monthParam)
+		if err != nil {
+			respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid month format"})
+			return
+		}
+
+		amounts, err := categoryBudgetAmounts(h.db, budgets)
+		if err != nil {
+			respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch budget amounts"})
+			return
+		}
+		for i := range budgets {
+			budgets[i].Amount = amountForMonth(budgets[i], amounts[budgets[i].ID], month)
+		}
+	}
+
 	respondJSON(w, http.StatusOK, map[string]interface{}{"data": budgets})
 }
 
@@ -80,8 +162,8 @@ func (h *BudgetHandler) CreateCategoryBudget(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	var user models.User
-	if err := h.db.First(&user, "id = ?", userID).Error; err != nil {
+	user, err := requestUser(h.db, r, userID)
+	if err != nil {
 		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch user"})
 		return
 	}
@@ -96,6 +178,20 @@ func (h *BudgetHandler) CreateCategoryBudget(w http.ResponseWriter, r *http.Requ
 		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid category_id"})
 		return
 	}
+	category, status, msg := budgetCategory(h.db, categoryID, *user.BudgetID)
+	if status != 0 {
+		respondJSON(w, status, map[string]string{"error": msg})
+		return
+	}
+	if category.Kind != categoryKindExpense {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "only expense categories can be budgeted"})
+		return
+	}
+
+	if err := validateThresholds(req.WarningThreshold, req.OverBudgetThreshold); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
 
 	allocationType := "pooled"
 	if req.AllocationType != nil {
@@ -103,13 +199,52 @@ func (h *BudgetHandler) CreateCategoryBudget(w http.ResponseWriter, r *http.Requ
 	}
 
 	budget := models.CategoryBudget{
-		BudgetID:       *user.BudgetID,
-		CategoryID:     categoryID,
-		Amount:         req.Amount,
-		AllocationType: allocationType,
+		BudgetID:            *user.BudgetID,
+		CategoryID:          categoryID,
+		Amount:              req.Amount,
+		AllocationType:      allocationType,
+		WarningThreshold:    req.WarningThreshold,
+		OverBudgetThreshold: req.OverBudgetThreshold,
+	}
+	if req.IsFixedExpense != nil {
+		budget.IsFixedExpense = *req.IsFixedExpense
 	}
 
-	if err := h.db.Create(&budget).Error; err != nil {
+	budget.BudgetType = "standard"
+	if req.BudgetType != nil {
+		budget.BudgetType = *req.BudgetType
+	}
+	budget.TargetAmount = req.TargetAmount
+	if req.TargetDate != nil {
+		targetDate, err := time.Parse("2006-01-02", *req.TargetDate)
+		if err != nil {
+			respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid target_date format"})
+			return
+		}
+		budget.TargetDate = &targetDate
+	}
+	if err := validateBudgetType(budget); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	// A sinking fund's amount is its first monthly contribution
+	if isSinkingFund(budget) {
+		now := time.Now()
+		budget.Amount = sinkingFundContribution(*budget.TargetAmount, 0, monthStart(now), *budget.TargetDate)
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&budget).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.CategoryBudgetAmount{
+			CategoryBudgetID: budget.ID,
+			Amount:           budget.Amount,
+			EffectiveMonth:   monthStart(time.Now()),
+		}).Error
+	})
+	if err != nil {
 		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to create budget"})
 		return
 	}
@@ -135,8 +270,8 @@ func (h *BudgetHandler) UpdateCategoryBudget(w http.ResponseWriter, r *http.Requ
 	}
 
 	// Verify user has access
-	var user models.User
-	if err := h.db.First(&user, "id = ?", userID).Error; err != nil {
+	user, err := requestUser(h.db, r, userID)
+	if err != nil {
 		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch user"})
 		return
 	}
@@ -152,15 +287,85 @@ func (h *BudgetHandler) UpdateCategoryBudget(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	updates := map[string]interface{}{}
-	if req.Amount != nil {
-		updates["amount"] = *req.Amount
+	amountMode := "forward"
+	if req.AmountMode != nil {
+		amountMode = *req.AmountMode
+	}
+	if amountMode != "forward" && amountMode != "this_month" {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid amount_mode"})
+		return
 	}
+	effectiveMonth := monthStart(time.Now())
+	if req.EffectiveMonth != nil {
+		month, err := time.Parse("2006-01", *req.EffectiveMonth)
+		if err != nil {
+			respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid effective_month format"})
+			return
+		}
+		effectiveMonth = month
+	}
+
+	updates := map[string]interface{}{}
 	if req.AllocationType != nil {
 		updates["allocation_type"] = *req.AllocationType
 	}
 
-	if err := h.db.Model(&budget).Updates(updates).Error; err != nil {
+	// Validate the thresholds as they will be after the update
+	warningThreshold := budget.WarningThreshold
+	if req.WarningThreshold != nil {
+		warningThreshold = req.WarningThreshold
+		updates["warning_threshold"] = *req.WarningThreshold
+	}
+	overBudgetThreshold := budget.OverBudgetThreshold
+	if req.OverBudgetThreshold != nil {
+		overBudgetThreshold = req.OverBudgetThreshold
+		updates["over_budget_threshold"] = *req.OverBudgetThreshold
+	}
+	if err := validateThresholds(warningThreshold, overBudgetThreshold); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if req.IsFixedExpense != nil {
+		updates["is_fixed_expense"] = *req.IsFixedExpense
+	}
+
+	// Validate the sinking fund settings as they will be after the update
+	if req.BudgetType != nil {
+		budget.BudgetType = *req.BudgetType
+		updates["budget_type"] = *req.BudgetType
+	}
+	if req.TargetAmount != nil {
+		budget.TargetAmount = req.TargetAmount
+		updates["target_amount"] = *req.TargetAmount
+	}
+	if req.TargetDate != nil {
+		targetDate, err := time.Parse("2006-01-02", *req.TargetDate)
+		if err != nil {
+			respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid target_date format"})
+			return
+		}
+		budget.TargetDate = &targetDate
+		updates["target_date"] = targetDate
+	}
+	if err := validateBudgetType(budget); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if req.Amount != nil {
+			amount, err := setCategoryBudgetAmount(tx, budget, *req.Amount, effectiveMonth, amountMode == "this_month")
+			if err != nil {
+				return err
+			}
+			updates["amount"] = amount
+		}
+		if len(updates) == 0 {
+			return nil
+		}
+		return tx.Model(&budget).Updates(updates).Error
+	})
+	if err != nil {
 		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to update budget"})
 		return
 	}
@@ -191,8 +396,8 @@ func (h *BudgetHandler) DeleteCategoryBudget(w http.ResponseWriter, r *http.Requ
 	}
 
 	// Verify user has access
-	var user models.User
-	if err := h.db.First(&user, "id = ?", userID).Error; err != nil {
+	user, err := requestUser(h.db, r, userID)
+	if err != nil {
 		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch user"})
 		return
 	}
@@ -202,7 +407,10 @@ func (h *BudgetHandler) DeleteCategoryBudget(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	if err := h.db.Delete(&budget).Error; err != nil {
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		return deleteCategoryBudget(tx, budget, nil)
+	})
+	if err != nil {
 		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to delete budget"})
 		return
 	}
@@ -212,6 +420,42 @@ func (h *BudgetHandler) DeleteCategoryBudget(w http.ResponseWriter, r *http.Requ
 	})
 }
 
+// GetCategoryBudgetAmounts lists the amount history of a category budget
+func (h *BudgetHandler) GetCategoryBudgetAmounts(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserID(r)
+	if err != nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+
+	categoryBudgetID := chi.URLParam(r, "id")
+	var categoryBudget models.CategoryBudget
+	if err := h.db.First(&categoryBudget, "id = ?", categoryBudgetID).Error; err != nil {
+		respondJSON(w, http.StatusNotFound, map[string]string{"error": "category budget not found"})
+		return
+	}
+
+	user, err := requestUser(h.db, r, userID)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch user"})
+		return
+	}
+
+	if user.BudgetID == nil || *user.BudgetID != categoryBudget.BudgetID {
+		respondJSON(w, http.StatusForbidden, map[string]string{"error": "access denied"})
+		return
+	}
+
+	var amounts []models.CategoryBudgetAmount
+	if err := h.db.Where("category_budget_id = ?", categoryBudget.ID).
+		Order("effective_month ASC, only_this_month ASC").Find(&amounts).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch budget amounts"})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"data": amounts})
+}
+
 // GetCategoryBudgetSplits retrieves the split allocations for a category budget
 func (h *BudgetHandler) GetCategoryBudgetSplits(w http.ResponseWriter, r *http.Request) {
 	userID, err := middleware.GetUserID(r)
@@ -228,8 +472,8 @@ func (h *BudgetHandler) GetCategoryBudgetSplits(w http.ResponseWriter, r *http.R
 	}
 
 	// Verify user has access to this budget
-	var user models.User
-	if err := h.db.First(&user, "id = ?", userID).Error; err != nil {
+	user, err := requestUser(h.db, r, userID)
+	if err != nil {
 		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch user"})
 		return
 	}
@@ -271,8 +515,8 @@ func (h *BudgetHandler) UpdateCategoryBudgetSplits(w http.ResponseWriter, r *htt
 	}
 
 	// Verify user has access to this budget
-	var user models.User
-	if err := h.db.First(&user, "id = ?", userID).Error; err != nil {
+	user, err := requestUser(h.db, r, userID)
+	if err != nil {
 		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch user"})
 		return
 	}
@@ -302,14 +546,12 @@ func (h *BudgetHandler) UpdateCategoryBudgetSplits(w http.ResponseWriter, r *htt
 			return
 		}
 
-		var splitUser models.User
-		if err := h.db.First(&splitUser, "id = ?", splitUserID).Error; err != nil {
-			respondJSON(w, http.StatusBadRequest, map[string]string{"error": "user not found in splits"})
-			return
-		}
-
-		if splitUser.BudgetID == nil || *splitUser.BudgetID != categoryBudget.BudgetID {
-			respondJSON(w, http.StatusBadRequest, map[string]string{"error": "all users must belong to the same budget"})
+		if _, err := budgetMember(h.db, categoryBudget.BudgetID, splitUserID); err != nil {
+			if err == gorm.ErrRecordNotFound {
+				respondJSON(w, http.StatusBadRequest, map[string]string{"error": "all users must belong to the same budget"})
+				return
+			}
+			respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch budget members"})
 			return
 		}
 	}
@@ -359,23 +601,671 @@ func (h *BudgetHandler) GetBudgetMembers(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	var user models.User
-	if err := h.db.First(&user, "id = ?", userID).Error; err != nil {
+	user, err := requestUser(h.db, r, userID)
+	if err != nil {
 		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch user"})
 		return
 	}
 
 	if user.BudgetID == nil {
-		respondJSON(w, http.StatusOK, map[string]interface{}{"data": []models.User{}})
+		respondJSON(w, http.StatusOK, map[string]interface{}{"data": []BudgetMemberResponse{}})
 		return
 	}
 
-	// Get all users in the same budget
-	var members []models.User
-	if err := h.db.Where("budget_id = ?", user.BudgetID).Find(&members).Error; err != nil {
+	members, err := budgetMembers(h.db, *user.BudgetID)
+	if err != nil {
 		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch budget members"})
 		return
 	}
 
 	respondJSON(w, http.StatusOK, map[string]interface{}{"data": members})
 }
+
+// ListBudgets returns the budgets the user belongs to with their role in
+// each, for switching between them
+func (h *BudgetHandler) ListBudgets(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserID(r)
+	if err != nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+
+	var user models.User
+	if err := h.db.First(&user, "id = ?", userID).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch user"})
+		return
+	}
+
+	var memberships []models.BudgetMember
+	if err := h.db.Where("user_id = ?", userID).Find(&memberships).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch budgets"})
+		return
+	}
+	roles := make(map[uuid.UUID]string, len(memberships))
+	budgetIDs := make([]uuid.UUID, len(memberships))
+	for i, membership := range memberships {
+		roles[membership.BudgetID] = membership.Role
+		budgetIDs[i] = membership.BudgetID
+	}
+
+	var budgets []models.Budget
+	if len(budgetIDs) > 0 {
+		if err := h.db.Where("id IN ?", budgetIDs).Order("name ASC").Find(&budgets).Error; err != nil {
+			respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch budgets"})
+			return
+		}
+	}
+
+	userBudgets := make([]UserBudget, len(budgets))
+	for i, budget := range budgets {
+		userBudgets[i] = UserBudget{
+			Budget:    budget,
+			Role:      roles[budget.ID],
+			IsDefault: user.BudgetID != nil && *user.BudgetID == budget.ID,
+		}
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"data": userBudgets})
+}
+
+// CreateBudget starts a new budget owned by the user. It becomes their default
+// budget if they have none.
+func (h *BudgetHandler) CreateBudget(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserID(r)
+	if err != nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+
+	var req CreateBudgetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "name is required"})
+		return
+	}
+
+	var user models.User
+	if err := h.db.First(&user, "id = ?", userID).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch user"})
+		return
+	}
+
+	budget := models.Budget{
+		Name:      req.Name,
+		CreatedBy: userID,
+	}
+	if req.BaseCurrency != "" {
+		baseCurrency, err := currency.Normalize(req.BaseCurrency)
+		if err != nil {
+			respondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		budget.BaseCurrency = baseCurrency
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&budget).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&models.BudgetMember{BudgetID: budget.ID, UserID: userID, Role: models.BudgetRoleOwner}).Error; err != nil {
+			return err
+		}
+		if user.BudgetID == nil {
+			return tx.Model(&user).Update("budget_id", budget.ID).Error
+		}
+		return nil
+	})
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to create budget"})
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, map[string]interface{}{
+		"data": UserBudget{
+			Budget:    budget,
+			Role:      models.BudgetRoleOwner,
+			IsDefault: user.BudgetID == nil,
+		},
+		"message": "Budget created successfully",
+	})
+}
+
+// GetBudget returns the selected budget with the user's role in it
+func (h *BudgetHandler) GetBudget(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserID(r)
+	if err != nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+
+	userBudget, ok := h.userBudget(w, r, userID)
+	if !ok {
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"data": userBudget})
+}
+
+// UpdateBudget renames the selected budget and changes its settings. Only the
+// fields sent are changed.
+func (h *BudgetHandler) UpdateBudget(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserID(r)
+	if err != nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+
+	var req UpdateBudgetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+
+	updates := make(map[string]interface{})
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			respondJSON(w, http.StatusBadRequest, map[string]string{"error": "name cannot be empty"})
+			return
+		}
+		updates["name"] = name
+	}
+	if req.BaseCurrency != nil {
+		baseCurrency, err := currency.Normalize(*req.BaseCurrency)
+		if err != nil {
+			respondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		updates["base_currency"] = baseCurrency
+	}
+	if req.DefaultViewPeriod != nil {
+		if *req.DefaultViewPeriod != "weekly" && *req.DefaultViewPeriod != "biweekly" && *req.DefaultViewPeriod != "monthly" {
+			respondJSON(w, http.StatusBadRequest, map[string]string{"error": "default_view_period must be weekly, biweekly or monthly"})
+			return
+		}
+		updates["default_view_period"] = *req.DefaultViewPeriod
+	}
+	if req.WeekStartDay != nil {
+		if *req.WeekStartDay < 0 || *req.WeekStartDay > 6 {
+			respondJSON(w, http.StatusBadRequest, map[string]string{"error": "week_start_day must be between 0 (Sunday) and 6 (Saturday)"})
+			return
+		}
+		updates["week_start_day"] = *req.WeekStartDay
+	}
+
+	userBudget, ok := h.userBudget(w, r, userID)
+	if !ok {
+		return
+	}
+
+	if len(updates) > 0 {
+		if err := h.db.Model(&models.Budget{}).Where("id = ?", userBudget.ID).Updates(updates).Error; err != nil {
+			respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to update budget"})
+			return
+		}
+	}
+
+	if err := h.db.First(&userBudget.Budget, "id = ?", userBudget.ID).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch updated budget"})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data":    userBudget,
+		"message": "Budget updated successfully",
+	})
+}
+
+// DeleteBudget deactivates the {budgetId} budget. Only the owner can. Its data
+// is kept for a grace period, during which the owner can restore it, and
+// purged after.
+func (h *BudgetHandler) DeleteBudget(w http.ResponseWriter, r *http.Request) {
+	userID, budgetID, ok := budgetPathIDs(w, r)
+	if !ok {
+		return
+	}
+	if !requireBudgetOwner(h.db, w, budgetID, userID, "delete the budget") {
+		return
+	}
+
+	var budget models.Budget
+	if err := h.db.First(&budget, "id = ?", budgetID).Error; err != nil {
+		respondJSON(w, http.StatusNotFound, map[string]string{"error": "budget not found"})
+		return
+	}
+
+	now := time.Now()
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		return deactivateBudget(tx, budget.ID, now)
+	}); err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to delete budget"})
+		return
+	}
+	budget.IsActive = false
+	budget.DeactivatedAt = &now
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data":    DeletedBudgetResponse{Budget: budget, PurgeAt: now.Add(budgetPurgeGracePeriod)},
+		"message": "Budget deleted, it can be restored until it is purged",
+	})
+}
+
+// RestoreBudget reactivates a deleted budget that has not been purged yet.
+// Only the owner can. It becomes their default budget if they have none.
+func (h *BudgetHandler) RestoreBudget(w http.ResponseWriter, r *http.Request) {
+	userID, budgetID, ok := budgetPathIDs(w, r)
+	if !ok {
+		return
+	}
+	if !requireBudgetOwner(h.db, w, budgetID, userID, "restore the budget") {
+		return
+	}
+
+	var budget models.Budget
+	if err := h.db.First(&budget, "id = ?", budgetID).Error; err != nil {
+		respondJSON(w, http.StatusNotFound, map[string]string{"error": "budget not found"})
+		return
+	}
+	if budget.IsActive {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "budget has not been deleted"})
+		return
+	}
+
+	var user models.User
+	if err := h.db.First(&user, "id = ?", userID).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch user"})
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{"is_active": true, "deactivated_at": nil}
+		if err := tx.Model(&budget).Updates(updates).Error; err != nil {
+			return err
+		}
+		if user.BudgetID == nil {
+			return tx.Model(&user).Update("budget_id", budget.ID).Error
+		}
+		return nil
+	})
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to restore budget"})
+		return
+	}
+	budget.IsActive = true
+	budget.DeactivatedAt = nil
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data": UserBudget{
+			Budget:    budget,
+			Role:      models.BudgetRoleOwner,
+			IsDefault: user.BudgetID == nil || *user.BudgetID == budget.ID,
+		},
+		"message": "Budget restored successfully",
+	})
+}
+
+// userBudget loads the budget the request acts on with the user's role in it,
+// writing the error response when there is none
+func (h *BudgetHandler) userBudget(w http.ResponseWriter, r *http.Request, userID uuid.UUID) (UserBudget, bool) {
+	user, err := requestUser(h.db, r, userID)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch user"})
+		return UserBudget{}, false
+	}
+	if user.BudgetID == nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "user does not belong to a budget"})
+		return UserBudget{}, false
+	}
+
+	var budget models.Budget
+	if err := h.db.First(&budget, "id = ?", *user.BudgetID).Error; err != nil {
+		respondJSON(w, http.StatusNotFound, map[string]string{"error": "budget not found"})
+		return UserBudget{}, false
+	}
+
+	// requestUser swaps in the selected budget, the stored one is the default
+	var stored models.User
+	if err := h.db.Select("id", "budget_id").First(&stored, "id = ?", userID).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch user"})
+		return UserBudget{}, false
+	}
+
+	return UserBudget{
+		Budget:    budget,
+		Role:      user.BudgetRole,
+		IsDefault: stored.BudgetID != nil && *stored.BudgetID == budget.ID,
+	}, true
+}
+
+// UpdateBudgetThresholds sets the budget-wide warning and over-budget
+// percentages used by categories without their own overrides
+func (h *BudgetHandler) UpdateBudgetThresholds(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserID(r)
+	if err != nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+
+	var req UpdateBudgetThresholdsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+
+	user, err := requestUser(h.db, r, userID)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch user"})
+		return
+	}
+
+	if user.BudgetID == nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "user does not belong to a budget"})
+		return
+	}
+
+	var budget models.Budget
+	if err := h.db.First(&budget, "id = ?", user.BudgetID).Error; err != nil {
+		respondJSON(w, http.StatusNotFound, map[string]string{"error": "budget not found"})
+		return
+	}
+
+	warningThreshold := budget.WarningThreshold
+	if req.WarningThreshold != nil {
+		warningThreshold = *req.WarningThreshold
+	}
+	overBudgetThreshold := budget.OverBudgetThreshold
+	if req.OverBudgetThreshold != nil {
+		overBudgetThreshold = *req.OverBudgetThreshold
+	}
+	if err := validateThresholds(&warningThreshold, &overBudgetThreshold); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	updates := map[string]interface{}{
+		"warning_threshold":     warningThreshold,
+		"over_budget_threshold": overBudgetThreshold,
+	}
+	if err := h.db.Model(&budget).Updates(updates).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to update thresholds"})
+		return
+	}
+
+	if err := h.db.First(&budget, "id = ?", budget.ID).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch updated budget"})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data":    budget,
+		"message": "Thresholds updated successfully",
+	})
+}
+
+// UpdateBudgetCurrency sets the currency spending summaries convert to
+func (h *BudgetHandler) UpdateBudgetCurrency(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserID(r)
+	if err != nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+
+	var req UpdateBudgetCurrencyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+
+	baseCurrency, err := currency.Normalize(req.BaseCurrency)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	user, err := requestUser(h.db, r, userID)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch user"})
+		return
+	}
+
+	if user.BudgetID == nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "user does not belong to a budget"})
+		return
+	}
+
+	var budget models.Budget
+	if err := h.db.First(&budget, "id = ?", user.BudgetID).Error; err != nil {
+		respondJSON(w, http.StatusNotFound, map[string]string{"error": "budget not found"})
+		return
+	}
+
+	if err := h.db.Model(&budget).Update("base_currency", baseCurrency).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to update base currency"})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data":    budget,
+		"message": "Base currency updated successfully",
+	})
+}
+
+// UpdateBudgetRefunds chooses the period refunds of a linked purchase net
+// against spending in
+func (h *BudgetHandler) UpdateBudgetRefunds(w http.ResponseWriter, r *http.Request) {
+	budgetID, ok := userBudgetID(h.db, w, r)
+	if !ok {
+		return
+	}
+
+	var req UpdateBudgetRefundsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+	if req.RefundPeriod != RefundPeriodRefund && req.RefundPeriod != RefundPeriodOriginal {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "refund_period must be refund or original"})
+		return
+	}
+
+	var budget models.Budget
+	if err := h.db.First(&budget, "id = ?", budgetID).Error; err != nil {
+		respondJSON(w, http.StatusNotFound, map[string]string{"error": "budget not found"})
+		return
+	}
+
+	if err := h.db.Model(&budget).Update("refund_period", req.RefundPeriod).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to update refund period"})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data":    budget,
+		"message": "Refund period updated successfully",
+	})
+}
+
+// validateBudgetType checks that sinking funds have a target to save towards
+func validateBudgetType(categoryBudget models.CategoryBudget) error {
+	switch categoryBudget.BudgetType {
+	case "", "standard":
+		return nil
+	case "sinking_fund":
+		if categoryBudget.TargetAmount == nil || *categoryBudget.TargetAmount <= 0 {
+			return errors.New("sinking funds require a positive target_amount")
+		}
+		if categoryBudget.TargetDate == nil {
+			return errors.New("sinking funds require a target_date")
+		}
+		return nil
+	default:
+		return errors.New("invalid budget_type")
+	}
+}
+
+// maxThreshold is the largest percentage the decimal(5,2) threshold columns hold
+const maxThreshold = 999.99
+
+// validateThresholds checks a warning/over-budget percentage pair; either side
+// may be nil when it is not being set
+func validateThresholds(warning, overBudget *float64) error {
+	if (warning != nil && *warning <= 0) || (overBudget != nil && *overBudget <= 0) {
+		return errors.New("thresholds must be greater than zero")
+	}
+	if (warning != nil && *warning > maxThreshold) || (overBudget != nil && *overBudget > maxThreshold) {
+		return errors.New("thresholds cannot exceed 999.99")
+	}
+	if warning != nil && overBudget != nil && *warning > *overBudget {
+		return errors.New("warning_threshold cannot exceed over_budget_threshold")
+	}
+	return nil
+}
+
+// monthStart returns the first day of t's month
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// categoryBudgetAmounts loads the amount history of the given category
+// budgets, keyed by category budget ID
+func categoryBudgetAmounts(db *gorm.DB, categoryBudgets []models.CategoryBudget) (map[uuid.UUID][]models.CategoryBudgetAmount, error) {
+	byBudget := make(map[uuid.UUID][]models.CategoryBudgetAmount)
+	if len(categoryBudgets) == 0 {
+		return byBudget, nil
+	}
+
+	ids := make([]uuid.UUID, len(categoryBudgets))
+	for i, cb := range categoryBudgets {
+		ids[i] = cb.ID
+	}
+
+	var amounts []models.CategoryBudgetAmount
+	if err := db.Where("category_budget_id IN ?", ids).Find(&amounts).Error; err != nil {
+		return nil, err
+	}
+	for _, amount := range amounts {
+		byBudget[amount.CategoryBudgetID] = append(byBudget[amount.CategoryBudgetID], amount)
+	}
+	return byBudget, nil
+}
+
+// amountForMonth returns the category budget amount in force during month:
+// a one-off override for that month, else the latest amount effective on or
+// before it. Months before the first recorded amount use the earliest one, and
+// budgets without any history fall back to their current amount.
+func amountForMonth(categoryBudget models.CategoryBudget, amounts []models.CategoryBudgetAmount, month time.Time) int {
+	month = monthStart(month)
+
+	var current, earliest *models.CategoryBudgetAmount
+	for i := range amounts {
+		amount := &amounts[i]
+		effective := monthStart(amount.EffectiveMonth)
+		if amount.OnlyThisMonth {
+			if effective.Equal(month) {
+				return amount.Amount
+			}
+			continue
+		}
+		if earliest == nil || effective.Before(monthStart(earliest.EffectiveMonth)) {
+			earliest = amount
+		}
+		if !effective.After(month) && (current == nil || effective.After(monthStart(current.EffectiveMonth))) {
+			current = amount
+		}
+	}
+
+	if current != nil {
+		return current.Amount
+	}
+	if earliest != nil {
+		return earliest.Amount
+	}
+	return categoryBudget.Amount
+}
+
+// deleteCategoryBudget deletes a category budget with its amount history and
+// splits, moving goals funded from it to replacement, or unlinking them
+func deleteCategoryBudget(tx *gorm.DB, categoryBudget models.CategoryBudget, replacement *uuid.UUID) error {
+	if err := tx.Where("category_budget_id = ?", categoryBudget.ID).Delete(&models.CategoryBudgetAmount{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("category_budget_id = ?", categoryBudget.ID).Delete(&models.CategoryBudgetSplit{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.Goal{}).Where("category_budget_id = ?", categoryBudget.ID).
+		Update("category_budget_id", replacement).Error; err != nil {
+		return err
+	}
+	return tx.Delete(&categoryBudget).Error
+}
+
+// setCategoryBudgetAmount records a new amount effective from month, either
+// from that month forward or as a one-off for that month only, and returns
+// the amount now in force for the current month.
+func setCategoryBudgetAmount(tx *gorm.DB, categoryBudget models.CategoryBudget, amount int, month time.Time, onlyThisMonth bool) (int, error) {
+	month = monthStart(month)
+
+	var amounts []models.CategoryBudgetAmount
+	if err := tx.Where("category_budget_id = ?", categoryBudget.ID).Find(&amounts).Error; err != nil {
+		return 0, err
+	}
+
+	// Budgets created before amounts were versioned get their existing amount
+	// recorded from their creation month, so earlier months keep it
+	hasBaseline := false
+	for _, existing := range amounts {
+		if !existing.OnlyThisMonth {
+			hasBaseline = true
+			break
+		}
+	}
+	if !hasBaseline {
+		baseline := models.CategoryBudgetAmount{
+			CategoryBudgetID: categoryBudget.ID,
+			Amount:           categoryBudget.Amount,
+			EffectiveMonth:   monthStart(categoryBudget.CreatedAt),
+		}
+		if err := tx.Create(&baseline).Error; err != nil {
+			return 0, err
+		}
+		amounts = append(amounts, baseline)
+	}
+
+	// A forward change replaces everything from that month on; a one-off
+	// replaces only an earlier one-off for the same month
+	var stale []uuid.UUID
+	kept := amounts[:0]
+	for _, existing := range amounts {
+		effective := monthStart(existing.EffectiveMonth)
+		replaced := effective.Equal(month) && existing.OnlyThisMonth == onlyThisMonth
+		if !onlyThisMonth && !effective.Before(month) {
+			replaced = true
+		}
+		if replaced {
+			stale = append(stale, existing.ID)
+			continue
+		}
+		kept = append(kept, existing)
+	}
+	if len(stale) > 0 {
+		if err := tx.Where("id IN ?", stale).Delete(&models.CategoryBudgetAmount{}).Error; err != nil {
+			return 0, err
+		}
+	}
+
+	version := models.CategoryBudgetAmount{
+		CategoryBudgetID: categoryBudget.ID,
+		Amount:           amount,
+		EffectiveMonth:   month,
+		OnlyThisMonth:    onlyThisMonth,
+	}
+	if err := tx.Create(&version).Error; err != nil {
+		return 0, err
+	}
+
+	return amountForMonth(categoryBudget, append(kept, version), time.Now()), nil
+}
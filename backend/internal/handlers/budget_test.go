@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -9,7 +10,9 @@ import (
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/yourusername/folda-finances/internal/middleware"
 	"github.com/yourusername/folda-finances/internal/models"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
@@ -22,14 +25,53 @@ func setupTestDB(t *testing.T) *gorm.DB {
 		t.Fatalf("Failed to connect to test database: %v", err)
 	}
 
-	// Auto-migrate all models
-	err = db.AutoMigrate(
+	testModels := []interface{}{
 		&models.User{},
 		&models.Budget{},
+		&models.BudgetMember{},
 		&models.Category{},
+		&models.Account{},
+		&models.Transaction{},
 		&models.CategoryBudget{},
+		&models.CategoryBudgetAmount{},
 		&models.CategoryBudgetSplit{},
-	)
+		&models.ExpectedIncome{},
+		&models.BudgetInvitation{},
+		&models.Goal{},
+		&models.GoalContribution{},
+		&models.LoanPayment{},
+		&models.NetWorthSnapshot{},
+		&models.NetWorthSnapshotItem{},
+		&models.InvestmentLot{},
+		&models.InvestmentTransaction{},
+		&models.SecurityPrice{},
+		&models.ExchangeRate{},
+		&models.Tag{},
+		&models.TransactionTag{},
+		&models.Attachment{},
+		&models.Reimbursement{},
+		&models.HiddenCategory{},
+		&models.CategoryParent{},
+		&models.BudgetTemplate{},
+		&models.BudgetTemplateItem{},
+	}
+
+	// SQLite cannot parse Postgres' gen_random_uuid() column default, so
+	// skip it here; IDs are assigned by the BeforeCreate hooks instead.
+	for _, model := range testModels {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			t.Fatalf("Failed to parse test model: %v", err)
+		}
+		for _, field := range stmt.Schema.Fields {
+			if field.DefaultValue == "gen_random_uuid()" {
+				field.DefaultValue = "(-)"
+			}
+		}
+	}
+
+	// Auto-migrate all models
+	err = db.AutoMigrate(testModels...)
 	if err != nil {
 		t.Fatalf("Failed to migrate test database: %v", err)
 	}
@@ -64,6 +106,9 @@ func createTestUser(t *testing.T, db *gorm.DB, email string) (*models.User, *mod
 	if err := db.Create(user).Error; err != nil {
 		t.Fatalf("Failed to create test user: %v", err)
 	}
+	if err := db.Create(&models.BudgetMember{BudgetID: budget.ID, UserID: userID, Role: "owner"}).Error; err != nil {
+		t.Fatalf("Failed to create test membership: %v", err)
+	}
 
 	return user, budget
 }
@@ -98,6 +143,7 @@ func TestGetBudgetMembers(t *testing.T) {
 		PeriodStartDate: func() *time.Time { t := time.Now(); return &t }(),
 	}
 	db.Create(user2)
+	db.Create(&models.BudgetMember{BudgetID: budget.ID, UserID: user2ID, Role: "read_write"})
 
 	// Create request
 	req := httptest.NewRequest("GET", "/budget/members", nil)
@@ -179,6 +225,7 @@ func TestUpdateCategoryBudgetSplits(t *testing.T) {
 		PeriodStartDate: func() *time.Time { t := time.Now(); return &t }(),
 	}
 	db.Create(user2)
+	db.Create(&models.BudgetMember{BudgetID: budget.ID, UserID: user2ID, Role: "read_write"})
 
 	category := createTestCategory(t, db, budget.ID, "Food")
 
@@ -328,6 +375,7 @@ func TestGetCategoryBudgetSplits(t *testing.T) {
 		PeriodStartDate: func() *time.Time { t := time.Now(); return &t }(),
 	}
 	db.Create(user2)
+	db.Create(&models.BudgetMember{BudgetID: budget.ID, UserID: user2ID, Role: "read_write"})
 
 	category := createTestCategory(t, db, budget.ID, "Food")
 	categoryBudget := &models.CategoryBudget{
@@ -384,6 +432,331 @@ func TestGetCategoryBudgetSplits(t *testing.T) {
 	}
 }
 
+func TestUpdateCategoryBudget_AmountVersions(t *testing.T) {
+	db := setupTestDB(t)
+	handler := NewBudgetHandler(db)
+
+	user, budget := createTestUser(t, db, "test@example.com")
+	category := createTestCategory(t, db, budget.ID, "Groceries")
+
+	// A category budget from before amounts were versioned
+	categoryBudget := &models.CategoryBudget{
+		ID:             uuid.New(),
+		BudgetID:       budget.ID,
+		CategoryID:     category.ID,
+		Amount:         50000,
+		AllocationType: "pooled",
+	}
+	db.Create(categoryBudget)
+
+	thisMonth := monthStart(time.Now())
+	nextMonth := thisMonth.AddDate(0, 1, 0)
+
+	update := func(reqBody UpdateCategoryBudgetRequest) {
+		body, _ := json.Marshal(reqBody)
+		req := httptest.NewRequest("PUT", "/category-budgets/"+categoryBudget.ID.String(), bytes.NewBuffer(body))
+		req = req.WithContext(setUserIDContext(req, user.ID))
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", categoryBudget.ID.String())
+		req = req.WithContext(setRouteContext(req, rctx))
+		w := httptest.NewRecorder()
+
+		handler.UpdateCategoryBudget(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+
+	amountsFor := func(months ...time.Time) []int {
+		var versions []models.CategoryBudgetAmount
+		db.Where("category_budget_id = ?", categoryBudget.ID).Find(&versions)
+		amounts := make([]int, len(months))
+		for i, month := range months {
+			amounts[i] = amountForMonth(*categoryBudget, versions, month)
+		}
+		return amounts
+	}
+
+	// Raise the budget from next month forward
+	update(UpdateCategoryBudgetRequest{
+		Amount:         intPtr(60000),
+		EffectiveMonth: stringPtr(nextMonth.Format("2006-01")),
+	})
+
+	got := amountsFor(thisMonth.AddDate(0, -1, 0), thisMonth, nextMonth, nextMonth.AddDate(0, 1, 0))
+	want := []int{50000, 50000, 60000, 60000}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("After forward edit, month %d: expected %d, got %d", i, want[i], got[i])
+		}
+	}
+
+	// The stored amount still reflects the current month
+	var stored models.CategoryBudget
+	db.First(&stored, "id = ?", categoryBudget.ID)
+	if stored.Amount != 50000 {
+		t.Errorf("Expected current amount 50000, got %d", stored.Amount)
+	}
+
+	// A one-off bump for this month leaves the other months alone
+	update(UpdateCategoryBudgetRequest{
+		Amount:     intPtr(80000),
+		AmountMode: stringPtr("this_month"),
+	})
+
+	got = amountsFor(thisMonth.AddDate(0, -1, 0), thisMonth, nextMonth)
+	want = []int{50000, 80000, 60000}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("After one-off edit, month %d: expected %d, got %d", i, want[i], got[i])
+		}
+	}
+
+	db.First(&stored, "id = ?", categoryBudget.ID)
+	if stored.Amount != 80000 {
+		t.Errorf("Expected current amount 80000, got %d", stored.Amount)
+	}
+}
+
+func TestUpdateBudgetThresholds(t *testing.T) {
+	db := setupTestDB(t)
+	handler := NewBudgetHandler(db)
+
+	user, budget := createTestUser(t, db, "test@example.com")
+
+	body, _ := json.Marshal(UpdateBudgetThresholdsRequest{WarningThreshold: float64Ptr(90)})
+	req := httptest.NewRequest("PUT", "/budget/thresholds", bytes.NewBuffer(body))
+	req = req.WithContext(setUserIDContext(req, user.ID))
+	w := httptest.NewRecorder()
+
+	handler.UpdateBudgetThresholds(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var updated models.Budget
+	db.First(&updated, "id = ?", budget.ID)
+	if updated.WarningThreshold != 90 || updated.OverBudgetThreshold != 100 {
+		t.Errorf("Expected thresholds 90/100, got %v/%v", updated.WarningThreshold, updated.OverBudgetThreshold)
+	}
+
+	// Warning above over-budget is rejected
+	body, _ = json.Marshal(UpdateBudgetThresholdsRequest{OverBudgetThreshold: float64Ptr(80)})
+	req = httptest.NewRequest("PUT", "/budget/thresholds", bytes.NewBuffer(body))
+	req = req.WithContext(setUserIDContext(req, user.ID))
+	w = httptest.NewRecorder()
+
+	handler.UpdateBudgetThresholds(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+
+	// So is anything the threshold columns cannot hold
+	body, _ = json.Marshal(UpdateBudgetThresholdsRequest{OverBudgetThreshold: float64Ptr(1000)})
+	req = httptest.NewRequest("PUT", "/budget/thresholds", bytes.NewBuffer(body))
+	req = req.WithContext(setUserIDContext(req, user.ID))
+	w = httptest.NewRecorder()
+
+	handler.UpdateBudgetThresholds(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 above 999.99, got %d", w.Code)
+	}
+}
+
+func TestUpdateBudgetSettings(t *testing.T) {
+	db := setupTestDB(t)
+	handler := NewBudgetHandler(db)
+
+	user, budget := createTestUser(t, db, "test@example.com")
+
+	update := func(body UpdateBudgetRequest) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.UpdateBudget(w, testRequest("PATCH", "/budgets/"+budget.ID.String(), body, user.ID, map[string]string{"budgetId": budget.ID.String()}))
+		return w
+	}
+
+	tests := []struct {
+		name string
+		body UpdateBudgetRequest
+	}{
+		{"empty name", UpdateBudgetRequest{Name: stringPtr("  ")}},
+		{"invalid currency", UpdateBudgetRequest{BaseCurrency: stringPtr("euro")}},
+		{"unknown period", UpdateBudgetRequest{DefaultViewPeriod: stringPtr("daily")}},
+		{"week start out of range", UpdateBudgetRequest{WeekStartDay: intPtr(7)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := update(tt.body); w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d", w.Code)
+			}
+		})
+	}
+
+	w := update(UpdateBudgetRequest{Name: stringPtr(" Household "), BaseCurrency: stringPtr("eur"), DefaultViewPeriod: stringPtr("weekly"), WeekStartDay: intPtr(1)})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Only the fields sent change
+	update(UpdateBudgetRequest{WeekStartDay: intPtr(0)})
+
+	req := httptest.NewRequest("GET", "/budgets/"+budget.ID.String(), nil)
+	req = req.WithContext(setUserIDContext(req, user.ID))
+	w = httptest.NewRecorder()
+	handler.GetBudget(w, req)
+	var response struct {
+		Data UserBudget `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&response)
+	got := response.Data
+	if got.Name != "Household" || got.BaseCurrency != "EUR" || got.DefaultViewPeriod != "weekly" || got.WeekStartDay != 0 {
+		t.Errorf("Expected the renamed weekly EUR budget, got %+v", got.Budget)
+	}
+	if got.Role != models.BudgetRoleOwner || !got.IsDefault {
+		t.Errorf("Expected the owner's default budget, got role %s default %v", got.Role, got.IsDefault)
+	}
+
+	// Members without a period of their own follow the budget's
+	body, _ := json.Marshal(UpdateUserRequest{ViewPeriod: stringPtr("")})
+	req = httptest.NewRequest("PATCH", "/users/me", bytes.NewBuffer(body))
+	req = req.WithContext(setUserIDContext(req, user.ID))
+	w = httptest.NewRecorder()
+	NewUserHandler(db).UpdateUser(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 clearing the view period, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest("GET", "/spending/available", nil)
+	req = req.WithContext(setUserIDContext(req, user.ID))
+	w = httptest.NewRecorder()
+	NewSpendingHandler(db).GetSpendingAvailable(w, req)
+	var spending struct {
+		Data SpendingAvailableResponse `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&spending)
+	if spending.Data.Period.Type != "weekly" {
+		t.Errorf("Expected the budget's weekly period, got %q", spending.Data.Period.Type)
+	}
+}
+
+func TestNewUserFollowsBudgetViewPeriod(t *testing.T) {
+	db := setupTestDB(t)
+	userHandler := NewUserHandler(db)
+
+	userID := uuid.New()
+	me := func() models.User {
+		req := httptest.NewRequest("GET", "/users/me", nil)
+		ctx := context.WithValue(setUserIDContext(req, userID), middleware.ClaimsKey, jwt.MapClaims{"email": "new@example.com"})
+		w := httptest.NewRecorder()
+		userHandler.GetCurrentUser(w, req.WithContext(ctx))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var response struct {
+			Data models.User `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+		return response.Data
+	}
+
+	// The first call creates the user and budget without a period of their own
+	user := me()
+	var stored models.User
+	db.First(&stored, "id = ?", userID)
+	if stored.ViewPeriod != "" || user.ViewPeriod != "monthly" {
+		t.Errorf("Expected no stored period and the budget's monthly one, got %q and %q", stored.ViewPeriod, user.ViewPeriod)
+	}
+
+	// Changing the budget's default reaches the existing member
+	w := httptest.NewRecorder()
+	NewBudgetHandler(db).UpdateBudget(w, testRequest("PATCH", "/budgets/"+user.BudgetID.String(), UpdateBudgetRequest{DefaultViewPeriod: stringPtr("weekly")}, userID, map[string]string{"budgetId": user.BudgetID.String()}))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if period := me().ViewPeriod; period != "weekly" {
+		t.Errorf("Expected the budget's new weekly period, got %q", period)
+	}
+}
+
+func TestDeleteAndRestoreBudget(t *testing.T) {
+	db := setupTestDB(t)
+	handler := NewBudgetHandler(db)
+
+	owner, budget := createTestUser(t, db, "owner@example.com")
+	member, _ := createTestUser(t, db, "member@example.com")
+	db.Create(&models.BudgetMember{BudgetID: budget.ID, UserID: member.ID, Role: models.BudgetRoleReadWrite})
+	db.Model(member).Update("budget_id", budget.ID)
+	invitation := models.BudgetInvitation{ID: uuid.New(), BudgetID: budget.ID, InviterID: owner.ID, InviteeEmail: "new@example.com", InvitedRole: models.BudgetRoleReadOnly, Token: "token", Status: "pending", ExpiresAt: time.Now().Add(time.Hour)}
+	db.Create(&invitation)
+
+	router := chi.NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(setUserIDContext(r, owner.ID)))
+		})
+	})
+	router.Route("/budgets/{budgetId}", func(r chi.Router) {
+		r.Use(middleware.NewBudgetMiddleware(db).SelectBudget)
+		r.Get("/", handler.GetBudget)
+	})
+	get := func() int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/budgets/"+budget.ID.String(), nil))
+		return w.Code
+	}
+
+	w := httptest.NewRecorder()
+	handler.DeleteBudget(w, testRequest("DELETE", "/budgets", nil, member.ID, map[string]string{"budgetId": budget.ID.String()}))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 deleting as a member, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.DeleteBudget(w, testRequest("DELETE", "/budgets", nil, owner.ID, map[string]string{"budgetId": budget.ID.String()}))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var deleted struct {
+		Data DeletedBudgetResponse `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&deleted)
+	if deleted.Data.IsActive || deleted.Data.PurgeAt.Before(time.Now().Add(29*24*time.Hour)) {
+		t.Errorf("Expected an inactive budget purged in 30 days, got %+v", deleted.Data)
+	}
+
+	// The budget is kept but out of reach, and nobody defaults to it
+	if code := get(); code != http.StatusGone {
+		t.Errorf("Expected status 410 selecting a deleted budget, got %d", code)
+	}
+	var reloaded models.User
+	db.First(&reloaded, "id = ?", member.ID)
+	if reloaded.BudgetID == nil || *reloaded.BudgetID == budget.ID {
+		t.Errorf("Expected the member's default moved off the deleted budget, got %v", reloaded.BudgetID)
+	}
+	db.First(&invitation, "id = ?", invitation.ID)
+	if invitation.Status != "expired" {
+		t.Errorf("Expected the pending invitation expired, got %s", invitation.Status)
+	}
+
+	w = httptest.NewRecorder()
+	handler.RestoreBudget(w, testRequest("POST", "/restore", nil, owner.ID, map[string]string{"budgetId": budget.ID.String()}))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if code := get(); code != http.StatusOK {
+		t.Errorf("Expected status 200 after restoring, got %d", code)
+	}
+	w = httptest.NewRecorder()
+	handler.RestoreBudget(w, testRequest("POST", "/restore", nil, owner.ID, map[string]string{"budgetId": budget.ID.String()}))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 restoring an active budget, got %d", w.Code)
+	}
+}
+
 // Helper functions
 func stringPtr(s string) *string {
 	return &s
@@ -392,3 +765,7 @@ func stringPtr(s string) *string {
 func intPtr(i int) *int {
 	return &i
 }
+
+func float64Ptr(f float64) *float64 {
+	return &f
+}
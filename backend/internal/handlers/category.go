@@ -2,14 +2,25 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/yourusername/folda-finances/internal/middleware"
 	"github.com/yourusername/folda-finances/internal/models"
 	"gorm.io/gorm"
 )
 
+// Category kinds; only expense categories are budgeted and count as spending
+const (
+	categoryKindExpense  = "expense"
+	categoryKindIncome   = "income"
+	categoryKindTransfer = "transfer"
+)
+
 type CategoryHandler struct {
 	db *gorm.DB
 }
@@ -26,8 +37,8 @@ func (h *CategoryHandler) GetCategories(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Get user to check their budget_id
-	var user models.User
-	if err := h.db.First(&user, "id = ?", userID).Error; err != nil {
+	user, err := requestUser(h.db, r, userID)
+	if err != nil {
 		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch user"})
 		return
 	}
@@ -39,12 +50,41 @@ func (h *CategoryHandler) GetCategories(w http.ResponseWriter, r *http.Request)
 	if user.BudgetID != nil {
 		query = query.Or("budget_id = ?", user.BudgetID)
 	}
+	if kind := r.URL.Query().Get("kind"); kind != "" {
+		query = h.db.Where(query).Where("kind = ?", kind)
+	}
 
 	if err := query.Order("name ASC").Find(&categories).Error; err != nil {
 		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch categories"})
 		return
 	}
 
+	// System categories the budget hid are left out unless asked for
+	if user.BudgetID != nil {
+		hidden, err := hiddenCategories(h.db, *user.BudgetID)
+		if err != nil {
+			respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch hidden categories"})
+			return
+		}
+		parents, err := categoryParents(h.db, *user.BudgetID)
+		if err != nil {
+			respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch category groups"})
+			return
+		}
+		includeHidden := r.URL.Query().Get("include_hidden") == "true"
+		visible := categories[:0]
+		for _, category := range categories {
+			category.IsHidden = hidden[category.ID]
+			if parentID, ok := parents[category.ID]; ok {
+				category.ParentID = &parentID
+			}
+			if !category.IsHidden || includeHidden {
+				visible = append(visible, category)
+			}
+		}
+		categories = visible
+	}
+
 	respondJSON(w, http.StatusOK, map[string]interface{}{"data": categories})
 }
 
@@ -52,6 +92,9 @@ type CreateCategoryRequest struct {
 	Name  string `json:"name"`
 	Color string `json:"color"`
 	Icon  string `json:"icon"`
+	Kind  string `json:"kind"` // expense (default), income or transfer
+
+	ParentID string `json:"parent_id"` // group to place the category in, optional
 }
 
 func (h *CategoryHandler) CreateCategory(w http.ResponseWriter, r *http.Request) {
@@ -80,10 +123,17 @@ func (h *CategoryHandler) CreateCategory(w http.ResponseWriter, r *http.Request)
 		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "category color is required"})
 		return
 	}
+	if req.Kind == "" {
+		req.Kind = categoryKindExpense
+	}
+	if req.Kind != categoryKindExpense && req.Kind != categoryKindIncome && req.Kind != categoryKindTransfer {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "kind must be expense, income or transfer"})
+		return
+	}
 
 	// Get user to access their budget_id
-	var user models.User
-	if err := h.db.First(&user, "id = ?", userID).Error; err != nil {
+	user, err := requestUser(h.db, r, userID)
+	if err != nil {
 		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch user"})
 		return
 	}
@@ -101,9 +151,31 @@ func (h *CategoryHandler) CreateCategory(w http.ResponseWriter, r *http.Request)
 		Color:    req.Color,
 		Icon:     req.Icon,
 		IsSystem: false,
+		Kind:     req.Kind,
 	}
 
-	if err := h.db.Create(&category).Error; err != nil {
+	var parent models.Category
+	if req.ParentID != "" {
+		var status int
+		var msg string
+		parent, status, msg = validateCategoryParent(h.db, category, req.ParentID, *user.BudgetID)
+		if status != 0 {
+			respondJSON(w, status, map[string]string{"error": msg})
+			return
+		}
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&category).Error; err != nil {
+			return err
+		}
+		if req.ParentID == "" {
+			return nil
+		}
+		category.ParentID = &parent.ID
+		return tx.Create(&models.CategoryParent{BudgetID: *user.BudgetID, CategoryID: category.ID, ParentID: parent.ID}).Error
+	})
+	if err != nil {
 		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to create category"})
 		return
 	}
@@ -113,3 +185,512 @@ func (h *CategoryHandler) CreateCategory(w http.ResponseWriter, r *http.Request)
 		"message": "Category created successfully",
 	})
 }
+
+type UpdateCategoryRequest struct {
+	Name  *string `json:"name"`
+	Color *string `json:"color"`
+	Icon  *string `json:"icon"`
+}
+
+// UpdateCategory renames or restyles one of the budget's own categories
+func (h *CategoryHandler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	category, _, ok := h.authorizedCategory(w, r)
+	if !ok {
+		return
+	}
+	if category.IsSystem {
+		respondJSON(w, http.StatusForbidden, map[string]string{"error": "system categories cannot be edited; hide them instead"})
+		return
+	}
+
+	var req UpdateCategoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+
+	updates := map[string]interface{}{}
+	fields := []struct {
+		name  string
+		value *string
+	}{{"name", req.Name}, {"color", req.Color}, {"icon", req.Icon}}
+	for _, field := range fields {
+		if field.value == nil {
+			continue
+		}
+		if strings.TrimSpace(*field.value) == "" {
+			respondJSON(w, http.StatusBadRequest, map[string]string{"error": "category " + field.name + " cannot be empty"})
+			return
+		}
+		updates[field.name] = strings.TrimSpace(*field.value)
+	}
+
+	if len(updates) > 0 {
+		if err := h.db.Model(&category).Updates(updates).Error; err != nil {
+			respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to update category"})
+			return
+		}
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data":    category,
+		"message": "Category updated successfully",
+	})
+}
+
+// HideCategory hides a system category from the budget's category lists.
+// Its transactions are kept; custom categories are deleted instead.
+func (h *CategoryHandler) HideCategory(w http.ResponseWriter, r *http.Request) {
+	category, budgetID, ok := h.authorizedCategory(w, r)
+	if !ok {
+		return
+	}
+	if !category.IsSystem {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "only system categories can be hidden; delete custom categories instead"})
+		return
+	}
+
+	var budgeted int64
+	if err := h.db.Model(&models.CategoryBudget{}).Where("budget_id = ? AND category_id = ?", budgetID, category.ID).Count(&budgeted).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to check category budgets"})
+		return
+	}
+	if budgeted > 0 {
+		respondJSON(w, http.StatusConflict, map[string]string{"error": "category is budgeted; delete its budget or merge it first"})
+		return
+	}
+
+	if err := h.db.Save(&models.HiddenCategory{BudgetID: budgetID, CategoryID: category.ID}).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to hide category"})
+		return
+	}
+
+	category.IsHidden = true
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data":    category,
+		"message": "Category hidden successfully",
+	})
+}
+
+// UnhideCategory shows a hidden system category again
+func (h *CategoryHandler) UnhideCategory(w http.ResponseWriter, r *http.Request) {
+	category, budgetID, ok := h.authorizedCategory(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.db.Where("budget_id = ? AND category_id = ?", budgetID, category.ID).Delete(&models.HiddenCategory{}).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to unhide category"})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data":    category,
+		"message": "Category unhidden successfully",
+	})
+}
+
+type SetCategoryParentRequest struct {
+	ParentID string `json:"parent_id"` // empty to take the category out of its group
+}
+
+// SetCategoryParent places a category in a group, or takes it out, for the
+// user's budget. System categories can be grouped too.
+func (h *CategoryHandler) SetCategoryParent(w http.ResponseWriter, r *http.Request) {
+	category, budgetID, ok := h.authorizedCategory(w, r)
+	if !ok {
+		return
+	}
+
+	var req SetCategoryParentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+
+	if req.ParentID == "" {
+		if err := h.db.Where("budget_id = ? AND category_id = ?", budgetID, category.ID).Delete(&models.CategoryParent{}).Error; err != nil {
+			respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to update category group"})
+			return
+		}
+		respondJSON(w, http.StatusOK, map[string]interface{}{
+			"data":    category,
+			"message": "Category group updated successfully",
+		})
+		return
+	}
+
+	parent, status, msg := validateCategoryParent(h.db, category, req.ParentID, budgetID)
+	if status != 0 {
+		respondJSON(w, status, map[string]string{"error": msg})
+		return
+	}
+
+	if err := h.db.Save(&models.CategoryParent{BudgetID: budgetID, CategoryID: category.ID, ParentID: parent.ID}).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to update category group"})
+		return
+	}
+
+	category.ParentID = &parent.ID
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data":    category,
+		"message": "Category group updated successfully",
+	})
+}
+
+type MergeCategoryRequest struct {
+	TargetCategoryID string `json:"target_category_id"`
+}
+
+// MergeCategory moves a category's transactions and budget into another
+// category of the same kind, then deletes it, or hides it for the budget when
+// it is a system category
+func (h *CategoryHandler) MergeCategory(w http.ResponseWriter, r *http.Request) {
+	category, budgetID, ok := h.authorizedCategory(w, r)
+	if !ok {
+		return
+	}
+
+	var req MergeCategoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+
+	target, status, msg := h.mergeTarget(category, budgetID, req.TargetCategoryID)
+	if status != 0 {
+		respondJSON(w, status, map[string]string{"error": msg})
+		return
+	}
+
+	moved, err := h.merge(category, target, budgetID)
+	if err != nil {
+		respondMergeError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"category":           target,
+			"transactions_moved": moved,
+		},
+		"message": "Category merged successfully",
+	})
+}
+
+// DeleteCategory deletes one of the budget's own categories. A category with
+// transactions needs ?reassign_to= naming the category to merge them into.
+func (h *CategoryHandler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	category, budgetID, ok := h.authorizedCategory(w, r)
+	if !ok {
+		return
+	}
+	if category.IsSystem {
+		respondJSON(w, http.StatusForbidden, map[string]string{"error": "system categories cannot be deleted; hide them instead"})
+		return
+	}
+
+	if targetID := r.URL.Query().Get("reassign_to"); targetID != "" {
+		target, status, msg := h.mergeTarget(category, budgetID, targetID)
+		if status != 0 {
+			respondJSON(w, status, map[string]string{"error": msg})
+			return
+		}
+		if _, err := h.merge(category, target, budgetID); err != nil {
+			respondMergeError(w, err)
+			return
+		}
+		respondJSON(w, http.StatusOK, map[string]interface{}{
+			"message": "Category deleted successfully",
+		})
+		return
+	}
+
+	var transactions int64
+	if err := h.db.Model(&models.Transaction{}).Where("budget_id = ? AND category_id = ?", budgetID, category.ID).Count(&transactions).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to check transactions"})
+		return
+	}
+	if transactions > 0 {
+		respondJSON(w, http.StatusConflict, map[string]interface{}{
+			"error":        "category has transactions; reassign them with reassign_to",
+			"transactions": transactions,
+		})
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		var categoryBudgets []models.CategoryBudget
+		if err := tx.Where("budget_id = ? AND category_id = ?", budgetID, category.ID).Find(&categoryBudgets).Error; err != nil {
+			return err
+		}
+		for _, categoryBudget := range categoryBudgets {
+			if err := deleteCategoryBudget(tx, categoryBudget, nil); err != nil {
+				return err
+			}
+		}
+		// Subcategories of a deleted group are left ungrouped
+		if err := tx.Where("budget_id = ? AND (category_id = ? OR parent_id = ?)", budgetID, category.ID, category.ID).
+			Delete(&models.CategoryParent{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&category).Error
+	})
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to delete category"})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"message": "Category deleted successfully",
+	})
+}
+
+var errMergeSinkingFunds = errors.New("both categories are budgeted and one is a sinking fund; merge their budgets by hand first")
+
+// mergeTarget checks the category another is merged into
+func (h *CategoryHandler) mergeTarget(source models.Category, budgetID uuid.UUID, targetID string) (models.Category, int, string) {
+	id, err := uuid.Parse(targetID)
+	if err != nil {
+		return models.Category{}, http.StatusBadRequest, "invalid target category"
+	}
+	if id == source.ID {
+		return models.Category{}, http.StatusBadRequest, "cannot merge a category into itself"
+	}
+	target, status, msg := budgetCategory(h.db, id, budgetID)
+	if status != 0 {
+		return target, status, msg
+	}
+	if target.Kind != source.Kind {
+		return target, http.StatusBadRequest, "categories must be the same kind to merge"
+	}
+	hidden, err := hiddenCategories(h.db, budgetID)
+	if err != nil {
+		return target, http.StatusInternalServerError, "failed to fetch hidden categories"
+	}
+	if hidden[target.ID] {
+		return target, http.StatusBadRequest, "cannot merge into a hidden category"
+	}
+	return target, 0, ""
+}
+
+// merge rewrites the budget's transactions and category budget from source
+// to target and removes source from the budget, returning the number of
+// transactions moved. When both are budgeted the target's budget grows by
+// the source's current amount from this month on.
+func (h *CategoryHandler) merge(source, target models.Category, budgetID uuid.UUID) (int64, error) {
+	var moved int64
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Transaction{}).Where("budget_id = ? AND category_id = ?", budgetID, source.ID).
+			Update("category_id", target.ID)
+		if result.Error != nil {
+			return result.Error
+		}
+		moved = result.RowsAffected
+
+		var from, into models.CategoryBudget
+		hasFrom, err := findCategoryBudget(tx, budgetID, source.ID, &from)
+		if err != nil {
+			return err
+		}
+		hasInto, err := findCategoryBudget(tx, budgetID, target.ID, &into)
+		if err != nil {
+			return err
+		}
+		switch {
+		case hasFrom && !hasInto:
+			if err := tx.Model(&from).Update("category_id", target.ID).Error; err != nil {
+				return err
+			}
+		case hasFrom && hasInto:
+			if isSinkingFund(from) || isSinkingFund(into) {
+				return errMergeSinkingFunds
+			}
+			amounts, err := categoryBudgetAmounts(tx, []models.CategoryBudget{from, into})
+			if err != nil {
+				return err
+			}
+			now := time.Now()
+			combined := amountForMonth(from, amounts[from.ID], now) + amountForMonth(into, amounts[into.ID], now)
+			amount, err := setCategoryBudgetAmount(tx, into, combined, now, false)
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(&into).Update("amount", amount).Error; err != nil {
+				return err
+			}
+			if err := deleteCategoryBudget(tx, from, &into.ID); err != nil {
+				return err
+			}
+		}
+
+		// The source leaves its group, and its subcategories join the target
+		// unless that would nest groups
+		if err := tx.Where("budget_id = ? AND category_id = ?", budgetID, source.ID).Delete(&models.CategoryParent{}).Error; err != nil {
+			return err
+		}
+		var targetParents int64
+		if err := tx.Model(&models.CategoryParent{}).Where("budget_id = ? AND category_id = ?", budgetID, target.ID).Count(&targetParents).Error; err != nil {
+			return err
+		}
+		children := tx.Where("budget_id = ? AND parent_id = ?", budgetID, source.ID)
+		if targetParents > 0 {
+			err = children.Delete(&models.CategoryParent{}).Error
+		} else {
+			err = tx.Model(&models.CategoryParent{}).Where("budget_id = ? AND parent_id = ?", budgetID, source.ID).
+				Update("parent_id", target.ID).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		if source.IsSystem {
+			return tx.Save(&models.HiddenCategory{BudgetID: budgetID, CategoryID: source.ID}).Error
+		}
+		return tx.Delete(&source).Error
+	})
+	return moved, err
+}
+
+func respondMergeError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errMergeSinkingFunds) {
+		respondJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+		return
+	}
+	respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to merge categories"})
+}
+
+// authorizedCategory loads the category in the URL if the user's budget can
+// use it, responding with an error otherwise
+func (h *CategoryHandler) authorizedCategory(w http.ResponseWriter, r *http.Request) (models.Category, uuid.UUID, bool) {
+	budgetID, ok := userBudgetID(h.db, w, r)
+	if !ok {
+		return models.Category{}, budgetID, false
+	}
+
+	categoryID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid category ID"})
+		return models.Category{}, budgetID, false
+	}
+
+	category, status, msg := budgetCategory(h.db, categoryID, budgetID)
+	if status == http.StatusBadRequest {
+		status = http.StatusNotFound
+	}
+	if status != 0 {
+		respondJSON(w, status, map[string]string{"error": msg})
+		return category, budgetID, false
+	}
+	return category, budgetID, true
+}
+
+// findCategoryBudget loads the budget's category budget for a category,
+// reporting whether there is one
+func findCategoryBudget(db *gorm.DB, budgetID, categoryID uuid.UUID, categoryBudget *models.CategoryBudget) (bool, error) {
+	err := db.Where("budget_id = ? AND category_id = ?", budgetID, categoryID).First(categoryBudget).Error
+	if err == gorm.ErrRecordNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// validateCategoryParent checks a category can be placed in the group
+// parentID: a category of the same kind the budget can use that is not in a
+// group itself, while the category has no subcategories of its own
+func validateCategoryParent(db *gorm.DB, category models.Category, parentID string, budgetID uuid.UUID) (models.Category, int, string) {
+	id, err := uuid.Parse(parentID)
+	if err != nil {
+		return models.Category{}, http.StatusBadRequest, "invalid parent_id"
+	}
+	if id == category.ID {
+		return models.Category{}, http.StatusBadRequest, "a category cannot be its own group"
+	}
+	parent, status, msg := budgetCategory(db, id, budgetID)
+	if status != 0 {
+		return parent, status, "parent " + msg
+	}
+	if parent.Kind != category.Kind {
+		return parent, http.StatusBadRequest, "a group and its subcategories must be the same kind"
+	}
+
+	parents, err := categoryParents(db, budgetID)
+	if err != nil {
+		return parent, http.StatusInternalServerError, "failed to fetch category groups"
+	}
+	if _, ok := parents[parent.ID]; ok {
+		return parent, http.StatusBadRequest, "groups cannot be nested; the parent is a subcategory"
+	}
+	for _, groupID := range parents {
+		if groupID == category.ID {
+			return parent, http.StatusBadRequest, "groups cannot be nested; the category has subcategories"
+		}
+	}
+	return parent, 0, ""
+}
+
+// categoryParents returns the group of each grouped category in a budget
+func categoryParents(db *gorm.DB, budgetID uuid.UUID) (map[uuid.UUID]uuid.UUID, error) {
+	var rows []models.CategoryParent
+	if err := db.Where("budget_id = ?", budgetID).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	parents := make(map[uuid.UUID]uuid.UUID, len(rows))
+	for _, row := range rows {
+		parents[row.CategoryID] = row.ParentID
+	}
+	return parents, nil
+}
+
+// hiddenCategories returns the system categories a budget hid
+func hiddenCategories(db *gorm.DB, budgetID uuid.UUID) (map[uuid.UUID]bool, error) {
+	var rows []models.HiddenCategory
+	if err := db.Where("budget_id = ?", budgetID).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	hidden := make(map[uuid.UUID]bool, len(rows))
+	for _, row := range rows {
+		hidden[row.CategoryID] = true
+	}
+	return hidden, nil
+}
+
+// budgetCategory fetches a category transactions in the budget can use: a
+// system category or one of the budget's own. It returns a status and message
+// to respond with when there is none.
+func budgetCategory(db *gorm.DB, categoryID, budgetID uuid.UUID) (models.Category, int, string) {
+	var category models.Category
+	err := db.Where("id = ? AND ((budget_id IS NULL AND is_system = ?) OR budget_id = ?)", categoryID, true, budgetID).
+		First(&category).Error
+	if err == gorm.ErrRecordNotFound {
+		return category, http.StatusBadRequest, "category not found"
+	}
+	if err != nil {
+		return category, http.StatusInternalServerError, "failed to fetch category"
+	}
+	return category, 0, ""
+}
+
+// validateCategoryAmount checks a transaction's sign suits its category:
+// expenses are negative apart from refunds, income is positive, and transfers
+// go either way
+func validateCategoryAmount(category models.Category, transaction models.Transaction) error {
+	switch category.Kind {
+	case categoryKindIncome:
+		if transaction.IsRefund {
+			return errors.New("refunds belong in expense categories")
+		}
+		if transaction.Amount < 0 {
+			return errors.New("income categories take positive amounts")
+		}
+	case categoryKindTransfer:
+		if transaction.IsRefund || transaction.IsReimbursable {
+			return errors.New("transfers cannot be refunds or reimbursable")
+		}
+	default:
+		if transaction.Amount > 0 && !transaction.IsRefund {
+			return errors.New("expense categories take negative amounts; flag money back with is_refund")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,348 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yourusername/folda-finances/internal/models"
+)
+
+func TestCategoryKinds(t *testing.T) {
+	db := setupTestDB(t)
+	categoryHandler := NewCategoryHandler(db)
+	transactionHandler := NewTransactionHandler(db)
+	budgetHandler := NewBudgetHandler(db)
+	spendingHandler := NewSpendingHandler(db)
+
+	user, budget := createTestUser(t, db, "test@example.com")
+	groceries := createTestCategory(t, db, budget.ID, "Groceries")
+	salary := &models.Category{ID: uuid.New(), Name: "Salary", Color: "#059669", Icon: "💵", IsSystem: true, Kind: categoryKindIncome}
+	transfers := &models.Category{ID: uuid.New(), Name: "Transfers", Color: "#64748B", Icon: "🔁", IsSystem: true, Kind: categoryKindTransfer}
+	db.Create(salary)
+	db.Create(transfers)
+
+	post := func(handler http.HandlerFunc, target string, body interface{}) *httptest.ResponseRecorder {
+		data, _ := json.Marshal(body)
+		req := httptest.NewRequest("POST", target, bytes.NewBuffer(data))
+		req = req.WithContext(setUserIDContext(req, user.ID))
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w
+	}
+
+	if w := post(categoryHandler.CreateCategory, "/categories", CreateCategoryRequest{Name: "Side Gig", Color: "#000000", Icon: "💼", Kind: "bonus"}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown kind, got %d", w.Code)
+	}
+	if w := post(categoryHandler.CreateCategory, "/categories", CreateCategoryRequest{Name: "Side Gig", Color: "#000000", Icon: "💼", Kind: categoryKindIncome}); w.Code != http.StatusCreated {
+		t.Errorf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// The amount's sign must suit the category
+	today := time.Now().Format("2006-01-02")
+	tests := []struct {
+		name     string
+		request  CreateTransactionRequest
+		expected int
+	}{
+		{"expense", CreateTransactionRequest{Amount: -8000, CategoryID: groceries.ID.String(), Date: today}, http.StatusCreated},
+		{"credit to an expense category", CreateTransactionRequest{Amount: 8000, CategoryID: groceries.ID.String(), Date: today}, http.StatusBadRequest},
+		{"salary", CreateTransactionRequest{Amount: 300000, CategoryID: salary.ID.String(), Date: today}, http.StatusCreated},
+		{"negative salary", CreateTransactionRequest{Amount: -300000, CategoryID: salary.ID.String(), Date: today}, http.StatusBadRequest},
+		{"refund as income", CreateTransactionRequest{Amount: 500, CategoryID: salary.ID.String(), Date: today, IsRefund: true}, http.StatusBadRequest},
+		{"transfer out", CreateTransactionRequest{Amount: -50000, CategoryID: transfers.ID.String(), Date: today}, http.StatusCreated},
+		{"transfer in", CreateTransactionRequest{Amount: 50000, CategoryID: transfers.ID.String(), Date: today}, http.StatusCreated},
+		{"unknown category", CreateTransactionRequest{Amount: -100, CategoryID: uuid.New().String(), Date: today}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := post(transactionHandler.CreateTransaction, "/transactions", tt.request); w.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	// Only expense categories can be budgeted
+	if w := post(budgetHandler.CreateCategoryBudget, "/category-budgets", CreateCategoryBudgetRequest{CategoryID: salary.ID.String(), Amount: 100000}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 budgeting an income category, got %d", w.Code)
+	}
+	if w := post(budgetHandler.CreateCategoryBudget, "/category-budgets", CreateCategoryBudgetRequest{CategoryID: groceries.ID.String(), Amount: 60000}); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Income budgeted before categories had kinds stays out of spending
+	db.Create(&models.CategoryBudget{ID: uuid.New(), BudgetID: budget.ID, CategoryID: salary.ID, Amount: 100000, AllocationType: "pooled"})
+
+	req := httptest.NewRequest("GET", "/spending/available", nil)
+	req = req.WithContext(setUserIDContext(req, user.ID))
+	w := httptest.NewRecorder()
+	spendingHandler.GetSpendingAvailable(w, req)
+
+	var response struct {
+		Data SpendingAvailableResponse `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&response)
+	if len(response.Data.Categories) != 1 || response.Data.Categories[0].CategoryName != "Groceries" || response.Data.Summary.TotalSpent != 8000 {
+		t.Errorf("Expected only Groceries with 8000 spent, got %+v", response.Data)
+	}
+
+	// Categories can be listed by kind
+	req = httptest.NewRequest("GET", "/categories?kind=income", nil)
+	req = req.WithContext(setUserIDContext(req, user.ID))
+	w = httptest.NewRecorder()
+	categoryHandler.GetCategories(w, req)
+
+	var categories struct {
+		Data []models.Category `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&categories)
+	if len(categories.Data) != 2 || categories.Data[0].Name != "Salary" || categories.Data[1].Name != "Side Gig" {
+		t.Errorf("Expected Salary and Side Gig, got %+v", categories.Data)
+	}
+}
+
+func TestUpdateAndHideCategories(t *testing.T) {
+	db := setupTestDB(t)
+	handler := NewCategoryHandler(db)
+
+	user, budget := createTestUser(t, db, "test@example.com")
+	coffee := createTestCategory(t, db, budget.ID, "Coffee")
+	housing := &models.Category{ID: uuid.New(), Name: "Housing", Color: "#8B5CF6", Icon: "🏠", IsSystem: true, Kind: categoryKindExpense}
+	education := &models.Category{ID: uuid.New(), Name: "Education", Color: "#F97316", Icon: "📚", IsSystem: true, Kind: categoryKindExpense}
+	db.Create(housing)
+	db.Create(education)
+
+	name, color := "Coffee & Tea", "#654321"
+	w := httptest.NewRecorder()
+	handler.UpdateCategory(w, testRequest("PUT", "/categories/"+coffee.ID.String(), UpdateCategoryRequest{Name: &name, Color: &color}, user.ID, map[string]string{"id": coffee.ID.String()}))
+	var updated struct {
+		Data models.Category `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&updated)
+	if w.Code != http.StatusOK || updated.Data.Name != name || updated.Data.Color != color || updated.Data.Icon != coffee.Icon {
+		t.Errorf("Expected the category renamed and recolored, got %d: %+v", w.Code, updated.Data)
+	}
+
+	w = httptest.NewRecorder()
+	handler.UpdateCategory(w, testRequest("PUT", "/categories/"+housing.ID.String(), UpdateCategoryRequest{Name: &name}, user.ID, map[string]string{"id": housing.ID.String()}))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 renaming a system category, got %d", w.Code)
+	}
+
+	// Only system categories that are not budgeted can be hidden
+	w = httptest.NewRecorder()
+	handler.HideCategory(w, testRequest("POST", "/categories/"+coffee.ID.String()+"/hide", nil, user.ID, map[string]string{"id": coffee.ID.String()}))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 hiding a custom category, got %d", w.Code)
+	}
+	db.Create(&models.CategoryBudget{ID: uuid.New(), BudgetID: budget.ID, CategoryID: housing.ID, Amount: 150000, AllocationType: "pooled"})
+	w = httptest.NewRecorder()
+	handler.HideCategory(w, testRequest("POST", "/categories/"+housing.ID.String()+"/hide", nil, user.ID, map[string]string{"id": housing.ID.String()}))
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 hiding a budgeted category, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	handler.HideCategory(w, testRequest("POST", "/categories/"+education.ID.String()+"/hide", nil, user.ID, map[string]string{"id": education.ID.String()}))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	list := func(query string) []models.Category {
+		req := httptest.NewRequest("GET", "/categories"+query, nil)
+		req = req.WithContext(setUserIDContext(req, user.ID))
+		w := httptest.NewRecorder()
+		handler.GetCategories(w, req)
+		var response struct {
+			Data []models.Category `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+		return response.Data
+	}
+
+	if categories := list(""); len(categories) != 2 {
+		t.Errorf("Expected the hidden category left out, got %+v", categories)
+	}
+	if categories := list("?include_hidden=true"); len(categories) != 3 || categories[1].Name != "Education" || !categories[1].IsHidden {
+		t.Errorf("Expected Education listed as hidden, got %+v", categories)
+	}
+
+	w = httptest.NewRecorder()
+	handler.UnhideCategory(w, testRequest("DELETE", "/categories/"+education.ID.String()+"/hide", nil, user.ID, map[string]string{"id": education.ID.String()}))
+	if categories := list(""); w.Code != http.StatusOK || len(categories) != 3 {
+		t.Errorf("Expected Education shown again, got %d: %+v", w.Code, categories)
+	}
+}
+
+func TestMergeAndDeleteCategories(t *testing.T) {
+	db := setupTestDB(t)
+	handler := NewCategoryHandler(db)
+
+	user, budget := createTestUser(t, db, "test@example.com")
+	coffee := createTestCategory(t, db, budget.ID, "Coffee")
+	dining := createTestCategory(t, db, budget.ID, "Dining")
+	snacks := createTestCategory(t, db, budget.ID, "Snacks")
+	salary := &models.Category{ID: uuid.New(), BudgetID: &budget.ID, Name: "Salary", Color: "#059669", Icon: "💵", Kind: categoryKindIncome}
+	db.Create(salary)
+
+	for _, category := range []*models.Category{coffee, coffee, dining, snacks} {
+		db.Create(&models.Transaction{ID: uuid.New(), UserID: user.ID, BudgetID: budget.ID, CategoryID: category.ID, Amount: -500, Currency: "USD", Date: time.Now()})
+	}
+	coffeeBudget := &models.CategoryBudget{ID: uuid.New(), BudgetID: budget.ID, CategoryID: coffee.ID, Amount: 4000, AllocationType: "pooled"}
+	diningBudget := &models.CategoryBudget{ID: uuid.New(), BudgetID: budget.ID, CategoryID: dining.ID, Amount: 20000, AllocationType: "pooled"}
+	db.Create(coffeeBudget)
+	db.Create(diningBudget)
+	goal := &models.Goal{ID: uuid.New(), BudgetID: budget.ID, Name: "Espresso machine", TargetAmount: 60000, CategoryBudgetID: &coffeeBudget.ID}
+	db.Create(goal)
+
+	merge := func(source, target uuid.UUID) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.MergeCategory(w, testRequest("POST", "/categories/"+source.String()+"/merge", MergeCategoryRequest{TargetCategoryID: target.String()}, user.ID, map[string]string{"id": source.String()}))
+		return w
+	}
+
+	if w := merge(coffee.ID, salary.ID); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 merging into another kind, got %d", w.Code)
+	}
+	if w := merge(coffee.ID, dining.ID); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var count int64
+	db.Model(&models.Transaction{}).Where("category_id = ?", dining.ID).Count(&count)
+	if count != 3 {
+		t.Errorf("Expected 3 transactions in Dining, got %d", count)
+	}
+	if db.First(&models.Category{}, "id = ?", coffee.ID).Error == nil {
+		t.Error("Expected the merged category deleted")
+	}
+
+	// The budgets are combined and the goal follows
+	var merged models.CategoryBudget
+	db.First(&merged, "id = ?", diningBudget.ID)
+	db.Model(&models.CategoryBudget{}).Where("id = ?", coffeeBudget.ID).Count(&count)
+	if merged.Amount != 24000 || count != 0 {
+		t.Errorf("Expected a single 24000 Dining budget, got %d with %d Coffee budgets left", merged.Amount, count)
+	}
+	db.First(goal, "id = ?", goal.ID)
+	if goal.CategoryBudgetID == nil || *goal.CategoryBudgetID != diningBudget.ID {
+		t.Errorf("Expected the goal funded from the Dining budget, got %v", goal.CategoryBudgetID)
+	}
+
+	// Deleting needs somewhere for the transactions to go
+	w := httptest.NewRecorder()
+	handler.DeleteCategory(w, testRequest("DELETE", "/categories/"+snacks.ID.String(), nil, user.ID, map[string]string{"id": snacks.ID.String()}))
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 deleting a category with transactions, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	handler.DeleteCategory(w, testRequest("DELETE", "/categories/"+snacks.ID.String()+"?reassign_to="+dining.ID.String(), nil, user.ID, map[string]string{"id": snacks.ID.String()}))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	db.Model(&models.Transaction{}).Where("category_id = ?", dining.ID).Count(&count)
+	if count != 4 {
+		t.Errorf("Expected 4 transactions in Dining, got %d", count)
+	}
+
+	w = httptest.NewRecorder()
+	handler.DeleteCategory(w, testRequest("DELETE", "/categories/"+salary.ID.String(), nil, user.ID, map[string]string{"id": salary.ID.String()}))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 deleting an unused category, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestCategoryGroups(t *testing.T) {
+	db := setupTestDB(t)
+	categoryHandler := NewCategoryHandler(db)
+	spendingHandler := NewSpendingHandler(db)
+
+	user, budget := createTestUser(t, db, "test@example.com")
+	food := createTestCategory(t, db, budget.ID, "Food")
+	groceries := createTestCategory(t, db, budget.ID, "Groceries")
+	dining := createTestCategory(t, db, budget.ID, "Dining")
+	snacks := createTestCategory(t, db, budget.ID, "Snacks")
+	salary := &models.Category{ID: uuid.New(), Name: "Salary", Color: "#059669", Icon: "💵", IsSystem: true, Kind: categoryKindIncome}
+	db.Create(salary)
+
+	setParent := func(category, parent uuid.UUID) int {
+		w := httptest.NewRecorder()
+		categoryHandler.SetCategoryParent(w, testRequest("PUT", "/categories/"+category.String()+"/parent", SetCategoryParentRequest{ParentID: parent.String()}, user.ID, map[string]string{"id": category.String()}))
+		return w.Code
+	}
+
+	for _, category := range []uuid.UUID{groceries.ID, dining.ID, snacks.ID} {
+		if code := setParent(category, food.ID); code != http.StatusOK {
+			t.Fatalf("Expected status 200 grouping under Food, got %d", code)
+		}
+	}
+	if code := setParent(food.ID, food.ID); code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 grouping a category under itself, got %d", code)
+	}
+	if code := setParent(snacks.ID, groceries.ID); code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 grouping under a subcategory, got %d", code)
+	}
+	if code := setParent(food.ID, salary.ID); code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 grouping under another kind, got %d", code)
+	}
+
+	today := time.Now()
+	db.Create(&models.Transaction{ID: uuid.New(), UserID: user.ID, BudgetID: budget.ID, CategoryID: groceries.ID, Amount: -30000, Currency: "USD", Date: today})
+	db.Create(&models.Transaction{ID: uuid.New(), UserID: user.ID, BudgetID: budget.ID, CategoryID: dining.ID, Amount: -10000, Currency: "USD", Date: today})
+	db.Create(&models.Transaction{ID: uuid.New(), UserID: user.ID, BudgetID: budget.ID, CategoryID: snacks.ID, Amount: -2000, Currency: "USD", Date: today})
+
+	spending := func() SpendingAvailableResponse {
+		req := httptest.NewRequest("GET", "/spending/available", nil)
+		req = req.WithContext(setUserIDContext(req, user.ID))
+		w := httptest.NewRecorder()
+		spendingHandler.GetSpendingAvailable(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var response struct {
+			Data SpendingAvailableResponse `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+		return response.Data
+	}
+
+	// Budgeting the subcategories rolls their budgets up to the group, with
+	// unbudgeted Snacks coming out of what is left
+	db.Create(&models.CategoryBudget{ID: uuid.New(), BudgetID: budget.ID, CategoryID: groceries.ID, Amount: 40000, AllocationType: "pooled"})
+	db.Create(&models.CategoryBudget{ID: uuid.New(), BudgetID: budget.ID, CategoryID: dining.ID, Amount: 20000, AllocationType: "pooled"})
+	data := spending()
+	if len(data.Groups) != 1 {
+		t.Fatalf("Expected one group, got %+v", data.Groups)
+	}
+	group := data.Groups[0]
+	if group.BudgetedAt != "subcategories" || group.Budgeted != 60000 || group.Spent != 42000 || group.Available != 18000 || len(group.Subcategories) != 2 {
+		t.Errorf("Expected Food budgeted 60000 with 42000 spent and 18000 available, got %+v", group)
+	}
+	if data.Summary.TotalBudgeted != 60000 || data.Summary.TotalSpent != 40000 {
+		t.Errorf("Expected totals from the subcategories, got %+v", data.Summary)
+	}
+
+	// Budgeting the group as well covers all its spending, counted once
+	db.Create(&models.CategoryBudget{ID: uuid.New(), BudgetID: budget.ID, CategoryID: food.ID, Amount: 50000, AllocationType: "pooled"})
+	data = spending()
+	group = data.Groups[0]
+	if group.BudgetedAt != "group" || group.Budgeted != 50000 || group.Spent != 42000 || group.Available != 8000 {
+		t.Errorf("Expected Food budgeted 50000 with 42000 spent, got %+v", group)
+	}
+	if data.Summary.TotalBudgeted != 50000 || data.Summary.TotalSpent != 42000 {
+		t.Errorf("Expected totals from the group alone, got %+v", data.Summary)
+	}
+
+	// Taking Snacks out of the group leaves its spending out of Food
+	w := httptest.NewRecorder()
+	categoryHandler.SetCategoryParent(w, testRequest("PUT", "/categories/"+snacks.ID.String()+"/parent", SetCategoryParentRequest{}, user.ID, map[string]string{"id": snacks.ID.String()}))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if group := spending().Groups[0]; group.Spent != 40000 {
+		t.Errorf("Expected 40000 spent in Food, got %+v", group)
+	}
+}
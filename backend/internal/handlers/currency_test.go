@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yourusername/folda-finances/internal/currency"
+	"github.com/yourusername/folda-finances/internal/models"
+)
+
+func TestGetSpendingAvailable_ConvertsCurrencies(t *testing.T) {
+	db := setupTestDB(t)
+	handler := NewSpendingHandler(db)
+
+	user, budget := createTestUser(t, db, "test@example.com")
+	category := createTestCategory(t, db, budget.ID, "Travel")
+	db.Create(&models.CategoryBudget{ID: uuid.New(), BudgetID: budget.ID, CategoryID: category.ID, Amount: 100000, AllocationType: "pooled"})
+
+	// ECB rates are quoted against EUR, so USD to GBP goes through EUR
+	today := time.Now()
+	rateDay := monthStart(today)
+	if err := currency.Save(db, []models.ExchangeRate{
+		{Date: rateDay, BaseCurrency: "EUR", QuoteCurrency: "USD", Rate: 1.25, Source: "ecb"},
+		{Date: rateDay, BaseCurrency: "EUR", QuoteCurrency: "GBP", Rate: 0.8, Source: "ecb"},
+	}); err != nil {
+		t.Fatalf("Failed to save rates: %v", err)
+	}
+
+	db.Create(&models.Transaction{ID: uuid.New(), UserID: user.ID, BudgetID: budget.ID, Amount: -10000, Currency: "USD", CategoryID: category.ID, Date: today})
+	db.Create(&models.Transaction{ID: uuid.New(), UserID: user.ID, BudgetID: budget.ID, Amount: -8000, Currency: "EUR", CategoryID: category.ID, Date: today})
+	db.Create(&models.Transaction{ID: uuid.New(), UserID: user.ID, BudgetID: budget.ID, Amount: -4000, Currency: "GBP", CategoryID: category.ID, Date: today})
+
+	req := httptest.NewRequest("GET", "/spending/available", nil)
+	req = req.WithContext(setUserIDContext(req, user.ID))
+	w := httptest.NewRecorder()
+
+	handler.GetSpendingAvailable(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response struct {
+		Data SpendingAvailableResponse `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&response)
+
+	if response.Data.BaseCurrency != "USD" {
+		t.Errorf("Expected base currency USD, got %s", response.Data.BaseCurrency)
+	}
+
+	// $100 + €80 ($100) + £40 (€50, $62.50)
+	spending := response.Data.Categories[0]
+	if spending.Spent != 26250 {
+		t.Errorf("Expected 26250 spent in USD, got %d", spending.Spent)
+	}
+	if len(spending.ForeignSpending) != 2 {
+		t.Fatalf("Expected EUR and GBP spending, got %+v", spending.ForeignSpending)
+	}
+	if gbp := spending.ForeignSpending[1]; gbp.Currency != "GBP" || gbp.Amount != 4000 || gbp.Converted != 6250 {
+		t.Errorf("Unexpected GBP spending: %+v", gbp)
+	}
+
+	// Without a rate the summary cannot be calculated
+	db.Create(&models.Transaction{ID: uuid.New(), UserID: user.ID, BudgetID: budget.ID, Amount: -1000, Currency: "JPY", CategoryID: category.ID, Date: today})
+	w = httptest.NewRecorder()
+	handler.GetSpendingAvailable(w, req)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status 422 without a JPY rate, got %d", w.Code)
+	}
+}
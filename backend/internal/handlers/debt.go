@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/yourusername/folda-finances/internal/middleware"
+	"github.com/yourusername/folda-finances/internal/models"
+	"gorm.io/gorm"
+)
+
+type DebtHandler struct {
+	db *gorm.DB
+}
+
+func NewDebtHandler(db *gorm.DB) *DebtHandler {
+	return &DebtHandler{db: db}
+}
+
+// maxPayoffMonths caps the simulation at 50 years
+const maxPayoffMonths = 600
+
+var errDebtNeverPaidOff = errors.New("payments do not cover the interest, debts would never be paid off")
+
+type DebtPayment struct {
+	AccountID string `json:"account_id"`
+	Payment   int    `json:"payment"`
+	Interest  int    `json:"interest"`
+	Principal int    `json:"principal"`
+	Balance   int    `json:"balance"` // owed after the payment
+}
+
+type PayoffMonth struct {
+	Month            string        `json:"month"` // YYYY-MM
+	Payments         []DebtPayment `json:"payments"`
+	TotalPayment     int           `json:"total_payment"`
+	RemainingBalance int           `json:"remaining_balance"`
+}
+
+type DebtPayoff struct {
+	AccountID      string `json:"account_id"`
+	AccountName    string `json:"account_name"`
+	StartBalance   int    `json:"start_balance"`
+	PayoffDate     string `json:"payoff_date"`
+	InterestPaid   int    `json:"interest_paid"`
+	PayoffOrder    int    `json:"payoff_order"`
+	MonthsToPayoff int    `json:"months_to_payoff"`
+}
+
+type PayoffPlan struct {
+	Strategy      string        `json:"strategy"`
+	Months        int           `json:"months"`
+	PayoffDate    string        `json:"payoff_date"`
+	TotalInterest int           `json:"total_interest"`
+	TotalPaid     int           `json:"total_paid"`
+	Debts         []DebtPayoff  `json:"debts"`
+	Schedule      []PayoffMonth `json:"schedule"`
+}
+
+// payoffDebt is a liability account being paid down in a simulation
+type payoffDebt struct {
+	account        models.Account
+	balance        int
+	apr            float64
+	minimumPayment int
+	dueDay         int
+}
+
+// GetPayoffPlan simulates paying off the budget's liability accounts with the
+// avalanche (highest APR first) and snowball (smallest balance first)
+// strategies, putting ?extra_payment= cents a month on top of the minimums
+func (h *DebtHandler) GetPayoffPlan(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserID(r)
+	if err != nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+
+	extraPayment := 0
+	if extraParam := r.URL.Query().Get("extra_payment"); extraParam != "" {
+		extraPayment, err = strconv.Atoi(extraParam)
+		if err != nil || extraPayment < 0 {
+			respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid extra_payment"})
+			return
+		}
+	}
+
+	user, err := requestUser(h.db, r, userID)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch user"})
+		return
+	}
+
+	if user.BudgetID == nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "user does not belong to a budget"})
+		return
+	}
+
+	var accounts []models.Account
+	if err := h.db.Where("budget_id = ? AND is_active = ?", user.BudgetID, true).Find(&accounts).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch accounts"})
+		return
+	}
+
+	// Only balances owed count; a card in credit has nothing to pay off
+	var liabilities []models.Account
+	for _, account := range accounts {
+		if isLiability(account.Type) && account.Balance < 0 {
+			liabilities = append(liabilities, account)
+		}
+	}
+
+	start := monthStart(time.Now()).AddDate(0, 1, 0)
+	plans := make(map[string]PayoffPlan)
+	for _, strategy := range []string{"avalanche", "snowball"} {
+		plan, err := simulatePayoff(liabilities, extraPayment, strategy, start)
+		if err != nil {
+			respondJSON(w, http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+			return
+		}
+		plans[strategy] = plan
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"extra_payment": extraPayment,
+			"avalanche":     plans["avalanche"],
+			"snowball":      plans["snowball"],
+		},
+	})
+}
+
+// simulatePayoff pays down debts month by month from start. Every debt gets
+// its minimum payment; the extra payment, plus the minimums of debts already
+// paid off, goes to the debt the strategy puts first.
+func simulatePayoff(accounts []models.Account, extraPayment int, strategy string, start time.Time) (PayoffPlan, error) {
+	debts := make([]*payoffDebt, 0, len(accounts))
+	for _, account := range accounts {
+		debt := &payoffDebt{account: account, balance: -account.Balance}
+		if account.APR != nil {
+			debt.apr = *account.APR
+		}
+		// Without a minimum_payment, the same default as a card statement's
+		debt.minimumPayment = minimumDue(account, debt.balance)
+		if account.PaymentDueDay != nil {
+			debt.dueDay = *account.PaymentDueDay
+		}
+		debts = append(debts, debt)
+	}
+
+	// Order debts by the strategy; ties fall back to the other strategy's rule
+	sort.SliceStable(debts, func(i, j int) bool {
+		a, b := debts[i], debts[j]
+		if strategy == "snowball" {
+			if a.balance != b.balance {
+				return a.balance < b.balance
+			}
+			return a.apr > b.apr
+		}
+		if a.apr != b.apr {
+			return a.apr > b.apr
+		}
+		return a.balance < b.balance
+	})
+
+	plan := PayoffPlan{Strategy: strategy, Schedule: []PayoffMonth{}, Debts: []DebtPayoff{}}
+	payoffs := make([]DebtPayoff, len(debts))
+	for i, debt := range debts {
+		payoffs[i] = DebtPayoff{
+			AccountID:    debt.account.ID.String(),
+			AccountName:  debt.account.Name,
+			StartBalance: debt.balance,
+		}
+	}
+
+	remaining := 0
+	for _, debt := range debts {
+		remaining += debt.balance
+	}
+
+	paidOffCount := 0
+	for month := 0; remaining > 0; month++ {
+		if month >= maxPayoffMonths {
+			return PayoffPlan{}, errDebtNeverPaidOff
+		}
+		current := start.AddDate(0, month, 0)
+		schedule := PayoffMonth{Month: current.Format("2006-01"), Payments: []DebtPayment{}}
+
+		// Interest accrues, then the minimums are paid
+		payments := make([]DebtPayment, len(debts))
+		available := extraPayment
+		for i, debt := range debts {
+			if debt.balance == 0 {
+				available += debt.minimumPayment
+				continue
+			}
+			interest := monthlyInterest(debt.balance, debt.apr)
+			debt.balance += interest
+			payment := debt.minimumPayment
+			if payment > debt.balance {
+				available += payment - debt.balance
+				payment = debt.balance
+			}
+			debt.balance -= payment
+			payments[i] = DebtPayment{AccountID: debt.account.ID.String(), Payment: payment, Interest: interest}
+		}
+
+		// Whatever is left goes to debts in strategy order
+		for i, debt := range debts {
+			if available == 0 {
+				break
+			}
+			if debt.balance == 0 {
+				continue
+			}
+			payment := available
+			if payment > debt.balance {
+				payment = debt.balance
+			}
+			debt.balance -= payment
+			available -= payment
+			payments[i].Payment += payment
+		}
+
+		progress := false
+		remaining = 0
+		for i, debt := range debts {
+			payment := payments[i]
+			if payment.AccountID == "" {
+				continue
+			}
+			payment.Principal = payment.Payment - payment.Interest
+			payment.Balance = debt.balance
+			if payment.Principal > 0 {
+				progress = true
+			}
+			schedule.Payments = append(schedule.Payments, payment)
+			schedule.TotalPayment += payment.Payment
+			remaining += debt.balance
+
+			payoffs[i].InterestPaid += payment.Interest
+			plan.TotalInterest += payment.Interest
+			if debt.balance == 0 {
+				paidOffCount++
+				payoffs[i].PayoffOrder = paidOffCount
+				payoffs[i].PayoffDate = payoffDate(current, debt.dueDay)
+				payoffs[i].MonthsToPayoff = month + 1
+			}
+		}
+		if !progress {
+			return PayoffPlan{}, errDebtNeverPaidOff
+		}
+
+		schedule.RemainingBalance = remaining
+		plan.TotalPaid += schedule.TotalPayment
+		plan.Schedule = append(plan.Schedule, schedule)
+		plan.Months = month + 1
+	}
+
+	for _, payoff := range payoffs {
+		plan.Debts = append(plan.Debts, payoff)
+		if payoff.PayoffDate > plan.PayoffDate {
+			plan.PayoffDate = payoff.PayoffDate
+		}
+	}
+	sort.SliceStable(plan.Debts, func(i, j int) bool {
+		return plan.Debts[i].PayoffOrder < plan.Debts[j].PayoffOrder
+	})
+
+	return plan, nil
+}
+
+// payoffDate is the payment due date within month, or the last day of the
+// month when the due day is unknown or past the end of the month
+func payoffDate(month time.Time, dueDay int) string {
+	return dueDate(month, dueDay).Format("2006-01-02")
+}
+
+func dueDate(month time.Time, dueDay int) time.Time {
+	month = monthStart(month)
+	lastDay := month.AddDate(0, 1, -1).Day()
+	if dueDay < 1 || dueDay > lastDay {
+		dueDay = lastDay
+	}
+	return time.Date(month.Year(), month.Month(), dueDay, 0, 0, 0, 0, time.UTC)
+}
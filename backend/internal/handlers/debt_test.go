@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/yourusername/folda-finances/internal/models"
+)
+
+func TestSimulatePayoff(t *testing.T) {
+	highAPR, lowAPR := 24.0, 12.0
+	minimum := 5000
+	dueDay := 15
+	accounts := []models.Account{
+		// Large, expensive balance
+		{ID: uuid.New(), Name: "Rewards Card", Type: "credit_card", Balance: -300000, APR: &highAPR, MinimumPayment: &minimum, PaymentDueDay: &dueDay},
+		// Small, cheap balance
+		{ID: uuid.New(), Name: "Store Card", Type: "credit_card", Balance: -50000, APR: &lowAPR, MinimumPayment: &minimum},
+	}
+	start := testDate("2025-01-01")
+
+	avalanche, err := simulatePayoff(accounts, 20000, "avalanche", start)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	snowball, err := simulatePayoff(accounts, 20000, "snowball", start)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The extra payment goes to the highest APR first with avalanche and to the
+	// smallest balance first with snowball
+	rewardsID, storeID := accounts[0].ID.String(), accounts[1].ID.String()
+	if payment := firstPayment(avalanche, rewardsID); payment != 25000 {
+		t.Errorf("Expected avalanche to put the extra on the rewards card, got %d", payment)
+	}
+	if payment := firstPayment(snowball, storeID); payment != 25000 {
+		t.Errorf("Expected snowball to put the extra on the store card, got %d", payment)
+	}
+	if snowball.Debts[0].AccountName != "Store Card" {
+		t.Errorf("Expected snowball to pay off the smallest balance first, got %s", snowball.Debts[0].AccountName)
+	}
+	if avalanche.TotalInterest >= snowball.TotalInterest {
+		t.Errorf("Expected avalanche interest %d to be below snowball interest %d", avalanche.TotalInterest, snowball.TotalInterest)
+	}
+
+	// Every payment in the schedule adds up to the totals
+	for _, plan := range []PayoffPlan{avalanche, snowball} {
+		paid, interest := 0, 0
+		for _, month := range plan.Schedule {
+			for _, payment := range month.Payments {
+				paid += payment.Payment
+				interest += payment.Interest
+			}
+		}
+		if paid != plan.TotalPaid || interest != plan.TotalInterest {
+			t.Errorf("%s: schedule totals %d/%d do not match plan %d/%d", plan.Strategy, paid, interest, plan.TotalPaid, plan.TotalInterest)
+		}
+		if plan.TotalPaid != 350000+plan.TotalInterest {
+			t.Errorf("%s: expected total paid to be balance plus interest, got %d", plan.Strategy, plan.TotalPaid)
+		}
+		if last := plan.Schedule[len(plan.Schedule)-1]; last.RemainingBalance != 0 {
+			t.Errorf("%s: expected nothing owed after the last month, got %d", plan.Strategy, last.RemainingBalance)
+		}
+	}
+
+	// Payoff lands on the due day, or the end of the month without one
+	for _, debt := range avalanche.Debts {
+		if debt.AccountName == "Rewards Card" && debt.PayoffDate[8:] != "15" {
+			t.Errorf("Expected payoff on the due day, got %s", debt.PayoffDate)
+		}
+		if debt.AccountName == "Store Card" && debt.PayoffDate != "2025-11-30" {
+			t.Errorf("Expected store card paid off at the end of November, got %s", debt.PayoffDate)
+		}
+	}
+}
+
+func firstPayment(plan PayoffPlan, accountID string) int {
+	for _, payment := range plan.Schedule[0].Payments {
+		if payment.AccountID == accountID {
+			return payment.Payment
+		}
+	}
+	return 0
+}
+
+func TestSimulatePayoff_NeverPaidOff(t *testing.T) {
+	apr := 30.0
+	minimum := 1000
+	accounts := []models.Account{
+		{ID: uuid.New(), Name: "Card", Type: "credit_card", Balance: -500000, APR: &apr, MinimumPayment: &minimum},
+	}
+
+	if _, err := simulatePayoff(accounts, 0, "avalanche", testDate("2025-01-01")); err != errDebtNeverPaidOff {
+		t.Errorf("Expected errDebtNeverPaidOff, got %v", err)
+	}
+}
+
+func TestSimulatePayoff_DefaultMinimum(t *testing.T) {
+	apr := 20.0
+	accounts := []models.Account{
+		{ID: uuid.New(), Name: "Card", Type: "credit_card", Balance: -100000, APR: &apr},
+	}
+
+	plan, err := simulatePayoff(accounts, 0, "avalanche", testDate("2025-01-01"))
+	if err != nil {
+		t.Fatalf("Expected a plan, got %v", err)
+	}
+	// 2% of $1,000 is below the $25 floor
+	if payment := firstPayment(plan, accounts[0].ID.String()); payment != 2500 {
+		t.Errorf("Expected default minimum payment 2500, got %d", payment)
+	}
+}
+
+func TestGetPayoffPlan_SkipsCardsInCredit(t *testing.T) {
+	db := setupTestDB(t)
+	handler := NewDebtHandler(db)
+
+	user, budget := createTestUser(t, db, "test@example.com")
+	apr := 20.0
+	owed := models.Account{ID: uuid.New(), BudgetID: budget.ID, Name: "Owed Card", Type: "credit_card", Balance: -100000, APR: &apr, IsActive: true}
+	credit := models.Account{ID: uuid.New(), BudgetID: budget.ID, Name: "Refunded Card", Type: "credit_card", Balance: 5000, APR: &apr, IsActive: true}
+	db.Create(&owed)
+	db.Create(&credit)
+
+	w := httptest.NewRecorder()
+	handler.GetPayoffPlan(w, testRequest("GET", "/debt-payoff", nil, user.ID, nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response struct {
+		Data struct {
+			Avalanche PayoffPlan `json:"avalanche"`
+		} `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	debts := response.Data.Avalanche.Debts
+	if len(debts) != 1 || debts[0].AccountID != owed.ID.String() {
+		t.Fatalf("Expected only the owed card in the plan, got %+v", debts)
+	}
+	if debts[0].StartBalance != 100000 {
+		t.Errorf("Expected start balance 100000, got %d", debts[0].StartBalance)
+	}
+}
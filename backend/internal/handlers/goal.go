@@ -0,0 +1,568 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/yourusername/folda-finances/internal/middleware"
+	"github.com/yourusername/folda-finances/internal/models"
+	"gorm.io/gorm"
+)
+
+type GoalHandler struct {
+	db *gorm.DB
+}
+
+func NewGoalHandler(db *gorm.DB) *GoalHandler {
+	return &GoalHandler{db: db}
+}
+
+type CreateGoalRequest struct {
+	Name             string  `json:"name"`
+	TargetAmount     int     `json:"target_amount"`
+	TargetDate       string  `json:"target_date"`
+	CurrentAmount    int     `json:"current_amount"` // optional amount already saved
+	AccountID        *string `json:"account_id"`
+	CategoryBudgetID *string `json:"category_budget_id"`
+}
+
+type UpdateGoalRequest struct {
+	Name             *string `json:"name"`
+	TargetAmount     *int    `json:"target_amount"`
+	TargetDate       *string `json:"target_date"`
+	AccountID        *string `json:"account_id"`         // empty string unlinks
+	CategoryBudgetID *string `json:"category_budget_id"` // empty string unlinks
+}
+
+type CreateGoalContributionRequest struct {
+	Amount        int     `json:"amount"`
+	Date          string  `json:"date"`
+	Note          string  `json:"note"`
+	TransactionID *string `json:"transaction_id"` // takes amount and date from the transaction
+}
+
+// GoalProgress is a goal with its computed progress
+type GoalProgress struct {
+	models.Goal
+	CurrentAmount   int                       `json:"current_amount"`
+	PercentComplete float64                   `json:"percent_complete"`
+	MonthlyRequired int                       `json:"monthly_required"`
+	ExpectedAmount  int                       `json:"expected_amount"` // saved by now if on a straight line to the target
+	OnTrack         bool                      `json:"on_track"`
+	Contributions   []models.GoalContribution `json:"contributions,omitempty"`
+}
+
+func (h *GoalHandler) ListGoals(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserID(r)
+	if err != nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+
+	user, err := requestUser(h.db, r, userID)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch user"})
+		return
+	}
+
+	if user.BudgetID == nil {
+		respondJSON(w, http.StatusOK, map[string]interface{}{"data": []GoalProgress{}})
+		return
+	}
+
+	var goals []models.Goal
+	if err := h.db.Where("budget_id = ?", user.BudgetID).Order("target_date ASC").Find(&goals).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch goals"})
+		return
+	}
+
+	now := time.Now()
+	progress := make([]GoalProgress, 0, len(goals))
+	for _, goal := range goals {
+		var contributions []models.GoalContribution
+		if err := h.db.Where("goal_id = ?", goal.ID).Find(&contributions).Error; err != nil {
+			respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch contributions"})
+			return
+		}
+		progress = append(progress, calculateGoalProgress(goal, contributions, now))
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"data": progress})
+}
+
+func (h *GoalHandler) CreateGoal(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserID(r)
+	if err != nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+
+	var req CreateGoalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+
+	if req.Name == "" {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "goal name is required"})
+		return
+	}
+	if req.TargetAmount <= 0 {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "target_amount must be positive"})
+		return
+	}
+
+	targetDate, err := time.Parse("2006-01-02", req.TargetDate)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid date format"})
+		return
+	}
+
+	user, err := requestUser(h.db, r, userID)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch user"})
+		return
+	}
+
+	if user.BudgetID == nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "user does not belong to a budget"})
+		return
+	}
+
+	goal := models.Goal{
+		BudgetID:     *user.BudgetID,
+		Name:         req.Name,
+		TargetAmount: req.TargetAmount,
+		TargetDate:   targetDate,
+	}
+
+	if req.AccountID != nil && *req.AccountID != "" {
+		accountID, status, msg := budgetAccount(h.db, *req.AccountID, *user.BudgetID)
+		if status != 0 {
+			respondJSON(w, status, map[string]string{"error": msg})
+			return
+		}
+		goal.AccountID = &accountID
+	}
+	if req.CategoryBudgetID != nil && *req.CategoryBudgetID != "" {
+		categoryBudgetID, status, msg := h.budgetCategoryBudget(*req.CategoryBudgetID, *user.BudgetID)
+		if status != 0 {
+			respondJSON(w, status, map[string]string{"error": msg})
+			return
+		}
+		goal.CategoryBudgetID = &categoryBudgetID
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&goal).Error; err != nil {
+			return err
+		}
+		if req.CurrentAmount != 0 {
+			initial := models.GoalContribution{
+				GoalID: goal.ID,
+				UserID: userID,
+				Amount: req.CurrentAmount,
+				Date:   time.Now(),
+				Note:   "Starting amount",
+			}
+			if err := tx.Create(&initial).Error; err != nil {
+				return err
+			}
+		}
+		return syncGoalCategoryBudget(tx, goal)
+	})
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to create goal"})
+		return
+	}
+
+	progress, err := h.goalProgress(goal)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch goal progress"})
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, map[string]interface{}{
+		"data":    progress,
+		"message": "Goal created successfully",
+	})
+}
+
+func (h *GoalHandler) GetGoal(w http.ResponseWriter, r *http.Request) {
+	goal, ok := h.authorizedGoal(w, r)
+	if !ok {
+		return
+	}
+
+	progress, err := h.goalProgress(goal)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch goal progress"})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"data": progress})
+}
+
+func (h *GoalHandler) UpdateGoal(w http.ResponseWriter, r *http.Request) {
+	goal, ok := h.authorizedGoal(w, r)
+	if !ok {
+		return
+	}
+
+	var req UpdateGoalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if req.Name != nil {
+		if *req.Name == "" {
+			respondJSON(w, http.StatusBadRequest, map[string]string{"error": "goal name is required"})
+			return
+		}
+		updates["name"] = *req.Name
+	}
+	if req.TargetAmount != nil {
+		if *req.TargetAmount <= 0 {
+			respondJSON(w, http.StatusBadRequest, map[string]string{"error": "target_amount must be positive"})
+			return
+		}
+		updates["target_amount"] = *req.TargetAmount
+	}
+	if req.TargetDate != nil {
+		targetDate, err := time.Parse("2006-01-02", *req.TargetDate)
+		if err != nil {
+			respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid date format"})
+			return
+		}
+		updates["target_date"] = targetDate
+	}
+	if req.AccountID != nil {
+		if *req.AccountID == "" {
+			updates["account_id"] = nil
+		} else {
+			accountID, status, msg := budgetAccount(h.db, *req.AccountID, goal.BudgetID)
+			if status != 0 {
+				respondJSON(w, status, map[string]string{"error": msg})
+				return
+			}
+			updates["account_id"] = accountID
+		}
+	}
+	if req.CategoryBudgetID != nil {
+		if *req.CategoryBudgetID == "" {
+			updates["category_budget_id"] = nil
+		} else {
+			categoryBudgetID, status, msg := h.budgetCategoryBudget(*req.CategoryBudgetID, goal.BudgetID)
+			if status != 0 {
+				respondJSON(w, status, map[string]string{"error": msg})
+				return
+			}
+			updates["category_budget_id"] = categoryBudgetID
+		}
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&goal).Updates(updates).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&goal, "id = ?", goal.ID).Error; err != nil {
+			return err
+		}
+		return syncGoalCategoryBudget(tx, goal)
+	})
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to update goal"})
+		return
+	}
+
+	progress, err := h.goalProgress(goal)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch goal progress"})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data":    progress,
+		"message": "Goal updated successfully",
+	})
+}
+
+func (h *GoalHandler) DeleteGoal(w http.ResponseWriter, r *http.Request) {
+	goal, ok := h.authorizedGoal(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("goal_id = ?", goal.ID).Delete(&models.GoalContribution{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&goal).Error
+	})
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to delete goal"})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"message": "Goal deleted successfully",
+	})
+}
+
+// CreateGoalContribution records money put towards a goal
+func (h *GoalHandler) CreateGoalContribution(w http.ResponseWriter, r *http.Request) {
+	goal, ok := h.authorizedGoal(w, r)
+	if !ok {
+		return
+	}
+
+	userID, err := middleware.GetUserID(r)
+	if err != nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+
+	var req CreateGoalContributionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+
+	contribution := models.GoalContribution{
+		GoalID: goal.ID,
+		UserID: userID,
+		Amount: req.Amount,
+		Note:   req.Note,
+	}
+
+	if req.TransactionID != nil {
+		var transaction models.Transaction
+		if err := h.db.First(&transaction, "id = ?", *req.TransactionID).Error; err != nil {
+			respondJSON(w, http.StatusBadRequest, map[string]string{"error": "transaction not found"})
+			return
+		}
+		if transaction.BudgetID != goal.BudgetID {
+			respondJSON(w, http.StatusForbidden, map[string]string{"error": "access denied"})
+			return
+		}
+
+		var existing int64
+		if err := h.db.Model(&models.GoalContribution{}).
+			Where("goal_id = ? AND transaction_id = ?", goal.ID, transaction.ID).Count(&existing).Error; err != nil {
+			respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to check contributions"})
+			return
+		}
+		if existing > 0 {
+			respondJSON(w, http.StatusConflict, map[string]string{"error": "transaction is already linked to this goal"})
+			return
+		}
+
+		// Money leaving a spending account is money going into the goal
+		contribution.TransactionID = &transaction.ID
+		contribution.Amount = transaction.Amount
+		if contribution.Amount < 0 {
+			contribution.Amount = -contribution.Amount
+		}
+		contribution.Date = transaction.Date
+		if contribution.Note == "" {
+			contribution.Note = transaction.Description
+		}
+	} else {
+		if req.Amount == 0 {
+			respondJSON(w, http.StatusBadRequest, map[string]string{"error": "amount is required"})
+			return
+		}
+		contribution.Date = time.Now()
+		if req.Date != "" {
+			date, err := time.Parse("2006-01-02", req.Date)
+			if err != nil {
+				respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid date format"})
+				return
+			}
+			contribution.Date = date
+		}
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&contribution).Error; err != nil {
+			return err
+		}
+		return syncGoalCategoryBudget(tx, goal)
+	})
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to create contribution"})
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, map[string]interface{}{
+		"data":    contribution,
+		"message": "Contribution added successfully",
+	})
+}
+
+// DeleteGoalContribution removes a contribution from a goal
+func (h *GoalHandler) DeleteGoalContribution(w http.ResponseWriter, r *http.Request) {
+	goal, ok := h.authorizedGoal(w, r)
+	if !ok {
+		return
+	}
+
+	var contribution models.GoalContribution
+	if err := h.db.First(&contribution, "id = ? AND goal_id = ?", chi.URLParam(r, "contributionId"), goal.ID).Error; err != nil {
+		respondJSON(w, http.StatusNotFound, map[string]string{"error": "contribution not found"})
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&contribution).Error; err != nil {
+			return err
+		}
+		return syncGoalCategoryBudget(tx, goal)
+	})
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to delete contribution"})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"message": "Contribution deleted successfully",
+	})
+}
+
+// authorizedGoal loads the goal in the URL and checks that the current user
+// belongs to its budget, writing the error response if not
+func (h *GoalHandler) authorizedGoal(w http.ResponseWriter, r *http.Request) (models.Goal, bool) {
+	var goal models.Goal
+
+	userID, err := middleware.GetUserID(r)
+	if err != nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return goal, false
+	}
+
+	if err := h.db.First(&goal, "id = ?", chi.URLParam(r, "id")).Error; err != nil {
+		respondJSON(w, http.StatusNotFound, map[string]string{"error": "goal not found"})
+		return goal, false
+	}
+
+	user, err := requestUser(h.db, r, userID)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch user"})
+		return goal, false
+	}
+
+	if user.BudgetID == nil || *user.BudgetID != goal.BudgetID {
+		respondJSON(w, http.StatusForbidden, map[string]string{"error": "access denied"})
+		return goal, false
+	}
+
+	return goal, true
+}
+
+// budgetAccount checks that an account belongs to the budget. A non-zero
+// status is the error response to send.
+func budgetAccount(db *gorm.DB, id string, budgetID uuid.UUID) (uuid.UUID, int, string) {
+	var account models.Account
+	if err := db.First(&account, "id = ?", id).Error; err != nil {
+		return uuid.Nil, http.StatusBadRequest, "account not found"
+	}
+	if account.BudgetID != budgetID {
+		return uuid.Nil, http.StatusForbidden, "access denied"
+	}
+	return account.ID, 0, ""
+}
+
+// budgetCategoryBudget checks that a category budget belongs to the budget. A
+// non-zero status is the error response to send.
+func (h *GoalHandler) budgetCategoryBudget(id string, budgetID uuid.UUID) (uuid.UUID, int, string) {
+	var categoryBudget models.CategoryBudget
+	if err := h.db.First(&categoryBudget, "id = ?", id).Error; err != nil {
+		return uuid.Nil, http.StatusBadRequest, "category budget not found"
+	}
+	if categoryBudget.BudgetID != budgetID {
+		return uuid.Nil, http.StatusForbidden, "access denied"
+	}
+	return categoryBudget.ID, 0, ""
+}
+
+func (h *GoalHandler) goalProgress(goal models.Goal) (GoalProgress, error) {
+	var contributions []models.GoalContribution
+	if err := h.db.Where("goal_id = ?", goal.ID).Order("date ASC").Find(&contributions).Error; err != nil {
+		return GoalProgress{}, err
+	}
+
+	progress := calculateGoalProgress(goal, contributions, time.Now())
+	progress.Contributions = contributions
+	return progress, nil
+}
+
+// calculateGoalProgress works out how far along a goal is, the monthly
+// saving still required to reach it by the target date, and whether the
+// savings so far keep pace with a straight line from the goal's creation to
+// its target date
+func calculateGoalProgress(goal models.Goal, contributions []models.GoalContribution, now time.Time) GoalProgress {
+	current := 0
+	for _, contribution := range contributions {
+		current += contribution.Amount
+	}
+
+	percentComplete := 0.0
+	if goal.TargetAmount > 0 {
+		percentComplete = (float64(current) / float64(goal.TargetAmount)) * 100
+	}
+
+	monthlyRequired := 0
+	if remaining := goal.TargetAmount - current; remaining > 0 {
+		months := monthsUntil(monthStart(now), monthStart(goal.TargetDate))
+		if months < 1 {
+			months = 1
+		}
+		monthlyRequired = (remaining + months - 1) / months
+	}
+
+	expected := goal.TargetAmount
+	total := goal.TargetDate.Sub(goal.CreatedAt)
+	if elapsed := now.Sub(goal.CreatedAt); total > 0 && elapsed < total {
+		expected = int(float64(goal.TargetAmount) * (float64(elapsed) / float64(total)))
+	}
+
+	return GoalProgress{
+		Goal:            goal,
+		CurrentAmount:   current,
+		PercentComplete: percentComplete,
+		MonthlyRequired: monthlyRequired,
+		ExpectedAmount:  expected,
+		OnTrack:         current >= expected,
+	}
+}
+
+// syncGoalCategoryBudget sets a linked category budget to the goal's required
+// monthly saving from this month forward
+func syncGoalCategoryBudget(tx *gorm.DB, goal models.Goal) error {
+	if goal.CategoryBudgetID == nil {
+		return nil
+	}
+
+	var categoryBudget models.CategoryBudget
+	if err := tx.First(&categoryBudget, "id = ?", goal.CategoryBudgetID).Error; err != nil {
+		return err
+	}
+
+	var contributions []models.GoalContribution
+	if err := tx.Where("goal_id = ?", goal.ID).Find(&contributions).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	progress := calculateGoalProgress(goal, contributions, now)
+	amount, err := setCategoryBudgetAmount(tx, categoryBudget, progress.MonthlyRequired, monthStart(now), false)
+	if err != nil {
+		return err
+	}
+	return tx.Model(&categoryBudget).Update("amount", amount).Error
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/yourusername/folda-finances/internal/models"
+)
+
+func TestCalculateGoalProgress(t *testing.T) {
+	goal := models.Goal{
+		TargetAmount: 1200000, // $12,000
+		TargetDate:   testDate("2025-12-31"),
+		CreatedAt:    testDate("2025-01-01"),
+	}
+	contributions := []models.GoalContribution{
+		{Amount: 300000},
+		{Amount: 100000},
+	}
+
+	progress := calculateGoalProgress(goal, contributions, testDate("2025-07-01"))
+
+	if progress.CurrentAmount != 400000 {
+		t.Errorf("Expected current amount 400000, got %d", progress.CurrentAmount)
+	}
+	if progress.PercentComplete < 33.3 || progress.PercentComplete > 33.4 {
+		t.Errorf("Expected about 33.3%% complete, got %v", progress.PercentComplete)
+	}
+	// $8,000 left over July through December
+	if progress.MonthlyRequired != 133334 {
+		t.Errorf("Expected monthly required 133334, got %d", progress.MonthlyRequired)
+	}
+	// Halfway through the year only a third has been saved
+	if progress.OnTrack {
+		t.Errorf("Expected goal to be behind, expected amount %d", progress.ExpectedAmount)
+	}
+
+	contributions = append(contributions, models.GoalContribution{Amount: 300000})
+	if progress := calculateGoalProgress(goal, contributions, testDate("2025-07-01")); !progress.OnTrack {
+		t.Errorf("Expected goal to be on track with %d saved", progress.CurrentAmount)
+	}
+}
+
+func TestCreateGoalContribution_FeedsCategoryBudget(t *testing.T) {
+	db := setupTestDB(t)
+	handler := NewGoalHandler(db)
+
+	user, budget := createTestUser(t, db, "test@example.com")
+	category := createTestCategory(t, db, budget.ID, "Savings")
+	categoryBudget := &models.CategoryBudget{
+		ID:             uuid.New(),
+		BudgetID:       budget.ID,
+		CategoryID:     category.ID,
+		Amount:         0,
+		AllocationType: "pooled",
+	}
+	db.Create(categoryBudget)
+
+	// Ten months from now, so the monthly saving divides evenly
+	targetDate := monthStart(time.Now()).AddDate(0, 9, 0)
+	goal := &models.Goal{
+		ID:               uuid.New(),
+		BudgetID:         budget.ID,
+		Name:             "Emergency Fund",
+		TargetAmount:     100000,
+		TargetDate:       targetDate,
+		CategoryBudgetID: &categoryBudget.ID,
+	}
+	db.Create(goal)
+
+	transaction := &models.Transaction{
+		ID:         uuid.New(),
+		UserID:     user.ID,
+		BudgetID:   budget.ID,
+		Amount:     -20000,
+		CategoryID: category.ID,
+		Date:       time.Now(),
+	}
+	db.Create(transaction)
+
+	transactionID := transaction.ID.String()
+	body, _ := json.Marshal(CreateGoalContributionRequest{TransactionID: &transactionID})
+	req := httptest.NewRequest("POST", "/goals/"+goal.ID.String()+"/contributions", bytes.NewBuffer(body))
+	req = req.WithContext(setUserIDContext(req, user.ID))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", goal.ID.String())
+	req = req.WithContext(setRouteContext(req, rctx))
+	w := httptest.NewRecorder()
+
+	handler.CreateGoalContribution(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var contribution models.GoalContribution
+	db.First(&contribution, "goal_id = ?", goal.ID)
+	if contribution.Amount != 20000 {
+		t.Errorf("Expected linked contribution of 20000, got %d", contribution.Amount)
+	}
+
+	// $800 left over ten months
+	var updated models.CategoryBudget
+	db.First(&updated, "id = ?", categoryBudget.ID)
+	if updated.Amount != 8000 {
+		t.Errorf("Expected category budget amount 8000, got %d", updated.Amount)
+	}
+}
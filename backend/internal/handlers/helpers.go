@@ -3,6 +3,12 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/yourusername/folda-finances/internal/middleware"
+	"github.com/yourusername/folda-finances/internal/models"
+	"gorm.io/gorm"
 )
 
 // respondJSON sends a JSON response
@@ -11,3 +17,91 @@ func respondJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(data)
 }
+
+// requestUser loads the requesting user with BudgetID and BudgetRole set to
+// the budget the request acts on: the one it selected by path or header, else
+// the user's default budget
+func requestUser(db *gorm.DB, r *http.Request, userID uuid.UUID) (models.User, error) {
+	var user models.User
+	if err := db.First(&user, "id = ?", userID).Error; err != nil {
+		return user, err
+	}
+
+	if budgetID, ok := middleware.GetBudgetID(r); ok {
+		user.BudgetID = &budgetID
+		user.BudgetRole = middleware.GetBudgetRole(r)
+		return user, nil
+	}
+	if user.BudgetID == nil {
+		return user, nil
+	}
+
+	member, err := budgetMember(db, *user.BudgetID, user.ID)
+	if err == nil {
+		user.BudgetRole = member.Role
+	} else if err != gorm.ErrRecordNotFound {
+		return user, err
+	}
+	return user, nil
+}
+
+// budgetMember fetches a user's membership of a budget, returning
+// gorm.ErrRecordNotFound when they are not a member
+func budgetMember(db *gorm.DB, budgetID, userID uuid.UUID) (models.BudgetMember, error) {
+	var member models.BudgetMember
+	err := db.First(&member, "budget_id = ? AND user_id = ?", budgetID, userID).Error
+	return member, err
+}
+
+// userBudgetID returns the budget of the requesting user, writing the error
+// response when there is none
+func userBudgetID(db *gorm.DB, w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	userID, err := middleware.GetUserID(r)
+	if err != nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return uuid.Nil, false
+	}
+
+	user, err := requestUser(db, r, userID)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch user"})
+		return uuid.Nil, false
+	}
+
+	if user.BudgetID == nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "user does not belong to a budget"})
+		return uuid.Nil, false
+	}
+
+	return *user.BudgetID, true
+}
+
+// authorizedTransaction loads the {id} transaction and checks the user
+// belongs to its budget, writing the error response when not
+func authorizedTransaction(db *gorm.DB, w http.ResponseWriter, r *http.Request) (models.Transaction, uuid.UUID, bool) {
+	var transaction models.Transaction
+
+	userID, err := middleware.GetUserID(r)
+	if err != nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return transaction, uuid.Nil, false
+	}
+
+	if err := db.First(&transaction, "id = ?", chi.URLParam(r, "id")).Error; err != nil {
+		respondJSON(w, http.StatusNotFound, map[string]string{"error": "transaction not found"})
+		return transaction, uuid.Nil, false
+	}
+
+	user, err := requestUser(db, r, userID)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch user"})
+		return transaction, uuid.Nil, false
+	}
+
+	if user.BudgetID == nil || *user.BudgetID != transaction.BudgetID {
+		respondJSON(w, http.StatusForbidden, map[string]string{"error": "access denied"})
+		return transaction, uuid.Nil, false
+	}
+
+	return transaction, userID, true
+}
@@ -41,8 +41,8 @@ func (h *IncomeHandler) ListExpectedIncome(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	var user models.User
-	if err := h.db.First(&user, "id = ?", userID).Error; err != nil {
+	user, err := requestUser(h.db, r, userID)
+	if err != nil {
 		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch user"})
 		return
 	}
@@ -74,8 +74,8 @@ func (h *IncomeHandler) CreateExpectedIncome(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	var user models.User
-	if err := h.db.First(&user, "id = ?", userID).Error; err != nil {
+	user, err := requestUser(h.db, r, userID)
+	if err != nil {
 		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch user"})
 		return
 	}
@@ -126,8 +126,8 @@ func (h *IncomeHandler) UpdateExpectedIncome(w http.ResponseWriter, r *http.Requ
 	}
 
 	// Verify user has access
-	var user models.User
-	if err := h.db.First(&user, "id = ?", userID).Error; err != nil {
+	user, err := requestUser(h.db, r, userID)
+	if err != nil {
 		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch user"})
 		return
 	}
@@ -196,8 +196,8 @@ func (h *IncomeHandler) DeleteExpectedIncome(w http.ResponseWriter, r *http.Requ
 	}
 
 	// Verify user has access
-	var user models.User
-	if err := h.db.First(&user, "id = ?", userID).Error; err != nil {
+	user, err := requestUser(h.db, r, userID)
+	if err != nil {
 		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch user"})
 		return
 	}
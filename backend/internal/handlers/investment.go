@@ -0,0 +1,532 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yourusername/folda-finances/internal/models"
+	"gorm.io/gorm"
+)
+
+// quantityEpsilon absorbs floating point error when comparing share quantities
+const quantityEpsilon = 1e-8
+
+var (
+	errInsufficientShares = errors.New("cannot sell more shares than are held")
+	errSellBeforeLot      = errors.New("cannot sell shares before the date they were bought")
+)
+
+type CreateInvestmentTransactionRequest struct {
+	Type     string  `json:"type"` // buy, sell, dividend
+	Symbol   string  `json:"symbol"`
+	Quantity float64 `json:"quantity"`
+	Price    int     `json:"price"`  // per unit, in cents
+	Fees     int     `json:"fees"`   // in cents
+	Amount   int     `json:"amount"` // dividends only, in cents
+	Date     string  `json:"date"`
+	Notes    string  `json:"notes"`
+}
+
+type Holding struct {
+	Symbol                string                 `json:"symbol"`
+	Quantity              float64                `json:"quantity"`
+	CostBasis             int                    `json:"cost_basis"`
+	AverageCost           int                    `json:"average_cost"` // per unit
+	Price                 int                    `json:"price"`
+	PriceDate             *string                `json:"price_date"` // null when priced from the last trade
+	MarketValue           int                    `json:"market_value"`
+	UnrealizedGain        int                    `json:"unrealized_gain"`
+	UnrealizedGainPercent float64                `json:"unrealized_gain_percent"`
+	Allocation            float64                `json:"allocation"` // percentage of the account's market value
+	Lots                  []models.InvestmentLot `json:"lots"`
+}
+
+type HoldingsSummary struct {
+	AccountID             string    `json:"account_id"`
+	MarketValue           int       `json:"market_value"`
+	CostBasis             int       `json:"cost_basis"`
+	UnrealizedGain        int       `json:"unrealized_gain"`
+	UnrealizedGainPercent float64   `json:"unrealized_gain_percent"`
+	Holdings              []Holding `json:"holdings"`
+}
+
+// GetHoldings returns an investment account's open positions valued at the
+// latest stored prices
+func (h *AccountHandler) GetHoldings(w http.ResponseWriter, r *http.Request) {
+	account, _, ok := h.authorizedInvestment(w, r)
+	if !ok {
+		return
+	}
+
+	var lots []models.InvestmentLot
+	if err := h.db.Where("account_id = ? AND remaining_quantity > ?", account.ID, quantityEpsilon).
+		Order("acquired_at ASC, created_at ASC").Find(&lots).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch holdings"})
+		return
+	}
+
+	prices, err := latestPrices(h.db, account, lots, time.Now())
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch prices"})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"data": calculateHoldings(account.ID, lots, prices)})
+}
+
+// ListInvestmentTransactions returns an investment account's buys, sells and
+// dividends, newest first
+func (h *AccountHandler) ListInvestmentTransactions(w http.ResponseWriter, r *http.Request) {
+	account, _, ok := h.authorizedInvestment(w, r)
+	if !ok {
+		return
+	}
+
+	var transactions []models.InvestmentTransaction
+	if err := h.db.Where("account_id = ?", account.ID).Order("date DESC, created_at DESC").Find(&transactions).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch investment transactions"})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"data": transactions})
+}
+
+// CreateInvestmentTransaction records a buy, which opens a lot, a sell, which
+// closes lots first in, first out and realizes the gain, or a dividend
+func (h *AccountHandler) CreateInvestmentTransaction(w http.ResponseWriter, r *http.Request) {
+	account, user, ok := h.authorizedInvestment(w, r)
+	if !ok {
+		return
+	}
+
+	var req CreateInvestmentTransactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+
+	symbol := normalizeSymbol(req.Symbol)
+	if symbol == "" {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "symbol is required"})
+		return
+	}
+
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid date format"})
+		return
+	}
+
+	if req.Fees < 0 {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "fees cannot be negative"})
+		return
+	}
+
+	transaction := models.InvestmentTransaction{
+		AccountID: account.ID,
+		UserID:    user.ID,
+		Type:      req.Type,
+		Symbol:    symbol,
+		Fees:      req.Fees,
+		Date:      date,
+		Notes:     req.Notes,
+	}
+
+	switch req.Type {
+	case "buy", "sell":
+		if req.Quantity <= 0 || req.Price <= 0 {
+			respondJSON(w, http.StatusBadRequest, map[string]string{"error": "quantity and price must be greater than 0"})
+			return
+		}
+		transaction.Quantity = req.Quantity
+		transaction.Price = req.Price
+		gross := int(math.Round(req.Quantity * float64(req.Price)))
+		if req.Type == "buy" {
+			transaction.Amount = gross + req.Fees
+		} else {
+			transaction.Amount = gross - req.Fees
+		}
+	case "dividend":
+		if req.Amount <= 0 {
+			respondJSON(w, http.StatusBadRequest, map[string]string{"error": "amount must be greater than 0"})
+			return
+		}
+		transaction.Amount = req.Amount - req.Fees
+	default:
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "type must be buy, sell or dividend"})
+		return
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		switch transaction.Type {
+		case "buy":
+			lot := models.InvestmentLot{
+				AccountID:         account.ID,
+				Symbol:            symbol,
+				Quantity:          transaction.Quantity,
+				RemainingQuantity: transaction.Quantity,
+				CostBasis:         transaction.Amount,
+				AcquiredAt:        date,
+			}
+			if err := tx.Create(&lot).Error; err != nil {
+				return err
+			}
+			transaction.LotID = &lot.ID
+		case "sell":
+			var lots []models.InvestmentLot
+			if err := tx.Where("account_id = ? AND symbol = ? AND remaining_quantity > ?", account.ID, symbol, quantityEpsilon).
+				Order("acquired_at ASC, created_at ASC").Find(&lots).Error; err != nil {
+				return err
+			}
+			held := make(map[uuid.UUID]float64, len(lots))
+			for _, lot := range lots {
+				held[lot.ID] = lot.RemainingQuantity
+			}
+			costOfSold, err := sellLots(lots, transaction.Quantity)
+			if err != nil {
+				return err
+			}
+			for _, lot := range lots {
+				if lot.RemainingQuantity != held[lot.ID] && lot.AcquiredAt.After(date) {
+					return errSellBeforeLot
+				}
+			}
+			for _, lot := range lots {
+				if err := tx.Model(&models.InvestmentLot{}).Where("id = ?", lot.ID).
+					Update("remaining_quantity", lot.RemainingQuantity).Error; err != nil {
+					return err
+				}
+			}
+			gain := transaction.Amount - costOfSold
+			transaction.RealizedGain = &gain
+		}
+		return tx.Create(&transaction).Error
+	})
+	if errors.Is(err, errInsufficientShares) || errors.Is(err, errSellBeforeLot) {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to record investment transaction"})
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, map[string]interface{}{
+		"data":    transaction,
+		"message": "Investment transaction recorded successfully",
+	})
+}
+
+// authorizedInvestment loads the {id} account like authorizedAccount and
+// checks that it is an investment account
+func (h *AccountHandler) authorizedInvestment(w http.ResponseWriter, r *http.Request) (models.Account, models.User, bool) {
+	account, user, ok := h.authorizedAccount(w, r)
+	if !ok {
+		return account, user, false
+	}
+
+	if account.Type != "investment" {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "account is not an investment account"})
+		return account, user, false
+	}
+
+	return account, user, true
+}
+
+// securityPrice is the price a holding is valued at and the day it was
+// stored, nil when it comes from the last trade instead
+type securityPrice struct {
+	Price int
+	Date  *time.Time
+}
+
+// latestPrices finds the most recent stored price on or before date for each
+// symbol held, falling back to the account's last trade price
+func latestPrices(db *gorm.DB, account models.Account, lots []models.InvestmentLot, date time.Time) (map[string]securityPrice, error) {
+	prices := make(map[string]securityPrice)
+	for _, lot := range lots {
+		if _, ok := prices[lot.Symbol]; ok {
+			continue
+		}
+
+		var stored models.SecurityPrice
+		err := db.Where("budget_id = ? AND symbol = ? AND date <= ?", account.BudgetID, lot.Symbol, date).
+			Order("date DESC").First(&stored).Error
+		if err == nil {
+			prices[lot.Symbol] = securityPrice{Price: stored.Price, Date: &stored.Date}
+			continue
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+
+		var trade models.InvestmentTransaction
+		err = db.Where("account_id = ? AND symbol = ? AND type IN ?", account.ID, lot.Symbol, []string{"buy", "sell"}).
+			Order("date DESC, created_at DESC").First(&trade).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		prices[lot.Symbol] = securityPrice{Price: trade.Price}
+	}
+	return prices, nil
+}
+
+// calculateHoldings groups open lots by symbol and values them at prices
+func calculateHoldings(accountID uuid.UUID, lots []models.InvestmentLot, prices map[string]securityPrice) HoldingsSummary {
+	bySymbol := make(map[string]*Holding)
+	var symbols []string
+	for _, lot := range lots {
+		holding, ok := bySymbol[lot.Symbol]
+		if !ok {
+			holding = &Holding{Symbol: lot.Symbol, Lots: []models.InvestmentLot{}}
+			bySymbol[lot.Symbol] = holding
+			symbols = append(symbols, lot.Symbol)
+		}
+		holding.Quantity += lot.RemainingQuantity
+		holding.CostBasis += lotCost(lot, lot.RemainingQuantity)
+		holding.Lots = append(holding.Lots, lot)
+	}
+	sort.Strings(symbols)
+
+	summary := HoldingsSummary{AccountID: accountID.String(), Holdings: []Holding{}}
+	for _, symbol := range symbols {
+		holding := bySymbol[symbol]
+		price := prices[symbol]
+		holding.Price = price.Price
+		if price.Date != nil {
+			priceDate := price.Date.Format("2006-01-02")
+			holding.PriceDate = &priceDate
+		}
+		holding.MarketValue = int(math.Round(holding.Quantity * float64(price.Price)))
+		holding.AverageCost = int(math.Round(float64(holding.CostBasis) / holding.Quantity))
+		holding.UnrealizedGain = holding.MarketValue - holding.CostBasis
+		holding.UnrealizedGainPercent = percentOf(holding.UnrealizedGain, holding.CostBasis)
+
+		summary.MarketValue += holding.MarketValue
+		summary.CostBasis += holding.CostBasis
+		summary.Holdings = append(summary.Holdings, *holding)
+	}
+	summary.UnrealizedGain = summary.MarketValue - summary.CostBasis
+	summary.UnrealizedGainPercent = percentOf(summary.UnrealizedGain, summary.CostBasis)
+
+	for i := range summary.Holdings {
+		summary.Holdings[i].Allocation = percentOf(summary.Holdings[i].MarketValue, summary.MarketValue)
+	}
+
+	return summary
+}
+
+// sellLots takes quantity out of date-ordered lots, oldest first, and returns
+// the cost basis of what was sold
+func sellLots(lots []models.InvestmentLot, quantity float64) (int, error) {
+	held := 0.0
+	for _, lot := range lots {
+		held += lot.RemainingQuantity
+	}
+	if quantity > held+quantityEpsilon {
+		return 0, errInsufficientShares
+	}
+
+	cost := 0
+	for i := range lots {
+		if quantity <= quantityEpsilon {
+			break
+		}
+		sold := math.Min(quantity, lots[i].RemainingQuantity)
+		cost += lotCost(lots[i], sold)
+		lots[i].RemainingQuantity -= sold
+		if lots[i].RemainingQuantity < quantityEpsilon {
+			lots[i].RemainingQuantity = 0
+		}
+		quantity -= sold
+	}
+	return cost, nil
+}
+
+// lotCost is the share of a lot's cost basis for quantity units
+func lotCost(lot models.InvestmentLot, quantity float64) int {
+	if lot.Quantity == 0 {
+		return 0
+	}
+	return int(math.Round(float64(lot.CostBasis) * quantity / lot.Quantity))
+}
+
+func percentOf(part, whole int) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return math.Round(float64(part)/float64(whole)*10000) / 100
+}
+
+func normalizeSymbol(symbol string) string {
+	return strings.ToUpper(strings.TrimSpace(symbol))
+}
+
+type PriceHandler struct {
+	db *gorm.DB
+}
+
+func NewPriceHandler(db *gorm.DB) *PriceHandler {
+	return &PriceHandler{db: db}
+}
+
+type PriceInput struct {
+	Symbol string `json:"symbol"`
+	Date   string `json:"date"`
+	Price  int    `json:"price"` // in cents
+}
+
+type UpdatePricesRequest struct {
+	Prices []PriceInput `json:"prices"`
+}
+
+// ListPrices returns the budget's stored prices, optionally for one ?symbol=
+func (h *PriceHandler) ListPrices(w http.ResponseWriter, r *http.Request) {
+	budgetID, ok := userBudgetID(h.db, w, r)
+	if !ok {
+		return
+	}
+
+	query := h.db.Where("budget_id = ?", budgetID)
+	if symbol := r.URL.Query().Get("symbol"); symbol != "" {
+		query = query.Where("symbol = ?", normalizeSymbol(symbol))
+	}
+
+	var prices []models.SecurityPrice
+	if err := query.Order("symbol ASC, date DESC").Find(&prices).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch prices"})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"data": prices})
+}
+
+// UpdatePrices stores prices sent as JSON, replacing any already stored for
+// the same symbol and day
+func (h *PriceHandler) UpdatePrices(w http.ResponseWriter, r *http.Request) {
+	budgetID, ok := userBudgetID(h.db, w, r)
+	if !ok {
+		return
+	}
+
+	var req UpdatePricesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+
+	prices := make([]models.SecurityPrice, 0, len(req.Prices))
+	for i, input := range req.Prices {
+		price, err := newSecurityPrice(budgetID, input.Symbol, input.Date, input.Price, "api")
+		if err != nil {
+			respondJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("price %d: %v", i+1, err)})
+			return
+		}
+		prices = append(prices, price)
+	}
+
+	h.savePrices(w, prices)
+}
+
+// ImportPrices stores prices from a CSV request body with symbol, date and
+// price columns, prices in decimal currency units such as 189.25
+func (h *PriceHandler) ImportPrices(w http.ResponseWriter, r *http.Request) {
+	budgetID, ok := userBudgetID(h.db, w, r)
+	if !ok {
+		return
+	}
+
+	prices, err := parsePriceCSV(budgetID, r.Body)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	h.savePrices(w, prices)
+}
+
+func (h *PriceHandler) savePrices(w http.ResponseWriter, prices []models.SecurityPrice) {
+	if len(prices) == 0 {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "no prices given"})
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		for _, price := range prices {
+			if err := tx.Where("budget_id = ? AND symbol = ? AND date = ?", price.BudgetID, price.Symbol, price.Date).
+				Delete(&models.SecurityPrice{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Create(&price).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to save prices"})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data":    map[string]int{"updated": len(prices)},
+		"message": "Prices updated successfully",
+	})
+}
+
+// parsePriceCSV reads symbol,date,price rows, skipping a header row
+func parsePriceCSV(budgetID uuid.UUID, body io.Reader) ([]models.SecurityPrice, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = 3
+	reader.TrimLeadingSpace = true
+
+	var prices []models.SecurityPrice
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		if line == 1 && strings.EqualFold(strings.TrimSpace(record[0]), "symbol") {
+			continue
+		}
+
+		amount, err := strconv.ParseFloat(strings.TrimSpace(record[2]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid price", line)
+		}
+		price, err := newSecurityPrice(budgetID, record[0], strings.TrimSpace(record[1]), int(math.Round(amount*100)), "csv")
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		prices = append(prices, price)
+	}
+	return prices, nil
+}
+
+func newSecurityPrice(budgetID uuid.UUID, symbol, date string, price int, source string) (models.SecurityPrice, error) {
+	symbol = normalizeSymbol(symbol)
+	if symbol == "" {
+		return models.SecurityPrice{}, errors.New("symbol is required")
+	}
+	parsed, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return models.SecurityPrice{}, errors.New("invalid date format")
+	}
+	if price <= 0 {
+		return models.SecurityPrice{}, errors.New("price must be greater than 0")
+	}
+	return models.SecurityPrice{BudgetID: budgetID, Symbol: symbol, Date: parsed, Price: price, Source: source}, nil
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/yourusername/folda-finances/internal/models"
+)
+
+func TestCalculateHoldings(t *testing.T) {
+	accountID := uuid.New()
+	lots := []models.InvestmentLot{
+		{Symbol: "VTI", Quantity: 10, RemainingQuantity: 10, CostBasis: 200000},
+		{Symbol: "VTI", Quantity: 10, RemainingQuantity: 5, CostBasis: 240000},
+		{Symbol: "BND", Quantity: 20, RemainingQuantity: 20, CostBasis: 150000},
+	}
+	priceDate := testDate("2025-03-01")
+	prices := map[string]securityPrice{
+		"VTI": {Price: 25000, Date: &priceDate},
+		"BND": {Price: 7500},
+	}
+
+	summary := calculateHoldings(accountID, lots, prices)
+
+	if len(summary.Holdings) != 2 || summary.Holdings[0].Symbol != "BND" {
+		t.Fatalf("Expected BND and VTI holdings, got %+v", summary.Holdings)
+	}
+
+	// Half of the second VTI lot is left: $2000 + $1200 cost, 15 shares at $250
+	vti := summary.Holdings[1]
+	if vti.Quantity != 15 || vti.CostBasis != 320000 || vti.MarketValue != 375000 {
+		t.Errorf("Unexpected VTI holding: %+v", vti)
+	}
+	if vti.UnrealizedGain != 55000 || vti.PriceDate == nil || *vti.PriceDate != "2025-03-01" {
+		t.Errorf("Unexpected VTI gain or price date: %d %v", vti.UnrealizedGain, vti.PriceDate)
+	}
+
+	if summary.MarketValue != 525000 || summary.UnrealizedGain != 55000 {
+		t.Errorf("Expected market value 525000 and gain 55000, got %d and %d", summary.MarketValue, summary.UnrealizedGain)
+	}
+	if vti.Allocation != 71.43 || summary.Holdings[0].Allocation != 28.57 {
+		t.Errorf("Expected allocation 71.43/28.57, got %v/%v", vti.Allocation, summary.Holdings[0].Allocation)
+	}
+}
+
+func TestCreateInvestmentTransaction_SellFIFO(t *testing.T) {
+	db := setupTestDB(t)
+	handler := NewAccountHandler(db)
+
+	user, budget := createTestUser(t, db, "test@example.com")
+	account := &models.Account{ID: uuid.New(), BudgetID: budget.ID, Name: "Brokerage", Type: "investment", IsActive: true}
+	db.Create(account)
+
+	record := func(req CreateInvestmentTransactionRequest) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(req)
+		r := httptest.NewRequest("POST", "/accounts/"+account.ID.String()+"/investment-transactions", bytes.NewBuffer(body))
+		r = r.WithContext(setUserIDContext(r, user.ID))
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", account.ID.String())
+		r = r.WithContext(setRouteContext(r, rctx))
+		w := httptest.NewRecorder()
+		handler.CreateInvestmentTransaction(w, r)
+		return w
+	}
+
+	record(CreateInvestmentTransactionRequest{Type: "buy", Symbol: "vti", Quantity: 10, Price: 20000, Date: "2025-01-10"})
+	record(CreateInvestmentTransactionRequest{Type: "buy", Symbol: "VTI", Quantity: 10, Price: 24000, Date: "2025-02-10"})
+
+	// Selling 15 closes the first lot and half the second
+	w := record(CreateInvestmentTransactionRequest{Type: "sell", Symbol: "VTI", Quantity: 15, Price: 25000, Fees: 500, Date: "2025-03-10"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response struct {
+		Data models.InvestmentTransaction `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&response)
+	// $3750 less $5 fees against $2000 + $1200 cost
+	if response.Data.Amount != 374500 || response.Data.RealizedGain == nil || *response.Data.RealizedGain != 54500 {
+		t.Errorf("Expected proceeds 374500 and gain 54500, got %d and %v", response.Data.Amount, response.Data.RealizedGain)
+	}
+
+	var lots []models.InvestmentLot
+	db.Where("account_id = ?", account.ID).Order("acquired_at ASC").Find(&lots)
+	if lots[0].RemainingQuantity != 0 || lots[1].RemainingQuantity != 5 {
+		t.Errorf("Expected lots left with 0 and 5 shares, got %v and %v", lots[0].RemainingQuantity, lots[1].RemainingQuantity)
+	}
+
+	// The shares left were bought in February
+	w = record(CreateInvestmentTransactionRequest{Type: "sell", Symbol: "VTI", Quantity: 1, Price: 25000, Date: "2025-01-20"})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 selling before the shares were bought, got %d", w.Code)
+	}
+
+	w = record(CreateInvestmentTransactionRequest{Type: "sell", Symbol: "VTI", Quantity: 6, Price: 25000, Date: "2025-03-11"})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 selling more than held, got %d", w.Code)
+	}
+}
+
+func TestParsePriceCSV(t *testing.T) {
+	budgetID := uuid.New()
+	csv := "symbol,date,price\nvti,2025-03-01,250.10\nBND, 2025-03-01, 75\n"
+
+	prices, err := parsePriceCSV(budgetID, strings.NewReader(csv))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(prices) != 2 {
+		t.Fatalf("Expected 2 prices, got %d", len(prices))
+	}
+	if prices[0].Symbol != "VTI" || prices[0].Price != 25010 || prices[0].Source != "csv" {
+		t.Errorf("Unexpected first price: %+v", prices[0])
+	}
+	if prices[1].Price != 7500 {
+		t.Errorf("Expected 7500, got %d", prices[1].Price)
+	}
+
+	if _, err := parsePriceCSV(budgetID, strings.NewReader("VTI,2025-03-01,abc\n")); err == nil {
+		t.Error("Expected an error for an invalid price")
+	}
+}
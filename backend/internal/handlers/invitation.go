@@ -57,22 +57,15 @@ func (h *InvitationHandler) InviteToBudget(w http.ResponseWriter, r *http.Reques
 	// Validate role
 	role := req.Role
 	if role == "" {
-		role = "read_write"
+		role = models.BudgetRoleReadWrite
 	}
-	if role != "read_only" && role != "read_write" {
+	if role != models.BudgetRoleReadOnly && role != models.BudgetRoleReadWrite {
 		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid role"})
 		return
 	}
 
-	// Check if user has permission to invite to this budget
-	var user models.User
-	if err := h.db.First(&user, "id = ?", userID).Error; err != nil {
-		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch user"})
-		return
-	}
-
-	if user.BudgetID == nil || *user.BudgetID != budgetID {
-		respondJSON(w, http.StatusForbidden, map[string]string{"error": "you do not have permission to invite to this budget"})
+	// Only the owner decides who joins the budget
+	if !requireBudgetOwner(h.db, w, budgetID, userID, "invite members") {
 		return
 	}
 
@@ -86,12 +79,38 @@ func (h *InvitationHandler) InviteToBudget(w http.ResponseWriter, r *http.Reques
 
 	// Check if user already has access to this budget
 	var existingUser models.User
-	err = h.db.Where("email = ? AND budget_id = ?", req.Email, budgetID).First(&existingUser).Error
+	err = h.db.Where("email = ? AND id IN (?)", req.Email,
+		h.db.Model(&models.BudgetMember{}).Select("user_id").Where("budget_id = ?", budgetID)).First(&existingUser).Error
 	if err == nil {
 		respondJSON(w, http.StatusConflict, map[string]string{"error": "user already has access to this budget"})
 		return
 	}
 
+	// Members and pending invitations both count towards the plan's limit
+	var budget models.Budget
+	if err := h.db.First(&budget, "id = ?", budgetID).Error; err != nil {
+		respondJSON(w, http.StatusNotFound, map[string]string{"error": "budget not found"})
+		return
+	}
+	limit, premium, err := budgetMemberLimit(h.db, budget, time.Now())
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch budget owner"})
+		return
+	}
+	var members, pending int64
+	if err := h.db.Model(&models.BudgetMember{}).Where("budget_id = ?", budgetID).Count(&members).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to count members"})
+		return
+	}
+	if err := h.db.Model(&models.BudgetInvitation{}).Where("budget_id = ? AND status = ? AND expires_at > ?", budgetID, "pending", time.Now()).Count(&pending).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to count invitations"})
+		return
+	}
+	if int(members+pending) >= limit {
+		respondJSON(w, http.StatusForbidden, map[string]string{"error": memberLimitError(limit, premium)})
+		return
+	}
+
 	// Generate invitation token
 	token, err := generateToken()
 	if err != nil {
@@ -179,6 +198,16 @@ func (h *InvitationHandler) AcceptBudgetInvitation(w http.ResponseWriter, r *htt
 		return
 	}
 
+	var budget models.Budget
+	if err := h.db.First(&budget, "id = ?", invitation.BudgetID).Error; err != nil {
+		respondJSON(w, http.StatusNotFound, map[string]string{"error": "budget not found"})
+		return
+	}
+	if !budget.IsActive {
+		respondJSON(w, http.StatusGone, map[string]string{"error": "this budget has been deleted"})
+		return
+	}
+
 	// Get user email from JWT
 	userEmail, err := middleware.GetUserEmail(r)
 	if err != nil {
@@ -196,13 +225,14 @@ func (h *InvitationHandler) AcceptBudgetInvitation(w http.ResponseWriter, r *htt
 	var user models.User
 	if err := h.db.First(&user, "id = ?", userID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			// Auto-create user record if it doesn't exist
+			// Auto-create user record if it doesn't exist, following the
+			// budget's view period until choosing one
+			periodStart := weekStart(time.Now(), budget.WeekStartDay)
 			user = models.User{
 				ID:              userID,
 				Email:           userEmail,
 				Name:            "New User",
-				ViewPeriod:      "monthly",
-				PeriodStartDate: func() *time.Time { t := time.Now(); return &t }(),
+				PeriodStartDate: &periodStart,
 				BudgetID:        nil,
 			}
 			if err := h.db.Create(&user).Error; err != nil {
@@ -215,23 +245,56 @@ func (h *InvitationHandler) AcceptBudgetInvitation(w http.ResponseWriter, r *htt
 		}
 	}
 
-	// Update user's budget_id
-	user.BudgetID = &invitation.BudgetID
-	if err := h.db.Save(&user).Error; err != nil {
-		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to update user"})
+	if _, err := budgetMember(h.db, invitation.BudgetID, userID); err == nil {
+		respondJSON(w, http.StatusConflict, map[string]string{"error": "you are already a member of this budget"})
+		return
+	} else if err != gorm.ErrRecordNotFound {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch budget membership"})
 		return
 	}
 
-	// Update invitation status
+	// The limit may have dropped since the invitation was sent, e.g. when the
+	// owner's premium lapsed
+	limit, premium, err := budgetMemberLimit(h.db, budget, time.Now())
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch budget owner"})
+		return
+	}
+	var members int64
+	if err := h.db.Model(&models.BudgetMember{}).Where("budget_id = ?", budget.ID).Count(&members).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to count members"})
+		return
+	}
+	if int(members) >= limit {
+		respondJSON(w, http.StatusForbidden, map[string]string{"error": memberLimitError(limit, premium)})
+		return
+	}
+
+	// Join the budget alongside any the user already belongs to; it only
+	// becomes their default if they have none
+	member := models.BudgetMember{BudgetID: invitation.BudgetID, UserID: userID, Role: invitation.InvitedRole}
 	now := time.Now()
-	invitation.Status = "accepted"
-	invitation.AcceptedAt = &now
-	if err := h.db.Save(&invitation).Error; err != nil {
-		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to update invitation"})
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&member).Error; err != nil {
+			return err
+		}
+		if user.BudgetID == nil {
+			if err := tx.Model(&user).Update("budget_id", invitation.BudgetID).Error; err != nil {
+				return err
+			}
+		}
+
+		invitation.Status = "accepted"
+		invitation.AcceptedAt = &now
+		return tx.Save(&invitation).Error
+	})
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to accept invitation"})
 		return
 	}
 
 	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data":    member,
 		"message": "Invitation accepted successfully",
 	})
 }
@@ -0,0 +1,401 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/yourusername/folda-finances/internal/models"
+	"gorm.io/gorm"
+)
+
+// System categories loan payments are split into
+const (
+	loanInterestCategory  = "Interest & Fees"
+	loanPrincipalCategory = "Debt Payments"
+)
+
+type AmortizationPayment struct {
+	Number    int    `json:"number"`
+	Date      string `json:"date"`
+	Payment   int    `json:"payment"`
+	Interest  int    `json:"interest"`
+	Principal int    `json:"principal"`
+	Balance   int    `json:"balance"` // owed after the payment
+}
+
+type AmortizationSchedule struct {
+	AccountID        string                `json:"account_id"`
+	MonthlyPayment   int                   `json:"monthly_payment"`
+	TotalInterest    int                   `json:"total_interest"`
+	TotalPaid        int                   `json:"total_paid"`
+	PayoffDate       string                `json:"payoff_date"`
+	ScheduledBalance int                   `json:"scheduled_balance"` // owed today according to the schedule
+	CurrentBalance   int                   `json:"current_balance"`   // owed today according to the account
+	Schedule         []AmortizationPayment `json:"schedule"`
+	Payments         []models.LoanPayment  `json:"payments"`
+}
+
+type CreateLoanPaymentRequest struct {
+	Amount              int    `json:"amount"` // in cents, positive
+	Date                string `json:"date"`
+	InterestCategoryID  string `json:"interest_category_id"`
+	PrincipalCategoryID string `json:"principal_category_id"`
+}
+
+// GetAmortizationSchedule returns a loan's full payment schedule alongside the
+// payments recorded against it
+func (h *AccountHandler) GetAmortizationSchedule(w http.ResponseWriter, r *http.Request) {
+	account, _, ok := h.authorizedLoan(w, r)
+	if !ok {
+		return
+	}
+
+	var payments []models.LoanPayment
+	if err := h.db.Where("account_id = ?", account.ID).Order("date ASC, created_at ASC").Find(&payments).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch loan payments"})
+		return
+	}
+
+	schedule := amortizationSchedule(account)
+	result := AmortizationSchedule{
+		AccountID:        account.ID.String(),
+		MonthlyPayment:   monthlyLoanPayment(*account.LoanPrincipal, *account.APR, *account.LoanTermMonths),
+		ScheduledBalance: scheduledLoanBalance(account, time.Now()),
+		CurrentBalance:   absInt(account.Balance),
+		Schedule:         schedule,
+		Payments:         payments,
+	}
+	for _, payment := range schedule {
+		result.TotalInterest += payment.Interest
+		result.TotalPaid += payment.Payment
+	}
+	if len(schedule) > 0 {
+		result.PayoffDate = schedule[len(schedule)-1].Date
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"data": result})
+}
+
+// CreateLoanPayment records a payment against a loan. Interest due on the
+// current balance is booked as its own transaction and the rest pays down
+// the principal, reducing the account balance.
+func (h *AccountHandler) CreateLoanPayment(w http.ResponseWriter, r *http.Request) {
+	account, user, ok := h.authorizedLoan(w, r)
+	if !ok {
+		return
+	}
+
+	var req CreateLoanPaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+
+	if req.Amount <= 0 {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "amount must be greater than 0"})
+		return
+	}
+
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid date format"})
+		return
+	}
+
+	interestCategoryID, status, msg := h.loanCategory(account.BudgetID, req.InterestCategoryID, loanInterestCategory)
+	if status != 0 {
+		respondJSON(w, status, map[string]string{"error": msg})
+		return
+	}
+	principalCategoryID, status, msg := h.loanCategory(account.BudgetID, req.PrincipalCategoryID, loanPrincipalCategory)
+	if status != 0 {
+		respondJSON(w, status, map[string]string{"error": msg})
+		return
+	}
+
+	owed := absInt(account.Balance)
+	interest, principal, err := splitLoanPayment(owed, *account.APR, req.Amount)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	payment := models.LoanPayment{
+		AccountID: account.ID,
+		UserID:    user.ID,
+		Amount:    req.Amount,
+		Interest:  interest,
+		Principal: principal,
+		Balance:   -(owed - principal),
+		Date:      date,
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if interest > 0 {
+			transaction, err := createLoanTransaction(tx, account, user.ID, -interest, interestCategoryID, date, "interest")
+			if err != nil {
+				return err
+			}
+			payment.InterestTransactionID = &transaction.ID
+		}
+		if principal > 0 {
+			transaction, err := createLoanTransaction(tx, account, user.ID, -principal, principalCategoryID, date, "principal")
+			if err != nil {
+				return err
+			}
+			payment.PrincipalTransactionID = &transaction.ID
+		}
+		if err := tx.Create(&payment).Error; err != nil {
+			return err
+		}
+		return tx.Model(&account).Update("balance", payment.Balance).Error
+	})
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to record loan payment"})
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, map[string]interface{}{
+		"data":    payment,
+		"message": "Loan payment recorded successfully",
+	})
+}
+
+// DeleteLoanPayment undoes the most recent payment on a loan, removing its
+// transactions and restoring the balance. Earlier payments cannot be removed
+// because later splits were calculated from the balance they left.
+func (h *AccountHandler) DeleteLoanPayment(w http.ResponseWriter, r *http.Request) {
+	account, _, ok := h.authorizedLoan(w, r)
+	if !ok {
+		return
+	}
+
+	paymentID := chi.URLParam(r, "paymentId")
+	var payment models.LoanPayment
+	if err := h.db.First(&payment, "id = ? AND account_id = ?", paymentID, account.ID).Error; err != nil {
+		respondJSON(w, http.StatusNotFound, map[string]string{"error": "loan payment not found"})
+		return
+	}
+
+	var latest models.LoanPayment
+	if err := h.db.Where("account_id = ?", account.ID).Order("date DESC, created_at DESC").First(&latest).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch loan payments"})
+		return
+	}
+	if latest.ID != payment.ID {
+		respondJSON(w, http.StatusConflict, map[string]string{"error": "only the most recent loan payment can be deleted"})
+		return
+	}
+
+	var transactionIDs []uuid.UUID
+	for _, transactionID := range []*uuid.UUID{payment.InterestTransactionID, payment.PrincipalTransactionID} {
+		if transactionID != nil {
+			transactionIDs = append(transactionIDs, *transactionID)
+		}
+	}
+
+	// Files in blob storage are removed through the attachment endpoints
+	if len(transactionIDs) > 0 {
+		var attachments int64
+		if err := h.db.Model(&models.Attachment{}).Where("transaction_id IN ?", transactionIDs).Count(&attachments).Error; err != nil {
+			respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to check attachments"})
+			return
+		}
+		if attachments > 0 {
+			respondJSON(w, http.StatusConflict, map[string]string{"error": "loan payment transactions have attachments; delete them first"})
+			return
+		}
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		for _, transactionID := range transactionIDs {
+			if err := deleteTransaction(tx, transactionID); err != nil {
+				return err
+			}
+		}
+		if err := tx.Delete(&payment).Error; err != nil {
+			return err
+		}
+		return tx.Model(&account).Update("balance", -(absInt(account.Balance) + payment.Principal)).Error
+	})
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to delete loan payment"})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"message": "Loan payment deleted successfully",
+	})
+}
+
+// authorizedLoan loads the {id} account like authorizedAccount and checks that
+// it is a loan
+func (h *AccountHandler) authorizedLoan(w http.ResponseWriter, r *http.Request) (models.Account, models.User, bool) {
+	account, user, ok := h.authorizedAccount(w, r)
+	if !ok {
+		return account, user, false
+	}
+
+	if !isLoan(account.Type) || validateLoanDetails(account) != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "account is not a loan"})
+		return account, user, false
+	}
+
+	return account, user, true
+}
+
+// loanCategory resolves the category a loan payment part is booked to: the
+// requested one if given, otherwise the named system category. A requested
+// category must be one the budget uses for outgoing money, and interest must
+// be spending. It returns a status and message to respond with on failure.
+func (h *AccountHandler) loanCategory(budgetID uuid.UUID, requested, systemName string) (uuid.UUID, int, string) {
+	var category models.Category
+	if requested != "" {
+		categoryID, err := uuid.Parse(requested)
+		if err != nil {
+			return uuid.Nil, http.StatusBadRequest, "invalid category_id"
+		}
+		category, status, msg := budgetCategory(h.db, categoryID, budgetID)
+		if status != 0 {
+			return uuid.Nil, status, msg
+		}
+		hidden, err := hiddenCategories(h.db, budgetID)
+		if err != nil {
+			return uuid.Nil, http.StatusInternalServerError, "failed to fetch hidden categories"
+		}
+		if hidden[category.ID] {
+			return uuid.Nil, http.StatusBadRequest, "category is hidden in this budget"
+		}
+		// Both parts of a payment are money going out
+		if err := validateCategoryAmount(category, models.Transaction{Amount: -1}); err != nil {
+			return uuid.Nil, http.StatusBadRequest, err.Error()
+		}
+		if systemName == loanInterestCategory && category.Kind == categoryKindTransfer {
+			return uuid.Nil, http.StatusBadRequest, "interest must be booked to an expense category"
+		}
+		return category.ID, 0, ""
+	}
+
+	if err := h.db.Where("name = ? AND is_system = ? AND budget_id IS NULL", systemName, true).First(&category).Error; err != nil {
+		return uuid.Nil, http.StatusBadRequest, "system category " + systemName + " not found"
+	}
+	return category.ID, 0, ""
+}
+
+func createLoanTransaction(tx *gorm.DB, account models.Account, userID uuid.UUID, amount int, categoryID uuid.UUID, date time.Time, part string) (models.Transaction, error) {
+	description := account.Name + " " + part
+	transaction := models.Transaction{
+		UserID:       userID,
+		BudgetID:     account.BudgetID,
+		AccountID:    &account.ID,
+		Amount:       amount,
+		Currency:     account.Currency,
+		Description:  description,
+		MerchantName: extractMerchantName(description),
+		CategoryID:   categoryID,
+		Date:         date,
+	}
+	err := tx.Create(&transaction).Error
+	return transaction, err
+}
+
+// applyLoanDefaults fills in the monthly payment from the loan terms and the
+// due day from the start date when they were not given
+func applyLoanDefaults(account *models.Account) {
+	if account.MinimumPayment == nil {
+		payment := monthlyLoanPayment(*account.LoanPrincipal, *account.APR, *account.LoanTermMonths)
+		account.MinimumPayment = &payment
+	}
+	if account.PaymentDueDay == nil {
+		dueDay := account.LoanStartDate.Day()
+		account.PaymentDueDay = &dueDay
+	}
+}
+
+// monthlyLoanPayment is the fixed payment that pays off principal over
+// months at the given APR
+func monthlyLoanPayment(principal int, apr float64, months int) int {
+	if months <= 0 {
+		return principal
+	}
+	rate := apr / 100 / 12
+	if rate == 0 {
+		return int(math.Ceil(float64(principal) / float64(months)))
+	}
+	payment := float64(principal) * rate / (1 - math.Pow(1+rate, -float64(months)))
+	return int(math.Round(payment))
+}
+
+// amortizationSchedule lays out every payment of a loan, starting a month
+// after the start date. The last payment clears whatever rounding left over.
+func amortizationSchedule(account models.Account) []AmortizationPayment {
+	principal, months := *account.LoanPrincipal, *account.LoanTermMonths
+	monthlyPayment := monthlyLoanPayment(principal, *account.APR, months)
+	dueDay := account.LoanStartDate.Day()
+	if account.PaymentDueDay != nil {
+		dueDay = *account.PaymentDueDay
+	}
+
+	schedule := make([]AmortizationPayment, 0, months)
+	balance := principal
+	for number := 1; number <= months && balance > 0; number++ {
+		interest := monthlyInterest(balance, *account.APR)
+		payment := monthlyPayment
+		if number == months || payment > balance+interest {
+			payment = balance + interest
+		}
+		balance -= payment - interest
+		schedule = append(schedule, AmortizationPayment{
+			Number:    number,
+			Date:      dueDate(account.LoanStartDate.AddDate(0, number, 0), dueDay).Format("2006-01-02"),
+			Payment:   payment,
+			Interest:  interest,
+			Principal: payment - interest,
+			Balance:   balance,
+		})
+	}
+	return schedule
+}
+
+// scheduledLoanBalance is what the schedule says is owed on a loan after the
+// payments due on or before date
+func scheduledLoanBalance(account models.Account, date time.Time) int {
+	balance := *account.LoanPrincipal
+	day := date.Format("2006-01-02")
+	for _, payment := range amortizationSchedule(account) {
+		if payment.Date > day {
+			break
+		}
+		balance = payment.Balance
+	}
+	return balance
+}
+
+// splitLoanPayment divides a payment into a month's interest on the amount
+// owed and the principal it pays down
+func splitLoanPayment(owed int, apr float64, amount int) (int, int, error) {
+	interest := monthlyInterest(owed, apr)
+	if amount < interest {
+		return 0, 0, errors.New("amount does not cover the interest due")
+	}
+	if amount > owed+interest {
+		return 0, 0, errors.New("amount is more than the loan payoff amount")
+	}
+	return interest, amount - interest, nil
+}
+
+func monthlyInterest(balance int, apr float64) int {
+	return int(math.Round(float64(balance) * apr / 100 / 12))
+}
+
+func absInt(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/yourusername/folda-finances/internal/models"
+)
+
+func TestAmortizationSchedule(t *testing.T) {
+	principal, term := 1000000, 12
+	apr := 6.0
+	start := testDate("2025-01-20")
+	account := models.Account{Type: "loan", APR: &apr, LoanPrincipal: &principal, LoanTermMonths: &term, LoanStartDate: &start}
+
+	// $10,000 over a year at 6% is $860.66 a month
+	if payment := monthlyLoanPayment(principal, apr, term); payment != 86066 {
+		t.Errorf("Expected monthly payment 86066, got %d", payment)
+	}
+
+	schedule := amortizationSchedule(account)
+	if len(schedule) != 12 {
+		t.Fatalf("Expected 12 payments, got %d", len(schedule))
+	}
+	if schedule[0].Date != "2025-02-20" || schedule[0].Interest != 5000 || schedule[0].Principal != 81066 {
+		t.Errorf("Unexpected first payment: %+v", schedule[0])
+	}
+
+	paidDown := 0
+	for _, payment := range schedule {
+		paidDown += payment.Principal
+	}
+	if paidDown != principal || schedule[11].Balance != 0 {
+		t.Errorf("Expected the schedule to repay %d exactly, repaid %d leaving %d", principal, paidDown, schedule[11].Balance)
+	}
+
+	if balance := scheduledLoanBalance(account, testDate("2025-03-19")); balance != schedule[0].Balance {
+		t.Errorf("Expected balance after one payment %d, got %d", schedule[0].Balance, balance)
+	}
+}
+
+func TestCreateLoanPayment(t *testing.T) {
+	db := setupTestDB(t)
+	handler := NewAccountHandler(db)
+
+	user, budget := createTestUser(t, db, "test@example.com")
+	for _, name := range []string{loanInterestCategory, loanPrincipalCategory} {
+		db.Create(&models.Category{ID: uuid.New(), Name: name, Color: "#DC2626", Icon: "💳", IsSystem: true})
+	}
+
+	principal, term := 20000000, 360
+	apr := 6.0
+	start := testDate("2025-01-01")
+	account := &models.Account{
+		ID:             uuid.New(),
+		BudgetID:       budget.ID,
+		Name:           "Mortgage",
+		Type:           "mortgage",
+		Balance:        -20000000,
+		APR:            &apr,
+		LoanPrincipal:  &principal,
+		LoanTermMonths: &term,
+		LoanStartDate:  &start,
+	}
+	db.Create(account)
+
+	body, _ := json.Marshal(CreateLoanPaymentRequest{Amount: 150000, Date: "2025-02-01"})
+	req := httptest.NewRequest("POST", "/accounts/"+account.ID.String()+"/loan-payments", bytes.NewBuffer(body))
+	req = req.WithContext(setUserIDContext(req, user.ID))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", account.ID.String())
+	req = req.WithContext(setRouteContext(req, rctx))
+	w := httptest.NewRecorder()
+
+	handler.CreateLoanPayment(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// A month's interest on $200,000 at 6% is $1,000, the other $500 is principal
+	var transactions []models.Transaction
+	db.Where("account_id = ?", account.ID).Order("amount ASC").Find(&transactions)
+	if len(transactions) != 2 {
+		t.Fatalf("Expected interest and principal transactions, got %d", len(transactions))
+	}
+	if transactions[0].Amount != -100000 || transactions[1].Amount != -50000 {
+		t.Errorf("Expected -100000 interest and -50000 principal, got %d and %d", transactions[0].Amount, transactions[1].Amount)
+	}
+
+	var interestCategory models.Category
+	db.First(&interestCategory, "id = ?", transactions[0].CategoryID)
+	if interestCategory.Name != loanInterestCategory {
+		t.Errorf("Expected interest booked to %s, got %s", loanInterestCategory, interestCategory.Name)
+	}
+
+	var updated models.Account
+	db.First(&updated, "id = ?", account.ID)
+	if updated.Balance != -19950000 {
+		t.Errorf("Expected balance -19950000, got %d", updated.Balance)
+	}
+
+	// Interest has to land in a visible expense category
+	salary := createTestCategory(t, db, budget.ID, "Salary")
+	db.Model(salary).Update("kind", categoryKindIncome)
+	transfers := createTestCategory(t, db, budget.ID, "Transfers")
+	db.Model(transfers).Update("kind", categoryKindTransfer)
+	var fees models.Category
+	db.First(&fees, "name = ?", loanInterestCategory)
+	db.Create(&models.HiddenCategory{BudgetID: budget.ID, CategoryID: fees.ID})
+
+	for name, request := range map[string]CreateLoanPaymentRequest{
+		"income category":     {Amount: 150000, Date: "2025-03-01", InterestCategoryID: salary.ID.String()},
+		"transfer interest":   {Amount: 150000, Date: "2025-03-01", InterestCategoryID: transfers.ID.String()},
+		"hidden category":     {Amount: 150000, Date: "2025-03-01", InterestCategoryID: fees.ID.String()},
+		"unknown category":    {Amount: 150000, Date: "2025-03-01", PrincipalCategoryID: uuid.New().String()},
+		"income as principal": {Amount: 150000, Date: "2025-03-01", PrincipalCategoryID: salary.ID.String()},
+	} {
+		w := httptest.NewRecorder()
+		handler.CreateLoanPayment(w, testRequest("POST", "/accounts/"+account.ID.String()+"/loan-payments", request, user.ID, map[string]string{"id": account.ID.String()}))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", name, w.Code)
+		}
+	}
+
+	// A payment short of the interest is rejected
+	body, _ = json.Marshal(CreateLoanPaymentRequest{Amount: 50000, Date: "2025-03-01"})
+	req = httptest.NewRequest("POST", "/accounts/"+account.ID.String()+"/loan-payments", bytes.NewBuffer(body))
+	req = req.WithContext(setUserIDContext(req, user.ID))
+	req = req.WithContext(setRouteContext(req, rctx))
+	w = httptest.NewRecorder()
+
+	handler.CreateLoanPayment(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+func TestDeleteLoanPayment(t *testing.T) {
+	db := setupTestDB(t)
+	handler := NewAccountHandler(db)
+	transactionHandler := NewTransactionHandler(db)
+
+	user, budget := createTestUser(t, db, "test@example.com")
+	for _, name := range []string{loanInterestCategory, loanPrincipalCategory} {
+		db.Create(&models.Category{ID: uuid.New(), Name: name, Color: "#DC2626", Icon: "💳", IsSystem: true})
+	}
+
+	principal, term := 20000000, 360
+	apr := 6.0
+	start := testDate("2025-01-01")
+	account := &models.Account{
+		ID:             uuid.New(),
+		BudgetID:       budget.ID,
+		Name:           "Mortgage",
+		Type:           "mortgage",
+		Balance:        -20000000,
+		APR:            &apr,
+		LoanPrincipal:  &principal,
+		LoanTermMonths: &term,
+		LoanStartDate:  &start,
+	}
+	db.Create(account)
+
+	w := httptest.NewRecorder()
+	handler.CreateLoanPayment(w, testRequest("POST", "/accounts/"+account.ID.String()+"/loan-payments", CreateLoanPaymentRequest{Amount: 150000, Date: "2025-02-01"}, user.ID, map[string]string{"id": account.ID.String()}))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var payment models.LoanPayment
+	db.First(&payment, "account_id = ?", account.ID)
+	tag := models.Tag{ID: uuid.New(), BudgetID: budget.ID, Name: "house"}
+	db.Create(&tag)
+	db.Create(&models.TransactionTag{TransactionID: *payment.InterestTransactionID, TagID: tag.ID})
+
+	// The payment's transactions can only change with the payment
+	amount := -90000
+	w = httptest.NewRecorder()
+	transactionHandler.UpdateTransaction(w, testRequest("PUT", "/transactions/"+payment.InterestTransactionID.String(), UpdateTransactionRequest{Amount: &amount}, user.ID, map[string]string{"id": payment.InterestTransactionID.String()}))
+	if w.Code != http.StatusConflict {
+		t.Fatalf("Expected status 409 updating, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	transactionHandler.DeleteTransaction(w, testRequest("DELETE", "/transactions/"+payment.InterestTransactionID.String(), nil, user.ID, map[string]string{"id": payment.InterestTransactionID.String()}))
+	if w.Code != http.StatusConflict {
+		t.Fatalf("Expected status 409, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req := httptest.NewRequest("DELETE", "/accounts/"+account.ID.String()+"/loan-payments/"+payment.ID.String(), nil)
+	req = req.WithContext(setUserIDContext(req, user.ID))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", account.ID.String())
+	rctx.URLParams.Add("paymentId", payment.ID.String())
+	req = req.WithContext(setRouteContext(req, rctx))
+	w = httptest.NewRecorder()
+
+	handler.DeleteLoanPayment(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var transactions, tags int64
+	db.Model(&models.Transaction{}).Where("account_id = ?", account.ID).Count(&transactions)
+	db.Model(&models.TransactionTag{}).Count(&tags)
+	if transactions != 0 || tags != 0 {
+		t.Errorf("Expected the payment's transactions and their tags deleted, got %d and %d", transactions, tags)
+	}
+
+	var updated models.Account
+	db.First(&updated, "id = ?", account.ID)
+	if updated.Balance != -20000000 {
+		t.Errorf("Expected balance restored to -20000000, got %d", updated.Balance)
+	}
+}
@@ -0,0 +1,398 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/yourusername/folda-finances/internal/middleware"
+	"github.com/yourusername/folda-finances/internal/models"
+	"gorm.io/gorm"
+)
+
+// freeBudgetMaxMembers caps the members of a budget whose owner is not
+// premium; premium owners get the budget's MaxMembers
+const freeBudgetMaxMembers = 2
+
+type MemberHandler struct {
+	db *gorm.DB
+}
+
+func NewMemberHandler(db *gorm.DB) *MemberHandler {
+	return &MemberHandler{db: db}
+}
+
+type UpdateBudgetMemberRequest struct {
+	Role string `json:"role"`
+}
+
+type TransferOwnershipRequest struct {
+	UserID string `json:"user_id"`
+}
+
+// UpdateBudgetMember changes a member's role. Only the owner can, and the
+// owner's own role only changes by transferring ownership.
+func (h *MemberHandler) UpdateBudgetMember(w http.ResponseWriter, r *http.Request) {
+	userID, budgetID, ok := budgetPathIDs(w, r)
+	if !ok {
+		return
+	}
+	if !requireBudgetOwner(h.db, w, budgetID, userID, "change member roles") {
+		return
+	}
+
+	var req UpdateBudgetMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+	if req.Role != models.BudgetRoleReadWrite && req.Role != models.BudgetRoleReadOnly {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "role must be read_write or read_only"})
+		return
+	}
+
+	member, ok := h.pathMember(w, r, budgetID)
+	if !ok {
+		return
+	}
+	if member.Role == models.BudgetRoleOwner {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "transfer ownership to change the owner's role"})
+		return
+	}
+
+	if err := h.db.Model(&models.BudgetMember{}).Where("budget_id = ? AND user_id = ?", member.BudgetID, member.UserID).Update("role", req.Role).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to update member"})
+		return
+	}
+	member.Role = req.Role
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data":    member,
+		"message": "Member updated successfully",
+	})
+}
+
+// RemoveBudgetMember removes another member from the budget. Only the owner
+// can; members remove themselves with LeaveBudget.
+func (h *MemberHandler) RemoveBudgetMember(w http.ResponseWriter, r *http.Request) {
+	userID, budgetID, ok := budgetPathIDs(w, r)
+	if !ok {
+		return
+	}
+	if !requireBudgetOwner(h.db, w, budgetID, userID, "remove members") {
+		return
+	}
+
+	member, ok := h.pathMember(w, r, budgetID)
+	if !ok {
+		return
+	}
+	if member.UserID == userID {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "the owner cannot remove themselves"})
+		return
+	}
+
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		return removeBudgetMember(tx, member, userID)
+	}); err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to remove member"})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Member removed successfully"})
+}
+
+// LeaveBudget removes the user from the budget. The owner has to transfer
+// ownership first so the budget is never left without one.
+func (h *MemberHandler) LeaveBudget(w http.ResponseWriter, r *http.Request) {
+	userID, budgetID, ok := budgetPathIDs(w, r)
+	if !ok {
+		return
+	}
+
+	member, err := budgetMember(h.db, budgetID, userID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			respondJSON(w, http.StatusNotFound, map[string]string{"error": "you are not a member of this budget"})
+			return
+		}
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch budget membership"})
+		return
+	}
+	if member.Role == models.BudgetRoleOwner {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "transfer ownership before leaving the budget"})
+		return
+	}
+
+	var budget models.Budget
+	if err := h.db.First(&budget, "id = ?", budgetID).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch budget"})
+		return
+	}
+
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		return removeBudgetMember(tx, member, budget.CreatedBy)
+	}); err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to leave budget"})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Left budget successfully"})
+}
+
+// TransferOwnership makes another member the owner. The previous owner stays
+// on as a read_write member.
+func (h *MemberHandler) TransferOwnership(w http.ResponseWriter, r *http.Request) {
+	userID, budgetID, ok := budgetPathIDs(w, r)
+	if !ok {
+		return
+	}
+	if !requireBudgetOwner(h.db, w, budgetID, userID, "transfer ownership") {
+		return
+	}
+
+	var req TransferOwnershipRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+	newOwnerID, err := uuid.Parse(req.UserID)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid user_id"})
+		return
+	}
+	if newOwnerID == userID {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "you already own this budget"})
+		return
+	}
+	if _, err := budgetMember(h.db, budgetID, newOwnerID); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			respondJSON(w, http.StatusNotFound, map[string]string{"error": "user is not a member of this budget"})
+			return
+		}
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch budget membership"})
+		return
+	}
+
+	// Budget.CreatedBy follows the owner, it is what the owner is looked up by
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.BudgetMember{}).Where("budget_id = ? AND user_id = ?", budgetID, userID).Update("role", models.BudgetRoleReadWrite).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.BudgetMember{}).Where("budget_id = ? AND user_id = ?", budgetID, newOwnerID).Update("role", models.BudgetRoleOwner).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Budget{}).Where("id = ?", budgetID).Update("created_by", newOwnerID).Error
+	})
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to transfer ownership"})
+		return
+	}
+
+	members, err := budgetMembers(h.db, budgetID)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch members"})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data":    members,
+		"message": "Ownership transferred successfully",
+	})
+}
+
+// budgetPathIDs returns the requesting user and the {budgetId} budget,
+// writing the error response when either is missing
+func budgetPathIDs(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	userID, err := middleware.GetUserID(r)
+	if err != nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	budgetID, err := uuid.Parse(chi.URLParam(r, "budgetId"))
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid budget_id"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	return userID, budgetID, true
+}
+
+// pathMember loads the {userId} member of the budget, writing the error
+// response when they are not one
+func (h *MemberHandler) pathMember(w http.ResponseWriter, r *http.Request, budgetID uuid.UUID) (models.BudgetMember, bool) {
+	memberID, err := uuid.Parse(chi.URLParam(r, "userId"))
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid user_id"})
+		return models.BudgetMember{}, false
+	}
+	member, err := budgetMember(h.db, budgetID, memberID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			respondJSON(w, http.StatusNotFound, map[string]string{"error": "member not found"})
+			return member, false
+		}
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch budget membership"})
+		return member, false
+	}
+	return member, true
+}
+
+// removeBudgetMember takes a member out of a budget. Their transactions and
+// other history stay in the budget under their name, editable from then on by
+// any member with write access (see transactionEditable), while their split
+// allocations pass to the owner so category budgets stay fully allocated. If
+// it was their default budget they fall back to another they belong to.
+func removeBudgetMember(tx *gorm.DB, member models.BudgetMember, ownerID uuid.UUID) error {
+	if err := tx.Where("budget_id = ? AND user_id = ?", member.BudgetID, member.UserID).Delete(&models.BudgetMember{}).Error; err != nil {
+		return err
+	}
+
+	var splits []models.CategoryBudgetSplit
+	if err := tx.Where("user_id = ? AND category_budget_id IN (?)", member.UserID,
+		tx.Model(&models.CategoryBudget{}).Select("id").Where("budget_id = ?", member.BudgetID)).Find(&splits).Error; err != nil {
+		return err
+	}
+	for _, split := range splits {
+		var ownerSplit models.CategoryBudgetSplit
+		err := tx.Where("category_budget_id = ? AND user_id = ?", split.CategoryBudgetID, ownerID).First(&ownerSplit).Error
+		if err == gorm.ErrRecordNotFound {
+			if err := tx.Model(&split).Update("user_id", ownerID).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if split.AllocationPercentage != nil {
+			percentage := *split.AllocationPercentage
+			if ownerSplit.AllocationPercentage != nil {
+				percentage += *ownerSplit.AllocationPercentage
+			}
+			ownerSplit.AllocationPercentage = &percentage
+		}
+		if split.AllocationAmount != nil {
+			amount := *split.AllocationAmount
+			if ownerSplit.AllocationAmount != nil {
+				amount += *ownerSplit.AllocationAmount
+			}
+			ownerSplit.AllocationAmount = &amount
+		}
+		if err := tx.Save(&ownerSplit).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&split).Error; err != nil {
+			return err
+		}
+	}
+
+	var user models.User
+	if err := tx.First(&user, "id = ?", member.UserID).Error; err != nil {
+		return err
+	}
+	if user.BudgetID == nil || *user.BudgetID != member.BudgetID {
+		return nil
+	}
+	next, err := nextDefaultBudget(tx, member.UserID, member.BudgetID)
+	if err != nil {
+		return err
+	}
+	return tx.Model(&user).Update("budget_id", next).Error
+}
+
+// nextDefaultBudget picks the active budget the user joined earliest other
+// than budgetID, or nil when they belong to no other
+func nextDefaultBudget(tx *gorm.DB, userID, budgetID uuid.UUID) (*uuid.UUID, error) {
+	var next models.BudgetMember
+	err := tx.Where("user_id = ? AND budget_id <> ? AND budget_id IN (?)", userID, budgetID,
+		tx.Model(&models.Budget{}).Select("id").Where("is_active = ?", true)).Order("created_at ASC").First(&next).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &next.BudgetID, nil
+}
+
+// requireBudgetOwner checks the user owns the budget before they take an
+// owner-only action, writing the error response when they do not
+func requireBudgetOwner(db *gorm.DB, w http.ResponseWriter, budgetID, userID uuid.UUID, action string) bool {
+	member, err := budgetMember(db, budgetID, userID)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch budget membership"})
+		return false
+	}
+	if err == gorm.ErrRecordNotFound || member.Role != models.BudgetRoleOwner {
+		respondJSON(w, http.StatusForbidden, map[string]string{"error": "only the budget owner can " + action})
+		return false
+	}
+	return true
+}
+
+// budgetMemberLimit returns how many members the budget allows under its
+// owner's plan, and whether the owner is premium
+func budgetMemberLimit(db *gorm.DB, budget models.Budget, now time.Time) (int, bool, error) {
+	var owner models.User
+	if err := db.First(&owner, "id = ?", budget.CreatedBy).Error; err != nil {
+		return 0, false, err
+	}
+	premium := owner.IsPremium && (owner.PremiumExpiresAt == nil || owner.PremiumExpiresAt.After(now))
+	if premium {
+		return budget.MaxMembers, true, nil
+	}
+	return freeBudgetMaxMembers, false, nil
+}
+
+// memberLimitError explains that a budget is full, pointing free owners at
+// premium
+func memberLimitError(limit int, premium bool) string {
+	if premium {
+		return fmt.Sprintf("this budget has reached its limit of %d members", limit)
+	}
+	return fmt.Sprintf("budgets on the free plan are limited to %d members, the owner can upgrade to premium for more", limit)
+}
+
+// budgetMembers lists a budget's members with their role, earliest first
+func budgetMembers(db *gorm.DB, budgetID uuid.UUID) ([]BudgetMemberResponse, error) {
+	var memberships []models.BudgetMember
+	if err := db.Where("budget_id = ?", budgetID).Order("created_at ASC").Find(&memberships).Error; err != nil {
+		return nil, err
+	}
+
+	members := make([]BudgetMemberResponse, 0, len(memberships))
+	if len(memberships) == 0 {
+		return members, nil
+	}
+	userIDs := make([]uuid.UUID, len(memberships))
+	for i, membership := range memberships {
+		userIDs[i] = membership.UserID
+	}
+	var users []models.User
+	if err := db.Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]models.User, len(users))
+	for _, user := range users {
+		byID[user.ID] = user
+	}
+
+	for _, membership := range memberships {
+		user, ok := byID[membership.UserID]
+		if !ok {
+			continue
+		}
+		members = append(members, BudgetMemberResponse{
+			UserID:     user.ID.String(),
+			Name:       user.Name,
+			Email:      user.Email,
+			BudgetRole: membership.Role,
+			JoinedAt:   membership.CreatedAt,
+		})
+	}
+	return members, nil
+}
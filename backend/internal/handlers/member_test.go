@@ -0,0 +1,607 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/yourusername/folda-finances/internal/database"
+	"github.com/yourusername/folda-finances/internal/middleware"
+	"github.com/yourusername/folda-finances/internal/models"
+	"gorm.io/gorm"
+)
+
+func TestSelectBudget(t *testing.T) {
+	db := setupTestDB(t)
+	handler := NewTagHandler(db)
+
+	user, home := createTestUser(t, db, "test@example.com")
+	_, shared := createTestUser(t, db, "partner@example.com")
+	_, other := createTestUser(t, db, "other@example.com")
+	db.Create(&models.BudgetMember{BudgetID: shared.ID, UserID: user.ID, Role: models.BudgetRoleReadWrite})
+	db.Create(&models.Tag{ID: uuid.New(), BudgetID: home.ID, Name: "home"})
+	db.Create(&models.Tag{ID: uuid.New(), BudgetID: shared.ID, Name: "shared"})
+
+	router := chi.NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(setUserIDContext(r, user.ID)))
+		})
+	})
+	budgetRoutes := func(r chi.Router) {
+		r.Use(middleware.NewBudgetMiddleware(db).SelectBudget)
+		r.Get("/tags", handler.ListTags)
+	}
+	router.Group(budgetRoutes)
+	router.Route("/budgets/{budgetId}", budgetRoutes)
+
+	tags := func(target, header string) (int, []string) {
+		req := httptest.NewRequest("GET", target, nil)
+		if header != "" {
+			req.Header.Set(middleware.BudgetHeader, header)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var response struct {
+			Data []TagListItem `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+		var names []string
+		for _, tag := range response.Data {
+			names = append(names, tag.Name)
+		}
+		return w.Code, names
+	}
+
+	tests := []struct {
+		name     string
+		target   string
+		header   string
+		expected int
+		tag      string
+	}{
+		{"default budget", "/tags", "", http.StatusOK, "home"},
+		{"header", "/tags", shared.ID.String(), http.StatusOK, "shared"},
+		{"path", "/budgets/" + shared.ID.String() + "/tags", "", http.StatusOK, "shared"},
+		{"path wins over header", "/budgets/" + home.ID.String() + "/tags", shared.ID.String(), http.StatusOK, "home"},
+		{"not a member", "/tags", other.ID.String(), http.StatusForbidden, ""},
+		{"invalid id", "/budgets/nope/tags", "", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, names := tags(tt.target, tt.header)
+			if code != tt.expected {
+				t.Fatalf("Expected status %d, got %d", tt.expected, code)
+			}
+			if tt.tag != "" && (len(names) != 1 || names[0] != tt.tag) {
+				t.Errorf("Expected the %s tag, got %v", tt.tag, names)
+			}
+		})
+	}
+
+	// The default budget is held to the same checks as a selected one
+	db.Model(home).Update("is_active", false)
+	if code, _ := tags("/tags", ""); code != http.StatusGone {
+		t.Errorf("Expected status 410 for a deleted default budget, got %d", code)
+	}
+	db.Model(home).Update("is_active", true)
+	db.Where("budget_id = ? AND user_id = ?", home.ID, user.ID).Delete(&models.BudgetMember{})
+	if code, _ := tags("/tags", ""); code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for a default budget without a membership, got %d", code)
+	}
+}
+
+func TestAcceptInvitationKeepsOwnBudget(t *testing.T) {
+	db := setupTestDB(t)
+	invitationHandler := NewInvitationHandler(db)
+	budgetHandler := NewBudgetHandler(db)
+
+	owner, shared := createTestUser(t, db, "owner@example.com")
+	invitee, home := createTestUser(t, db, "invitee@example.com")
+
+	data, _ := json.Marshal(CreateBudgetInvitationRequest{Email: invitee.Email, Role: models.BudgetRoleReadOnly})
+	req := httptest.NewRequest("POST", "/budgets/"+shared.ID.String()+"/invite", bytes.NewBuffer(data))
+	req = req.WithContext(setUserIDContext(req, owner.ID))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("budgetId", shared.ID.String())
+	req = req.WithContext(setRouteContext(req, rctx))
+	w := httptest.NewRecorder()
+	invitationHandler.InviteToBudget(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var invitation struct {
+		Data models.BudgetInvitation `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&invitation)
+
+	accept := func() int {
+		req := httptest.NewRequest("POST", "/budget-invitations/"+invitation.Data.Token+"/accept", nil)
+		ctx := context.WithValue(setUserIDContext(req, invitee.ID), middleware.ClaimsKey, jwt.MapClaims{"email": invitee.Email})
+		req = req.WithContext(ctx)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("token", invitation.Data.Token)
+		req = req.WithContext(setRouteContext(req, rctx))
+		w := httptest.NewRecorder()
+		invitationHandler.AcceptBudgetInvitation(w, req)
+		return w.Code
+	}
+	if code := accept(); code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+
+	// The invitee keeps their own budget as the default and gains the shared one
+	var reloaded models.User
+	db.First(&reloaded, "id = ?", invitee.ID)
+	if reloaded.BudgetID == nil || *reloaded.BudgetID != home.ID {
+		t.Errorf("Expected the invitee's default budget kept, got %v", reloaded.BudgetID)
+	}
+
+	req = httptest.NewRequest("GET", "/budgets", nil)
+	req = req.WithContext(setUserIDContext(req, invitee.ID))
+	w = httptest.NewRecorder()
+	budgetHandler.ListBudgets(w, req)
+	var budgets struct {
+		Data []UserBudget `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&budgets)
+	if len(budgets.Data) != 2 {
+		t.Fatalf("Expected two budgets, got %+v", budgets.Data)
+	}
+	roles := map[uuid.UUID]UserBudget{}
+	for _, budget := range budgets.Data {
+		roles[budget.ID] = budget
+	}
+	if !roles[home.ID].IsDefault || roles[home.ID].Role != models.BudgetRoleOwner || roles[shared.ID].Role != models.BudgetRoleReadOnly {
+		t.Errorf("Expected owner of the default budget and read-only in the shared one, got %+v", budgets.Data)
+	}
+
+	// The shared budget lists both members with their roles
+	req = httptest.NewRequest("GET", "/budget/members", nil)
+	req = req.WithContext(setUserIDContext(req, owner.ID))
+	w = httptest.NewRecorder()
+	budgetHandler.GetBudgetMembers(w, req)
+	var members struct {
+		Data []BudgetMemberResponse `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&members)
+	if len(members.Data) != 2 || members.Data[1].Email != invitee.Email || members.Data[1].BudgetRole != models.BudgetRoleReadOnly {
+		t.Errorf("Expected the invitee listed as read-only, got %+v", members.Data)
+	}
+}
+
+func TestCreateBudgetAndSwitchDefault(t *testing.T) {
+	db := setupTestDB(t)
+	budgetHandler := NewBudgetHandler(db)
+	userHandler := NewUserHandler(db)
+
+	user, _ := createTestUser(t, db, "test@example.com")
+	_, other := createTestUser(t, db, "other@example.com")
+
+	data, _ := json.Marshal(CreateBudgetRequest{Name: "Holiday House", BaseCurrency: "eur"})
+	req := httptest.NewRequest("POST", "/budgets", bytes.NewBuffer(data))
+	req = req.WithContext(setUserIDContext(req, user.ID))
+	w := httptest.NewRecorder()
+	budgetHandler.CreateBudget(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		Data UserBudget `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&created)
+	if created.Data.Role != models.BudgetRoleOwner || created.Data.IsDefault || created.Data.BaseCurrency != "EUR" {
+		t.Errorf("Expected an owned EUR budget alongside the default, got %+v", created.Data)
+	}
+
+	update := func(budgetID uuid.UUID) int {
+		value := budgetID.String()
+		data, _ := json.Marshal(UpdateUserRequest{BudgetID: &value})
+		req := httptest.NewRequest("PATCH", "/auth/me", bytes.NewBuffer(data))
+		req = req.WithContext(setUserIDContext(req, user.ID))
+		w := httptest.NewRecorder()
+		userHandler.UpdateUser(w, req)
+		return w.Code
+	}
+	if code := update(other.ID); code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 defaulting to another user's budget, got %d", code)
+	}
+	if code := update(created.Data.ID); code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+
+	// Requests without a selection now act on the new budget
+	req = httptest.NewRequest("GET", "/budget/members", nil)
+	req = req.WithContext(setUserIDContext(req, user.ID))
+	reloaded, _ := requestUser(db, req, user.ID)
+	if *reloaded.BudgetID != created.Data.ID || reloaded.BudgetRole != models.BudgetRoleOwner {
+		t.Errorf("Expected the new budget as default, got %v", reloaded.BudgetID)
+	}
+}
+
+func TestBudgetRoles(t *testing.T) {
+	db := setupTestDB(t)
+	tagHandler := NewTagHandler(db)
+	transactionHandler := NewTransactionHandler(db)
+	invitationHandler := NewInvitationHandler(db)
+
+	owner, budget := createTestUser(t, db, "owner@example.com")
+	writer, _ := createTestUser(t, db, "writer@example.com")
+	reader, _ := createTestUser(t, db, "reader@example.com")
+	db.Create(&models.BudgetMember{BudgetID: budget.ID, UserID: writer.ID, Role: models.BudgetRoleReadWrite})
+	db.Create(&models.BudgetMember{BudgetID: budget.ID, UserID: reader.ID, Role: models.BudgetRoleReadOnly})
+	db.Model(owner).Update("is_premium", true)
+
+	router := chi.NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, _ := uuid.Parse(r.Header.Get("X-Test-User"))
+			next.ServeHTTP(w, r.WithContext(setUserIDContext(r, userID)))
+		})
+	})
+	budgetRoutes := func(r chi.Router) {
+		r.Use(middleware.NewBudgetMiddleware(db).SelectBudget)
+		r.Use(middleware.RequireWriteAccess)
+		r.Get("/tags", tagHandler.ListTags)
+		r.Post("/tags", tagHandler.CreateTag)
+		r.Put("/transactions/{id}", transactionHandler.UpdateTransaction)
+		r.Delete("/transactions/{id}", transactionHandler.DeleteTransaction)
+	}
+	router.Group(budgetRoutes)
+	router.Route("/budgets/{budgetId}", func(r chi.Router) {
+		budgetRoutes(r)
+		r.Post("/invite", invitationHandler.InviteToBudget)
+	})
+
+	send := func(user *models.User, method, target, header string, body interface{}) int {
+		data, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, target, bytes.NewBuffer(data))
+		req.Header.Set("X-Test-User", user.ID.String())
+		if header != "" {
+			req.Header.Set(middleware.BudgetHeader, header)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	tests := []struct {
+		role   string
+		user   *models.User
+		list   int
+		create int
+		invite int
+	}{
+		{models.BudgetRoleOwner, owner, http.StatusOK, http.StatusCreated, http.StatusCreated},
+		{models.BudgetRoleReadWrite, writer, http.StatusOK, http.StatusCreated, http.StatusForbidden},
+		{models.BudgetRoleReadOnly, reader, http.StatusOK, http.StatusForbidden, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.role, func(t *testing.T) {
+			if code := send(tt.user, "GET", "/tags", budget.ID.String(), nil); code != tt.list {
+				t.Errorf("Expected status %d listing tags, got %d", tt.list, code)
+			}
+			tag := CreateTagRequest{Name: tt.role, Color: "#000000"}
+			if code := send(tt.user, "POST", "/tags", budget.ID.String(), tag); code != tt.create {
+				t.Errorf("Expected status %d creating a tag, got %d", tt.create, code)
+			}
+			invite := CreateBudgetInvitationRequest{Email: "invitee-" + tt.role + "@example.com"}
+			if code := send(tt.user, "POST", "/budgets/"+budget.ID.String()+"/invite", "", invite); code != tt.invite {
+				t.Errorf("Expected status %d inviting, got %d", tt.invite, code)
+			}
+		})
+	}
+
+	// A read-only member's own transaction in the shared budget cannot be
+	// changed from their default budget, where they are owner
+	category := createTestCategory(t, db, budget.ID, "Groceries")
+	transaction := models.Transaction{ID: uuid.New(), UserID: reader.ID, BudgetID: budget.ID, CategoryID: category.ID, Amount: -1500, Date: time.Now()}
+	db.Create(&transaction)
+	target := "/transactions/" + transaction.ID.String()
+	for _, header := range []string{"", budget.ID.String()} {
+		if code := send(reader, "PUT", target, header, UpdateTransactionRequest{Amount: intPtr(-100)}); code != http.StatusForbidden {
+			t.Errorf("Expected status 403 updating with budget %q selected, got %d", header, code)
+		}
+		if code := send(reader, "DELETE", target, header, nil); code != http.StatusForbidden {
+			t.Errorf("Expected status 403 deleting with budget %q selected, got %d", header, code)
+		}
+	}
+	db.First(&transaction, "id = ?", transaction.ID)
+	if transaction.Amount != -1500 {
+		t.Errorf("Expected the transaction unchanged, got %d", transaction.Amount)
+	}
+
+	// The role applies to the default budget too
+	db.Model(reader).Update("budget_id", budget.ID)
+	if code := send(reader, "POST", "/tags", "", CreateTagRequest{Name: "default", Color: "#000000"}); code != http.StatusForbidden {
+		t.Errorf("Expected status 403 writing to a read-only default budget, got %d", code)
+	}
+}
+
+func TestRemoveAndLeaveBudget(t *testing.T) {
+	db := setupTestDB(t)
+	handler := NewMemberHandler(db)
+
+	owner, budget := createTestUser(t, db, "owner@example.com")
+	writer, home := createTestUser(t, db, "writer@example.com")
+	reader, _ := createTestUser(t, db, "reader@example.com")
+	db.Create(&models.BudgetMember{BudgetID: budget.ID, UserID: writer.ID, Role: models.BudgetRoleReadWrite})
+	db.Create(&models.BudgetMember{BudgetID: budget.ID, UserID: reader.ID, Role: models.BudgetRoleReadOnly})
+	db.Model(reader).Update("budget_id", budget.ID)
+
+	category := createTestCategory(t, db, budget.ID, "Groceries")
+	pooled := models.CategoryBudget{ID: uuid.New(), BudgetID: budget.ID, CategoryID: category.ID, Amount: 60000, AllocationType: "split"}
+	db.Create(&pooled)
+	share := func(percentage float64) *float64 { return &percentage }
+	db.Create(&models.CategoryBudgetSplit{ID: uuid.New(), CategoryBudgetID: pooled.ID, UserID: owner.ID, AllocationPercentage: share(50)})
+	db.Create(&models.CategoryBudgetSplit{ID: uuid.New(), CategoryBudgetID: pooled.ID, UserID: writer.ID, AllocationPercentage: share(30)})
+	db.Create(&models.CategoryBudgetSplit{ID: uuid.New(), CategoryBudgetID: pooled.ID, UserID: reader.ID, AllocationPercentage: share(20)})
+	transaction := models.Transaction{ID: uuid.New(), UserID: writer.ID, BudgetID: budget.ID, Amount: -2500, CategoryID: category.ID, Date: time.Now()}
+	db.Create(&transaction)
+
+	remove := func(userID uuid.UUID, memberID uuid.UUID) int {
+		w := httptest.NewRecorder()
+		handler.RemoveBudgetMember(w, testRequest("DELETE", "/members", nil, userID, map[string]string{"budgetId": budget.ID.String(), "userId": memberID.String()}))
+		return w.Code
+	}
+	leave := func(userID uuid.UUID) int {
+		w := httptest.NewRecorder()
+		handler.LeaveBudget(w, testRequest("POST", "/leave", nil, userID, map[string]string{"budgetId": budget.ID.String()}))
+		return w.Code
+	}
+
+	if code := remove(writer.ID, reader.ID); code != http.StatusForbidden {
+		t.Errorf("Expected status 403 removing as a read_write member, got %d", code)
+	}
+	if code := remove(owner.ID, owner.ID); code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 removing the owner, got %d", code)
+	}
+	if code := leave(owner.ID); code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 leaving as the owner, got %d", code)
+	}
+
+	// The removed member's split joins the owner's and their transactions stay
+	if code := remove(owner.ID, writer.ID); code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+	if _, err := budgetMember(db, budget.ID, writer.ID); err != gorm.ErrRecordNotFound {
+		t.Errorf("Expected the membership removed, got %v", err)
+	}
+	var splits []models.CategoryBudgetSplit
+	db.Where("category_budget_id = ?", pooled.ID).Order("allocation_percentage DESC").Find(&splits)
+	if len(splits) != 2 || splits[0].UserID != owner.ID || *splits[0].AllocationPercentage != 80 {
+		t.Errorf("Expected the owner's split raised to 80%%, got %+v", splits)
+	}
+	var count int64
+	db.Model(&models.Transaction{}).Where("id = ?", transaction.ID).Count(&count)
+	if count != 1 {
+		t.Error("Expected the removed member's transaction kept")
+	}
+	if code := remove(owner.ID, writer.ID); code != http.StatusNotFound {
+		t.Errorf("Expected status 404 removing a former member, got %d", code)
+	}
+
+	// Members left behind look after the removed member's transactions, while
+	// the removed member has lost access to them
+	transactionHandler := NewTransactionHandler(db)
+	updateTransaction := func(userID uuid.UUID, amount int) int {
+		w := httptest.NewRecorder()
+		transactionHandler.UpdateTransaction(w, testRequest("PUT", "/transactions", UpdateTransactionRequest{Amount: &amount}, userID, map[string]string{"id": transaction.ID.String()}))
+		return w.Code
+	}
+	if code := updateTransaction(writer.ID, -100); code != http.StatusForbidden {
+		t.Errorf("Expected status 403 updating as the removed member, got %d", code)
+	}
+	if code := updateTransaction(owner.ID, -2000); code != http.StatusOK {
+		t.Errorf("Expected status 200 updating a removed member's transaction, got %d", code)
+	}
+	db.First(&transaction, "id = ?", transaction.ID)
+	if transaction.Amount != -2000 || transaction.UserID != writer.ID {
+		t.Errorf("Expected the amount fixed and the author kept, got %+v", transaction)
+	}
+
+	// Read-only members can leave; a split the owner lacks passes to them whole
+	db.Where("category_budget_id = ? AND user_id = ?", pooled.ID, owner.ID).Delete(&models.CategoryBudgetSplit{})
+	if code := leave(reader.ID); code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+	db.Where("category_budget_id = ?", pooled.ID).Find(&splits)
+	if len(splits) != 1 || splits[0].UserID != owner.ID || *splits[0].AllocationPercentage != 20 {
+		t.Errorf("Expected the reader's split passed to the owner, got %+v", splits)
+	}
+
+	// Leaving their default budget falls back to another they belong to
+	var reloaded models.User
+	db.First(&reloaded, "id = ?", reader.ID)
+	if reloaded.BudgetID == nil || *reloaded.BudgetID == budget.ID {
+		t.Errorf("Expected the reader's default budget moved off the shared one, got %v", reloaded.BudgetID)
+	}
+	var removed models.User
+	db.First(&removed, "id = ?", writer.ID)
+	if removed.BudgetID == nil || *removed.BudgetID != home.ID {
+		t.Errorf("Expected the writer's default budget kept, got %v", removed.BudgetID)
+	}
+}
+
+func TestChangeRoleAndTransferOwnership(t *testing.T) {
+	db := setupTestDB(t)
+	handler := NewMemberHandler(db)
+
+	owner, budget := createTestUser(t, db, "owner@example.com")
+	member, _ := createTestUser(t, db, "member@example.com")
+	outsider, _ := createTestUser(t, db, "outsider@example.com")
+	db.Create(&models.BudgetMember{BudgetID: budget.ID, UserID: member.ID, Role: models.BudgetRoleReadWrite})
+
+	update := func(userID, memberID uuid.UUID, role string) int {
+		w := httptest.NewRecorder()
+		handler.UpdateBudgetMember(w, testRequest("PATCH", "/members", UpdateBudgetMemberRequest{Role: role}, userID, map[string]string{"budgetId": budget.ID.String(), "userId": memberID.String()}))
+		return w.Code
+	}
+	transfer := func(userID, newOwnerID uuid.UUID) int {
+		w := httptest.NewRecorder()
+		handler.TransferOwnership(w, testRequest("POST", "/transfer-ownership", TransferOwnershipRequest{UserID: newOwnerID.String()}, userID, map[string]string{"budgetId": budget.ID.String()}))
+		return w.Code
+	}
+
+	tests := []struct {
+		name     string
+		userID   uuid.UUID
+		memberID uuid.UUID
+		role     string
+		expected int
+	}{
+		{"not the owner", member.ID, member.ID, models.BudgetRoleReadOnly, http.StatusForbidden},
+		{"invalid role", owner.ID, member.ID, models.BudgetRoleOwner, http.StatusBadRequest},
+		{"owner's own role", owner.ID, owner.ID, models.BudgetRoleReadOnly, http.StatusBadRequest},
+		{"not a member", owner.ID, outsider.ID, models.BudgetRoleReadOnly, http.StatusNotFound},
+		{"read only", owner.ID, member.ID, models.BudgetRoleReadOnly, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := update(tt.userID, tt.memberID, tt.role); code != tt.expected {
+				t.Errorf("Expected status %d, got %d", tt.expected, code)
+			}
+		})
+	}
+	if m, _ := budgetMember(db, budget.ID, member.ID); m.Role != models.BudgetRoleReadOnly {
+		t.Errorf("Expected the member made read-only, got %s", m.Role)
+	}
+
+	if code := transfer(owner.ID, outsider.ID); code != http.StatusNotFound {
+		t.Errorf("Expected status 404 transferring to a non-member, got %d", code)
+	}
+	if code := transfer(owner.ID, member.ID); code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+	if m, _ := budgetMember(db, budget.ID, member.ID); m.Role != models.BudgetRoleOwner {
+		t.Errorf("Expected the member to own the budget, got %s", m.Role)
+	}
+	if m, _ := budgetMember(db, budget.ID, owner.ID); m.Role != models.BudgetRoleReadWrite {
+		t.Errorf("Expected the previous owner kept as read_write, got %s", m.Role)
+	}
+	db.First(budget, "id = ?", budget.ID)
+	if budget.CreatedBy != member.ID {
+		t.Errorf("Expected created_by to follow the owner, got %s", budget.CreatedBy)
+	}
+	if code := transfer(owner.ID, member.ID); code != http.StatusForbidden {
+		t.Errorf("Expected status 403 transferring as the previous owner, got %d", code)
+	}
+}
+
+func TestBudgetMemberLimits(t *testing.T) {
+	db := setupTestDB(t)
+	handler := NewInvitationHandler(db)
+
+	owner, budget := createTestUser(t, db, "owner@example.com")
+	db.Model(budget).Update("max_members", 3)
+
+	invite := func(email string) (int, string, string) {
+		w := httptest.NewRecorder()
+		handler.InviteToBudget(w, testRequest("POST", "/invite", CreateBudgetInvitationRequest{Email: email}, owner.ID, map[string]string{"budgetId": budget.ID.String()}))
+		var response struct {
+			Data  models.BudgetInvitation `json:"data"`
+			Error string                  `json:"error"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+		return w.Code, response.Data.Token, response.Error
+	}
+	accept := func(email, token string) int {
+		user, _ := createTestUser(t, db, email)
+		req := httptest.NewRequest("POST", "/budget-invitations/"+token+"/accept", nil)
+		req = req.WithContext(context.WithValue(setUserIDContext(req, user.ID), middleware.ClaimsKey, jwt.MapClaims{"email": email}))
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("token", token)
+		req = req.WithContext(setRouteContext(req, rctx))
+		w := httptest.NewRecorder()
+		handler.AcceptBudgetInvitation(w, req)
+		return w.Code
+	}
+
+	// Free budgets take the owner and one more, pending invitations included
+	code, first, _ := invite("first@example.com")
+	if code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", code)
+	}
+	if code, _, message := invite("second@example.com"); code != http.StatusForbidden || message != memberLimitError(freeBudgetMaxMembers, false) {
+		t.Errorf("Expected status 403 over the free limit, got %d: %s", code, message)
+	}
+
+	// Premium owners get the budget's max_members
+	db.Model(owner).Update("is_premium", true)
+	code, second, _ := invite("second@example.com")
+	if code != http.StatusCreated {
+		t.Fatalf("Expected status 201 with premium, got %d", code)
+	}
+	if code, _, message := invite("third@example.com"); code != http.StatusForbidden || message != memberLimitError(3, true) {
+		t.Errorf("Expected status 403 over the premium limit, got %d: %s", code, message)
+	}
+
+	// Expired invitations free their place
+	db.Model(&models.BudgetInvitation{}).Where("token = ?", second).Update("expires_at", time.Now().Add(-time.Hour))
+	code, third, _ := invite("third@example.com")
+	if code != http.StatusCreated {
+		t.Fatalf("Expected status 201 after an invitation expired, got %d", code)
+	}
+
+	// Accepting is checked again once premium has lapsed
+	lapsed := time.Now().Add(-time.Hour)
+	db.Model(owner).Update("premium_expires_at", lapsed)
+	if code := accept("first@example.com", first); code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+	if code := accept("third@example.com", third); code != http.StatusForbidden {
+		t.Errorf("Expected status 403 accepting into a full budget, got %d", code)
+	}
+	var members int64
+	db.Model(&models.BudgetMember{}).Where("budget_id = ?", budget.ID).Count(&members)
+	if members != 2 {
+		t.Errorf("Expected 2 members, got %d", members)
+	}
+}
+
+func TestBackfillBudgetMembers(t *testing.T) {
+	db := setupTestDB(t)
+
+	// Before memberships, accepting an invitation overwrote the creator's
+	// budget_id, leaving their own budget with no one pointing at it
+	creator, created := createTestUser(t, db, "creator@example.com")
+	owner, shared := createTestUser(t, db, "owner@example.com")
+	db.Where("1 = 1").Delete(&models.BudgetMember{})
+	db.Model(&models.User{}).Where("id = ?", creator.ID).Updates(map[string]interface{}{"budget_id": shared.ID, "budget_role": models.BudgetRoleReadWrite})
+
+	if err := database.BackfillBudgetMembers(db); err != nil {
+		t.Fatalf("Failed to backfill: %v", err)
+	}
+	expected := []struct {
+		budgetID uuid.UUID
+		userID   uuid.UUID
+		role     string
+	}{
+		{created.ID, creator.ID, models.BudgetRoleOwner},
+		{shared.ID, creator.ID, models.BudgetRoleReadWrite},
+		{shared.ID, owner.ID, models.BudgetRoleOwner},
+	}
+	for _, e := range expected {
+		member, err := budgetMember(db, e.budgetID, e.userID)
+		if err != nil || member.Role != e.role {
+			t.Errorf("Expected %s to be %s of %s, got %q: %v", e.userID, e.role, e.budgetID, member.Role, err)
+		}
+	}
+
+	// Running it again changes nothing
+	if err := database.BackfillBudgetMembers(db); err != nil {
+		t.Fatalf("Failed to backfill again: %v", err)
+	}
+	var count int64
+	db.Model(&models.BudgetMember{}).Count(&count)
+	if count != 3 {
+		t.Errorf("Expected 3 memberships, got %d", count)
+	}
+}
@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yourusername/folda-finances/internal/currency"
+	"github.com/yourusername/folda-finances/internal/models"
+	"gorm.io/gorm"
+)
+
+type NetWorthHandler struct {
+	db *gorm.DB
+}
+
+func NewNetWorthHandler(db *gorm.DB) *NetWorthHandler {
+	return &NetWorthHandler{db: db}
+}
+
+type NetWorthSummary struct {
+	Date         string                        `json:"date"`
+	BaseCurrency string                        `json:"base_currency"`
+	Assets       int                           `json:"assets"`
+	Liabilities  int                           `json:"liabilities"`
+	NetWorth     int                           `json:"net_worth"`
+	Breakdown    []models.NetWorthSnapshotItem `json:"breakdown"`
+}
+
+// GetNetWorth returns the budget's current net worth from its active accounts
+func (h *NetWorthHandler) GetNetWorth(w http.ResponseWriter, r *http.Request) {
+	budgetID, ok := userBudgetID(h.db, w, r)
+	if !ok {
+		return
+	}
+
+	var budget models.Budget
+	if err := h.db.First(&budget, "id = ?", budgetID).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch budget"})
+		return
+	}
+
+	var accounts []models.Account
+	if err := h.db.Where("budget_id = ? AND is_active = ?", budgetID, true).Find(&accounts).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch accounts"})
+		return
+	}
+
+	now := time.Now()
+	values, err := accountValues(h.db, accounts, budget.BaseCurrency, now)
+	if err != nil {
+		respondConversionError(w, err)
+		return
+	}
+
+	snapshot := calculateNetWorth(accounts, values)
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data": NetWorthSummary{
+			Date:         now.Format("2006-01-02"),
+			BaseCurrency: budget.BaseCurrency,
+			Assets:       snapshot.Assets,
+			Liabilities:  snapshot.Liabilities,
+			NetWorth:     snapshot.NetWorth,
+			Breakdown:    snapshot.Items,
+		},
+	})
+}
+
+// GetNetWorthHistory returns stored snapshots between ?start_date= and
+// ?end_date=, one per day, or the last of each month with ?interval=monthly
+func (h *NetWorthHandler) GetNetWorthHistory(w http.ResponseWriter, r *http.Request) {
+	budgetID, ok := userBudgetID(h.db, w, r)
+	if !ok {
+		return
+	}
+
+	interval := r.URL.Query().Get("interval")
+	if interval == "" {
+		interval = "daily"
+	}
+	if interval != "daily" && interval != "monthly" {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "interval must be daily or monthly"})
+		return
+	}
+
+	now := time.Now()
+	endDate := now
+	if endParam := r.URL.Query().Get("end_date"); endParam != "" {
+		parsed, err := time.Parse("2006-01-02", endParam)
+		if err != nil {
+			respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid end_date format"})
+			return
+		}
+		endDate = parsed
+	}
+	startDate := endDate.AddDate(0, 0, -90)
+	if interval == "monthly" {
+		startDate = monthStart(endDate).AddDate(-1, 0, 0)
+	}
+	if startParam := r.URL.Query().Get("start_date"); startParam != "" {
+		parsed, err := time.Parse("2006-01-02", startParam)
+		if err != nil {
+			respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid start_date format"})
+			return
+		}
+		startDate = parsed
+	}
+
+	var snapshots []models.NetWorthSnapshot
+	if err := h.db.Preload("Items").
+		Where("budget_id = ? AND date >= ? AND date <= ?", budgetID, startDate, endDate).
+		Order("date ASC").
+		Find(&snapshots).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch net worth history"})
+		return
+	}
+
+	if interval == "monthly" {
+		snapshots = lastSnapshotPerMonth(snapshots)
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"data": snapshots})
+}
+
+// calculateNetWorth totals accounts by type, taking an account's value from
+// values in place of its balance when there is one. Liability balances are
+// negative when money is owed, so they count towards liabilities negated.
+func calculateNetWorth(accounts []models.Account, values map[uuid.UUID]int) models.NetWorthSnapshot {
+	byType := make(map[string]*models.NetWorthSnapshotItem)
+	for _, account := range accounts {
+		item, ok := byType[account.Type]
+		if !ok {
+			item = &models.NetWorthSnapshotItem{AccountType: account.Type, IsLiability: isLiability(account.Type)}
+			byType[account.Type] = item
+		}
+		if value, ok := values[account.ID]; ok {
+			item.Balance += value
+		} else {
+			item.Balance += account.Balance
+		}
+		item.AccountCount++
+	}
+
+	snapshot := models.NetWorthSnapshot{Items: []models.NetWorthSnapshotItem{}}
+	for _, item := range byType {
+		if item.IsLiability {
+			snapshot.Liabilities -= item.Balance
+		} else {
+			snapshot.Assets += item.Balance
+		}
+		snapshot.Items = append(snapshot.Items, *item)
+	}
+	snapshot.NetWorth = snapshot.Assets - snapshot.Liabilities
+
+	// Assets first, then liabilities, each by type
+	sort.Slice(snapshot.Items, func(i, j int) bool {
+		a, b := snapshot.Items[i], snapshot.Items[j]
+		if a.IsLiability != b.IsLiability {
+			return !a.IsLiability
+		}
+		return a.AccountType < b.AccountType
+	})
+
+	return snapshot
+}
+
+// accountValues values accounts in the base currency as of date, by ID.
+// Investment accounts that record trades count at their open lots' market
+// value: buys and sells do not move an account's balance, so it only stands
+// for accounts tracked by hand. Other accounts count at their balance.
+func accountValues(db *gorm.DB, accounts []models.Account, base string, date time.Time) (map[uuid.UUID]int, error) {
+	converter := currency.NewConverter(db)
+	values := make(map[uuid.UUID]int, len(accounts))
+	for _, account := range accounts {
+		value := account.Balance
+		if account.Type == "investment" {
+			var lots []models.InvestmentLot
+			if err := db.Where("account_id = ?", account.ID).
+				Order("acquired_at ASC, created_at ASC").Find(&lots).Error; err != nil {
+				return nil, err
+			}
+			if len(lots) > 0 {
+				var open []models.InvestmentLot
+				for _, lot := range lots {
+					if lot.RemainingQuantity > quantityEpsilon {
+						open = append(open, lot)
+					}
+				}
+				prices, err := latestPrices(db, account, open, date)
+				if err != nil {
+					return nil, err
+				}
+				value = calculateHoldings(account.ID, open, prices).MarketValue
+			}
+		}
+
+		if account.Currency != "" && account.Currency != base {
+			converted, err := converter.Convert(value, account.Currency, base, date)
+			if err != nil {
+				return nil, err
+			}
+			value = converted
+		}
+		values[account.ID] = value
+	}
+	return values, nil
+}
+
+// lastSnapshotPerMonth keeps the latest of date-ordered snapshots in each month
+func lastSnapshotPerMonth(snapshots []models.NetWorthSnapshot) []models.NetWorthSnapshot {
+	monthly := []models.NetWorthSnapshot{}
+	for i, snapshot := range snapshots {
+		if i+1 < len(snapshots) && snapshots[i+1].Date.Format("2006-01") == snapshot.Date.Format("2006-01") {
+			continue
+		}
+		monthly = append(monthly, snapshot)
+	}
+	return monthly
+}
+
+// StartNetWorthSnapshots records every active budget's net worth now and then
+// once per interval until ctx is cancelled
+func StartNetWorthSnapshots(ctx context.Context, db *gorm.DB, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := SnapshotNetWorth(db, time.Now()); err != nil {
+				log.Printf("Failed to snapshot net worth: %v", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// SnapshotNetWorth stores the net worth of every active budget for date,
+// replacing any snapshot already taken that day
+func SnapshotNetWorth(db *gorm.DB, date time.Time) error {
+	var budgets []models.Budget
+	if err := db.Where("is_active = ?", true).Find(&budgets).Error; err != nil {
+		return err
+	}
+
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	for _, budget := range budgets {
+		err := snapshotBudgetNetWorth(db, budget, day)
+		if errors.Is(err, currency.ErrNoRate) {
+			// Other budgets are still snapshotted while rates are missing
+			log.Printf("Skipped net worth snapshot of budget %s: %v", budget.ID, err)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func snapshotBudgetNetWorth(db *gorm.DB, budget models.Budget, day time.Time) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var accounts []models.Account
+		if err := tx.Where("budget_id = ? AND is_active = ?", budget.ID, true).Find(&accounts).Error; err != nil {
+			return err
+		}
+
+		var existing []models.NetWorthSnapshot
+		if err := tx.Where("budget_id = ? AND date = ?", budget.ID, day).Find(&existing).Error; err != nil {
+			return err
+		}
+		for _, snapshot := range existing {
+			if err := tx.Where("snapshot_id = ?", snapshot.ID).Delete(&models.NetWorthSnapshotItem{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Delete(&snapshot).Error; err != nil {
+				return err
+			}
+		}
+
+		values, err := accountValues(tx, accounts, budget.BaseCurrency, day)
+		if err != nil {
+			return err
+		}
+
+		snapshot := calculateNetWorth(accounts, values)
+		snapshot.BudgetID = budget.ID
+		snapshot.Date = day
+		return tx.Create(&snapshot).Error
+	})
+}
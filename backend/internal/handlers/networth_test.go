@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/yourusername/folda-finances/internal/models"
+)
+
+func TestCalculateNetWorth(t *testing.T) {
+	accounts := []models.Account{
+		{Type: "checking", Balance: 250000},
+		{Type: "savings", Balance: 1000000},
+		{Type: "credit_card", Balance: -150000},
+		{Type: "credit_card", Balance: -50000},
+		{Type: "mortgage", Balance: -20000000},
+	}
+
+	snapshot := calculateNetWorth(accounts, nil)
+
+	if snapshot.Assets != 1250000 {
+		t.Errorf("Expected assets 1250000, got %d", snapshot.Assets)
+	}
+	if snapshot.Liabilities != 20200000 {
+		t.Errorf("Expected liabilities 20200000, got %d", snapshot.Liabilities)
+	}
+	if snapshot.NetWorth != -18950000 {
+		t.Errorf("Expected net worth -18950000, got %d", snapshot.NetWorth)
+	}
+
+	if len(snapshot.Items) != 4 {
+		t.Fatalf("Expected 4 account types, got %d", len(snapshot.Items))
+	}
+	if item := snapshot.Items[2]; item.AccountType != "credit_card" || !item.IsLiability || item.AccountCount != 2 || item.Balance != -200000 {
+		t.Errorf("Unexpected credit card breakdown: %+v", item)
+	}
+}
+
+func TestGetNetWorth_Investments(t *testing.T) {
+	db := setupTestDB(t)
+	handler := NewNetWorthHandler(db)
+	accountHandler := NewAccountHandler(db)
+
+	user, budget := createTestUser(t, db, "test@example.com")
+	brokerage := &models.Account{ID: uuid.New(), BudgetID: budget.ID, Name: "Brokerage", Type: "investment", Balance: 100000, IsActive: true}
+	manual := &models.Account{ID: uuid.New(), BudgetID: budget.ID, Name: "Pension", Type: "investment", Balance: 500000, IsActive: true}
+	db.Create(brokerage)
+	db.Create(manual)
+
+	for _, trade := range []CreateInvestmentTransactionRequest{
+		{Type: "buy", Symbol: "VTI", Quantity: 10, Price: 20000, Date: "2025-01-10"},
+		{Type: "sell", Symbol: "VTI", Quantity: 4, Price: 22000, Date: "2025-02-10"},
+	} {
+		w := httptest.NewRecorder()
+		accountHandler.CreateInvestmentTransaction(w, testRequest("POST", "/accounts/"+brokerage.ID.String()+"/investment-transactions", trade, user.ID, map[string]string{"id": brokerage.ID.String()}))
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+	db.Create(&models.SecurityPrice{ID: uuid.New(), BudgetID: budget.ID, Symbol: "VTI", Date: testDate("2025-03-01"), Price: 25000, Source: "api"})
+
+	req := httptest.NewRequest("GET", "/net-worth", nil)
+	req = req.WithContext(setUserIDContext(req, user.ID))
+	w := httptest.NewRecorder()
+
+	handler.GetNetWorth(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response struct {
+		Data NetWorthSummary `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&response)
+
+	// 6 shares at $250 in the brokerage, the pension's balance as entered
+	if response.Data.Assets != 650000 {
+		t.Errorf("Expected assets 650000, got %d", response.Data.Assets)
+	}
+}
+
+func TestGetNetWorth_Currencies(t *testing.T) {
+	db := setupTestDB(t)
+	handler := NewNetWorthHandler(db)
+
+	user, budget := createTestUser(t, db, "test@example.com")
+	db.Create(&models.Account{ID: uuid.New(), BudgetID: budget.ID, Name: "Checking", Type: "checking", Balance: 100000, Currency: "USD", IsActive: true})
+	db.Create(&models.Account{ID: uuid.New(), BudgetID: budget.ID, Name: "Girokonto", Type: "checking", Balance: 100000, Currency: "EUR", IsActive: true})
+	db.Create(&models.Account{ID: uuid.New(), BudgetID: budget.ID, Name: "Kreditkarte", Type: "credit_card", Balance: -20000, Currency: "EUR", IsActive: true})
+	db.Create(&models.ExchangeRate{ID: uuid.New(), Date: testDate("2025-01-02"), BaseCurrency: "EUR", QuoteCurrency: "USD", Rate: 1.1, Source: "csv"})
+
+	get := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/net-worth", nil)
+		req = req.WithContext(setUserIDContext(req, user.ID))
+		w := httptest.NewRecorder()
+		handler.GetNetWorth(w, req)
+		return w
+	}
+
+	w := get()
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response struct {
+		Data NetWorthSummary `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&response)
+
+	if response.Data.BaseCurrency != "USD" {
+		t.Errorf("Expected base currency USD, got %s", response.Data.BaseCurrency)
+	}
+	// €1,000 is $1,100 and the card's €200 owed is $220
+	if response.Data.Assets != 210000 || response.Data.Liabilities != 22000 {
+		t.Errorf("Expected assets 210000 and liabilities 22000, got %d and %d", response.Data.Assets, response.Data.Liabilities)
+	}
+
+	// Balances without a rate are not summed as if they were dollars
+	db.Create(&models.Account{ID: uuid.New(), BudgetID: budget.ID, Name: "Current", Type: "savings", Balance: 100000, Currency: "GBP", IsActive: true})
+	if w := get(); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status 422 without a GBP rate, got %d", w.Code)
+	}
+}
+
+func TestSnapshotNetWorth(t *testing.T) {
+	db := setupTestDB(t)
+	handler := NewNetWorthHandler(db)
+
+	user, budget := createTestUser(t, db, "test@example.com")
+	account := &models.Account{ID: uuid.New(), BudgetID: budget.ID, Name: "Checking", Type: "checking", Balance: 100000, IsActive: true}
+	db.Create(account)
+
+	if err := SnapshotNetWorth(db, testDate("2025-01-15")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	db.Model(account).Update("balance", 120000)
+	if err := SnapshotNetWorth(db, testDate("2025-01-31")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Snapshotting the same day again replaces that day's snapshot
+	db.Model(account).Update("balance", 150000)
+	if err := SnapshotNetWorth(db, testDate("2025-02-10")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	db.Model(account).Update("balance", 160000)
+	if err := SnapshotNetWorth(db, testDate("2025-02-10")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var count int64
+	db.Model(&models.NetWorthSnapshot{}).Where("budget_id = ?", budget.ID).Count(&count)
+	if count != 3 {
+		t.Errorf("Expected 3 snapshots, got %d", count)
+	}
+
+	req := httptest.NewRequest("GET", "/net-worth/history?interval=monthly&start_date=2025-01-01&end_date=2025-02-28", nil)
+	req = req.WithContext(setUserIDContext(req, user.ID))
+	w := httptest.NewRecorder()
+
+	handler.GetNetWorthHistory(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response struct {
+		Data []models.NetWorthSnapshot `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&response)
+
+	// One per month, each the month's last
+	if len(response.Data) != 2 {
+		t.Fatalf("Expected 2 monthly snapshots, got %d", len(response.Data))
+	}
+	if response.Data[0].NetWorth != 120000 || response.Data[1].NetWorth != 160000 {
+		t.Errorf("Expected net worth 120000 then 160000, got %d then %d", response.Data[0].NetWorth, response.Data[1].NetWorth)
+	}
+	if len(response.Data[1].Items) != 1 || response.Data[1].Items[0].Balance != 160000 {
+		t.Errorf("Expected a checking breakdown of 160000, got %+v", response.Data[1].Items)
+	}
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yourusername/folda-finances/internal/models"
+	"github.com/yourusername/folda-finances/internal/storage"
+	"gorm.io/gorm"
+)
+
+// budgetPurgeGracePeriod is how long a deleted budget can be restored before
+// its data is purged
+const budgetPurgeGracePeriod = 30 * 24 * time.Hour
+
+// deactivateBudget marks a budget deleted as of now. Pending invitations to it
+// expire, and members using it as their default move to another budget.
+func deactivateBudget(tx *gorm.DB, budgetID uuid.UUID, now time.Time) error {
+	updates := map[string]interface{}{"is_active": false, "deactivated_at": now}
+	if err := tx.Model(&models.Budget{}).Where("id = ?", budgetID).Updates(updates).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.BudgetInvitation{}).Where("budget_id = ? AND status = ?", budgetID, "pending").Update("status", "expired").Error; err != nil {
+		return err
+	}
+
+	var users []models.User
+	if err := tx.Where("budget_id = ?", budgetID).Find(&users).Error; err != nil {
+		return err
+	}
+	for _, user := range users {
+		next, err := nextDefaultBudget(tx, user.ID, budgetID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&user).Update("budget_id", next).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StartBudgetPurges purges budgets deleted longer ago than the grace period
+// now and then once per interval until ctx is cancelled
+func StartBudgetPurges(ctx context.Context, db *gorm.DB, store storage.Store, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := PurgeDeletedBudgets(ctx, db, store, time.Now()); err != nil {
+				log.Printf("Failed to purge deleted budgets: %v", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// PurgeDeletedBudgets permanently removes the budgets whose grace period has
+// passed by now, with all their data and attachment files
+func PurgeDeletedBudgets(ctx context.Context, db *gorm.DB, store storage.Store, now time.Time) error {
+	var budgets []models.Budget
+	if err := db.Where("is_active = ? AND deactivated_at <= ?", false, now.Add(-budgetPurgeGracePeriod)).Find(&budgets).Error; err != nil {
+		return err
+	}
+
+	for _, budget := range budgets {
+		var attachments []models.Attachment
+		if err := db.Where("budget_id = ?", budget.ID).Find(&attachments).Error; err != nil {
+			return err
+		}
+
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			return purgeBudget(tx, budget.ID)
+		}); err != nil {
+			return err
+		}
+
+		// Files go once their rows are gone; one left behind is only clutter
+		for _, attachment := range attachments {
+			if err := store.Delete(ctx, attachment.StorageKey); err != nil {
+				log.Printf("Failed to delete attachment %s: %v", attachment.ID, err)
+			}
+			if attachment.ThumbnailKey != "" {
+				if err := store.Delete(ctx, attachment.ThumbnailKey); err != nil {
+					log.Printf("Failed to delete attachment thumbnail %s: %v", attachment.ID, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// purgeBudget deletes a budget and everything belonging to it, children
+// before the rows they hang off
+func purgeBudget(tx *gorm.DB, budgetID uuid.UUID) error {
+	ofBudget := func(model interface{}) *gorm.DB {
+		return tx.Model(model).Select("id").Where("budget_id = ?", budgetID)
+	}
+	children := []struct {
+		model  interface{}
+		column string
+		parent interface{}
+	}{
+		{&models.TransactionTag{}, "transaction_id", &models.Transaction{}},
+		{&models.CategoryBudgetAmount{}, "category_budget_id", &models.CategoryBudget{}},
+		{&models.CategoryBudgetSplit{}, "category_budget_id", &models.CategoryBudget{}},
+		{&models.GoalContribution{}, "goal_id", &models.Goal{}},
+		{&models.LoanPayment{}, "account_id", &models.Account{}},
+		{&models.InvestmentLot{}, "account_id", &models.Account{}},
+		{&models.InvestmentTransaction{}, "account_id", &models.Account{}},
+		{&models.NetWorthSnapshotItem{}, "snapshot_id", &models.NetWorthSnapshot{}},
+		{&models.BudgetTemplateItem{}, "template_id", &models.BudgetTemplate{}},
+	}
+	for _, child := range children {
+		if err := tx.Where(child.column+" IN (?)", ofBudget(child.parent)).Delete(child.model).Error; err != nil {
+			return err
+		}
+	}
+
+	owned := []interface{}{
+		&models.Attachment{},
+		&models.Reimbursement{},
+		&models.Transaction{},
+		&models.Tag{},
+		&models.CategoryBudget{},
+		&models.Goal{},
+		&models.Account{},
+		&models.NetWorthSnapshot{},
+		&models.BudgetTemplate{},
+		&models.ExpectedIncome{},
+		&models.SecurityPrice{},
+		&models.HiddenCategory{},
+		&models.CategoryParent{},
+		&models.Category{},
+		&models.BudgetInvitation{},
+		&models.BudgetMember{},
+	}
+	for _, model := range owned {
+		if err := tx.Where("budget_id = ?", budgetID).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Model(&models.User{}).Where("budget_id = ?", budgetID).Update("budget_id", nil).Error; err != nil {
+		return err
+	}
+	return tx.Delete(&models.Budget{}, "id = ?", budgetID).Error
+}
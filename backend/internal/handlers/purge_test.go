@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yourusername/folda-finances/internal/models"
+	"github.com/yourusername/folda-finances/internal/storage"
+	"gorm.io/gorm"
+)
+
+func TestPurgeDeletedBudgets(t *testing.T) {
+	db := setupTestDB(t)
+	store, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	user, budget := createTestUser(t, db, "test@example.com")
+	_, kept := createTestUser(t, db, "other@example.com")
+	for _, budgetID := range []uuid.UUID{budget.ID, kept.ID} {
+		category := createTestCategory(t, db, budgetID, "Groceries")
+		transaction := models.Transaction{ID: uuid.New(), UserID: user.ID, BudgetID: budgetID, CategoryID: category.ID, Amount: -1500, Date: time.Now()}
+		db.Create(&transaction)
+		tag := models.Tag{ID: uuid.New(), BudgetID: budgetID, Name: "weekly"}
+		db.Create(&tag)
+		db.Create(&models.TransactionTag{TransactionID: transaction.ID, TagID: tag.ID})
+		categoryBudget := models.CategoryBudget{ID: uuid.New(), BudgetID: budgetID, CategoryID: category.ID, Amount: 40000, AllocationType: "pooled"}
+		db.Create(&categoryBudget)
+		db.Create(&models.CategoryBudgetAmount{ID: uuid.New(), CategoryBudgetID: categoryBudget.ID, Amount: 40000, EffectiveMonth: monthStart(time.Now())})
+	}
+	attachment := models.Attachment{ID: uuid.New(), BudgetID: budget.ID, TransactionID: uuid.New(), UploadedBy: user.ID, FileName: "receipt.pdf", ContentType: "application/pdf", StorageKey: "receipt"}
+	db.Create(&attachment)
+	store.Put(t.Context(), attachment.StorageKey, bytes.NewReader([]byte("%PDF")), 4, "application/pdf")
+
+	deletedAt := time.Now()
+	db.Transaction(func(tx *gorm.DB) error {
+		return deactivateBudget(tx, budget.ID, deletedAt)
+	})
+
+	count := func(model interface{}) int64 {
+		var n int64
+		db.Model(model).Count(&n)
+		return n
+	}
+
+	// Nothing is purged during the grace period
+	if err := PurgeDeletedBudgets(t.Context(), db, store, deletedAt.Add(budgetPurgeGracePeriod-time.Hour)); err != nil {
+		t.Fatalf("Failed to purge: %v", err)
+	}
+	if n := count(&models.Budget{}); n != 2 {
+		t.Fatalf("Expected both budgets kept during the grace period, got %d", n)
+	}
+
+	if err := PurgeDeletedBudgets(t.Context(), db, store, deletedAt.Add(budgetPurgeGracePeriod)); err != nil {
+		t.Fatalf("Failed to purge: %v", err)
+	}
+	tables := []struct {
+		name  string
+		model interface{}
+	}{
+		{"budgets", &models.Budget{}},
+		{"budget members", &models.BudgetMember{}},
+		{"categories", &models.Category{}},
+		{"transactions", &models.Transaction{}},
+		{"tags", &models.Tag{}},
+		{"transaction tags", &models.TransactionTag{}},
+		{"category budgets", &models.CategoryBudget{}},
+		{"category budget amounts", &models.CategoryBudgetAmount{}},
+	}
+	for _, table := range tables {
+		if n := count(table.model); n != 1 {
+			t.Errorf("Expected only the active budget's %s kept, got %d", table.name, n)
+		}
+	}
+	if n := count(&models.Attachment{}); n != 0 {
+		t.Errorf("Expected the attachment purged, got %d", n)
+	}
+	if _, err := store.Get(t.Context(), attachment.StorageKey); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected the attachment file deleted, got %v", err)
+	}
+	var reloaded models.User
+	db.First(&reloaded, "id = ?", user.ID)
+	if reloaded.BudgetID != nil {
+		t.Errorf("Expected the user left without a default budget, got %v", reloaded.BudgetID)
+	}
+}
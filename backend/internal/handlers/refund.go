@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yourusername/folda-finances/internal/models"
+	"gorm.io/gorm"
+)
+
+// Periods a linked refund can net against its category's spending in
+const (
+	RefundPeriodRefund   = "refund"
+	RefundPeriodOriginal = "original"
+)
+
+// validateRefund checks a refund is money coming back and, when linked, that
+// the purchase it refunds is an earlier expense in the same budget and
+// currency with enough left to refund. It returns a status and message to
+// respond with, or zero when the refund is fine.
+func validateRefund(db *gorm.DB, transaction models.Transaction) (int, string) {
+	if !transaction.IsRefund {
+		if transaction.RefundOfTransactionID != nil {
+			return http.StatusBadRequest, "refund_of_transaction_id requires is_refund"
+		}
+		return 0, ""
+	}
+	if transaction.Amount <= 0 {
+		return http.StatusBadRequest, "refunds must be positive amounts"
+	}
+	if transaction.RefundOfTransactionID == nil {
+		return 0, ""
+	}
+
+	var original models.Transaction
+	if err := db.First(&original, "id = ? AND budget_id = ?", *transaction.RefundOfTransactionID, transaction.BudgetID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return http.StatusBadRequest, "refunded transaction not found"
+		}
+		return http.StatusInternalServerError, "failed to fetch refunded transaction"
+	}
+	if original.ID == transaction.ID || original.Amount >= 0 {
+		return http.StatusBadRequest, "only expenses can be refunded"
+	}
+	if original.Currency != transaction.Currency {
+		return http.StatusBadRequest, "refund must be in the purchase's currency"
+	}
+	if transaction.Date.Before(original.Date) {
+		return http.StatusBadRequest, "refund cannot be dated before the purchase"
+	}
+
+	var refunded int64
+	if err := db.Model(&models.Transaction{}).
+		Where("refund_of_transaction_id = ? AND id <> ?", original.ID, transaction.ID).
+		Select("COALESCE(SUM(amount), 0)").Scan(&refunded).Error; err != nil {
+		return http.StatusInternalServerError, "failed to fetch refunds"
+	}
+	if int(refunded)+transaction.Amount > -original.Amount {
+		return http.StatusBadRequest, "refunds exceed the purchase amount"
+	}
+	return 0, ""
+}
+
+// validatePurchaseRefunds checks a purchase being edited still covers the
+// refunds linked to it
+func validatePurchaseRefunds(db *gorm.DB, purchase models.Transaction) (int, string) {
+	var refunds []models.Transaction
+	if err := db.Where("refund_of_transaction_id = ?", purchase.ID).Find(&refunds).Error; err != nil {
+		return http.StatusInternalServerError, "failed to fetch refunds"
+	}
+	if len(refunds) == 0 {
+		return 0, ""
+	}
+
+	refunded := 0
+	for _, refund := range refunds {
+		if refund.Currency != purchase.Currency {
+			return http.StatusBadRequest, "purchase has refunds in another currency"
+		}
+		if refund.Date.Before(purchase.Date) {
+			return http.StatusBadRequest, "purchase cannot be dated after its refunds"
+		}
+		refunded += refund.Amount
+	}
+	if refunded > -purchase.Amount {
+		return http.StatusBadRequest, "purchase amount is less than its refunds"
+	}
+	return 0, ""
+}
+
+// periodRefunds returns the refunds that net against spending in the period
+// ending at end, given the period's transactions. Refunds count in the
+// period they land in unless the budget nets linked refunds in the purchase's
+// period, in which case those for earlier purchases are left to their
+// purchase's period and refunds of this period's purchases that landed later
+// are returned separately, as they still need converting.
+func periodRefunds(db *gorm.DB, budget models.Budget, transactions []models.Transaction, end time.Time) (refunds, later []models.Transaction, err error) {
+	var linked []models.Transaction
+	for _, tx := range transactions {
+		if !tx.IsRefund || tx.Amount <= 0 {
+			continue
+		}
+		if budget.RefundPeriod == RefundPeriodOriginal && tx.RefundOfTransactionID != nil {
+			linked = append(linked, tx)
+			continue
+		}
+		refunds = append(refunds, tx)
+	}
+	if budget.RefundPeriod != RefundPeriodOriginal {
+		return refunds, nil, nil
+	}
+
+	inPeriod := make(map[uuid.UUID]bool)
+	for _, tx := range transactions {
+		if tx.Amount < 0 {
+			inPeriod[tx.ID] = true
+		}
+	}
+	for _, tx := range linked {
+		if inPeriod[*tx.RefundOfTransactionID] {
+			refunds = append(refunds, tx)
+		}
+	}
+
+	purchaseIDs := make([]uuid.UUID, 0, len(inPeriod))
+	for id := range inPeriod {
+		purchaseIDs = append(purchaseIDs, id)
+	}
+	if len(purchaseIDs) == 0 {
+		return refunds, nil, nil
+	}
+	err = db.Where("budget_id = ? AND is_refund = ? AND amount > 0 AND refund_of_transaction_id IN ? AND date > ?",
+		budget.ID, true, purchaseIDs, end).Order("date ASC").Find(&later).Error
+	return refunds, later, err
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/yourusername/folda-finances/internal/models"
+)
+
+func TestRefundsNetAgainstSpending(t *testing.T) {
+	db := setupTestDB(t)
+	handler := NewSpendingHandler(db)
+
+	user, budget := createTestUser(t, db, "test@example.com")
+	shopping := createTestCategory(t, db, budget.ID, "Shopping")
+	db.Create(&models.CategoryBudget{ID: uuid.New(), BudgetID: budget.ID, CategoryID: shopping.ID, Amount: 50000, AllocationType: "pooled"})
+
+	jacket := &models.Transaction{ID: uuid.New(), UserID: user.ID, BudgetID: budget.ID, CategoryID: shopping.ID, Amount: -10000, Currency: "USD", Date: testDate("2025-01-20")}
+	db.Create(jacket)
+	db.Create(&models.Transaction{ID: uuid.New(), UserID: user.ID, BudgetID: budget.ID, CategoryID: shopping.ID, Amount: -9000, Currency: "USD", Date: testDate("2025-02-12")})
+	db.Create(&models.Transaction{ID: uuid.New(), UserID: user.ID, BudgetID: budget.ID, CategoryID: shopping.ID, Amount: 6000, Currency: "USD", Date: testDate("2025-02-05"), IsRefund: true, RefundOfTransactionID: &jacket.ID})
+	db.Create(&models.Transaction{ID: uuid.New(), UserID: user.ID, BudgetID: budget.ID, CategoryID: shopping.ID, Amount: 1000, Currency: "USD", Date: testDate("2025-02-10"), IsRefund: true})
+
+	spent := func(date string) int {
+		req := httptest.NewRequest("GET", "/spending/available?date="+date, nil)
+		req = req.WithContext(setUserIDContext(req, user.ID))
+		w := httptest.NewRecorder()
+		handler.GetSpendingAvailable(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var response struct {
+			Data SpendingAvailableResponse `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+		return response.Data.Categories[0].Spent
+	}
+
+	// By default refunds net against the period they land in
+	if got := spent("2025-01-15"); got != 10000 {
+		t.Errorf("Expected 10000 spent in January, got %d", got)
+	}
+	if got := spent("2025-02-15"); got != 2000 {
+		t.Errorf("Expected 2000 spent in February, got %d", got)
+	}
+
+	// Linked refunds can net against the purchase's period instead
+	db.Model(budget).Update("refund_period", RefundPeriodOriginal)
+	if got := spent("2025-01-15"); got != 4000 {
+		t.Errorf("Expected the jacket refund to net in January, got %d spent", got)
+	}
+	if got := spent("2025-02-15"); got != 8000 {
+		t.Errorf("Expected only the unlinked refund to net in February, got %d spent", got)
+	}
+}
+
+func TestCreateRefund(t *testing.T) {
+	db := setupTestDB(t)
+	handler := NewTransactionHandler(db)
+
+	user, budget := createTestUser(t, db, "test@example.com")
+	shopping := createTestCategory(t, db, budget.ID, "Shopping")
+	purchase := &models.Transaction{ID: uuid.New(), UserID: user.ID, BudgetID: budget.ID, CategoryID: shopping.ID, Amount: -10000, Currency: "USD", Date: testDate("2025-03-01")}
+	db.Create(purchase)
+
+	create := func(body map[string]interface{}) *httptest.ResponseRecorder {
+		data, _ := json.Marshal(body)
+		req := httptest.NewRequest("POST", "/transactions", bytes.NewBuffer(data))
+		req = req.WithContext(setUserIDContext(req, user.ID))
+		w := httptest.NewRecorder()
+		handler.CreateTransaction(w, req)
+		return w
+	}
+
+	tests := []struct {
+		name string
+		body map[string]interface{}
+	}{
+		{"negative refund", map[string]interface{}{"amount": -500, "category_id": shopping.ID.String(), "date": "2025-03-05", "is_refund": true}},
+		{"link without flag", map[string]interface{}{"amount": 500, "category_id": shopping.ID.String(), "date": "2025-03-05", "refund_of_transaction_id": purchase.ID.String()}},
+		{"dated before purchase", map[string]interface{}{"amount": 500, "date": "2025-02-28", "is_refund": true, "refund_of_transaction_id": purchase.ID.String()}},
+		{"other currency", map[string]interface{}{"amount": 500, "currency": "EUR", "date": "2025-03-05", "is_refund": true, "refund_of_transaction_id": purchase.ID.String()}},
+		{"more than the purchase", map[string]interface{}{"amount": 10001, "date": "2025-03-05", "is_refund": true, "refund_of_transaction_id": purchase.ID.String()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := create(tt.body); w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+
+	// The category defaults to the purchase's
+	w := create(map[string]interface{}{"amount": 6000, "date": "2025-03-05", "is_refund": true, "refund_of_transaction_id": purchase.ID.String()})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var response struct {
+		Data models.Transaction `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&response)
+	if response.Data.CategoryID != shopping.ID {
+		t.Errorf("Expected the refund in the purchase's category, got %s", response.Data.CategoryID)
+	}
+
+	// Later refunds only cover what is left
+	if w := create(map[string]interface{}{"amount": 5000, "date": "2025-03-06", "is_refund": true, "refund_of_transaction_id": purchase.ID.String()}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 refunding more than the rest, got %d", w.Code)
+	}
+	if w := create(map[string]interface{}{"amount": 4000, "date": "2025-03-06", "is_refund": true, "refund_of_transaction_id": purchase.ID.String()}); w.Code != http.StatusCreated {
+		t.Errorf("Expected status 201 refunding the rest, got %d: %s", w.Code, w.Body.String())
+	}
+}
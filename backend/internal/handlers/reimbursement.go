@@ -0,0 +1,497 @@
+package handlers
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/yourusername/folda-finances/internal/currency"
+	"github.com/yourusername/folda-finances/internal/models"
+	"gorm.io/gorm"
+)
+
+// Aging buckets for outstanding reimbursements, by days since the expense
+var reimbursementAgingBuckets = []struct {
+	Name    string
+	MaxDays int
+}{
+	{"0-30", 30},
+	{"31-60", 60},
+	{"61-90", 90},
+	{"over_90", math.MaxInt},
+}
+
+type ReimbursementHandler struct {
+	db *gorm.DB
+}
+
+func NewReimbursementHandler(db *gorm.DB) *ReimbursementHandler {
+	return &ReimbursementHandler{db: db}
+}
+
+type CreateReimbursementRequest struct {
+	ReimbursementTransactionID string `json:"reimbursement_transaction_id"`
+	Amount                     *int   `json:"amount"` // defaults to as much as both sides allow
+}
+
+type UpdateReimbursementSettingsRequest struct {
+	ExcludePendingReimbursements bool `json:"exclude_pending_reimbursements"`
+}
+
+// ReimbursementStatus is how much of a reimbursable expense has been paid back
+type ReimbursementStatus struct {
+	TransactionID  string                 `json:"transaction_id"`
+	Payer          string                 `json:"payer"`
+	Currency       string                 `json:"currency"`
+	Amount         int                    `json:"amount"` // expense, as a positive amount
+	Reimbursed     int                    `json:"reimbursed"`
+	Outstanding    int                    `json:"outstanding"`
+	Status         string                 `json:"status"` // pending, partial, reimbursed
+	Reimbursements []models.Reimbursement `json:"reimbursements"`
+}
+
+type OutstandingReimbursement struct {
+	TransactionID string `json:"transaction_id"`
+	Description   string `json:"description"`
+	Payer         string `json:"payer"`
+	Date          string `json:"date"`
+	Currency      string `json:"currency"`
+	Amount        int    `json:"amount"`
+	Reimbursed    int    `json:"reimbursed"`
+	Outstanding   int    `json:"outstanding"`
+	AgeDays       int    `json:"age_days"`
+	AgingBucket   string `json:"aging_bucket"`
+}
+
+type ReimbursementTotal struct {
+	Name        string `json:"name"` // aging bucket or payer
+	Outstanding int    `json:"outstanding"`
+	Count       int    `json:"count"`
+}
+
+type OutstandingReimbursementsResponse struct {
+	BaseCurrency     string                     `json:"base_currency"`
+	TotalOutstanding int                        `json:"total_outstanding"`
+	Aging            []ReimbursementTotal       `json:"aging"`
+	ByPayer          []ReimbursementTotal       `json:"by_payer"`
+	Items            []OutstandingReimbursement `json:"items"`
+}
+
+// GetReimbursementStatus returns what has been paid back on a reimbursable
+// expense and the transactions that paid it
+func (h *ReimbursementHandler) GetReimbursementStatus(w http.ResponseWriter, r *http.Request) {
+	expense, _, ok := authorizedTransaction(h.db, w, r)
+	if !ok {
+		return
+	}
+
+	if !expense.IsReimbursable {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "transaction is not a reimbursable expense"})
+		return
+	}
+
+	status, err := h.reimbursementStatus(expense)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch reimbursements"})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"data": status})
+}
+
+// CreateReimbursement links an incoming transaction to the reimbursable
+// expense it pays back
+func (h *ReimbursementHandler) CreateReimbursement(w http.ResponseWriter, r *http.Request) {
+	expense, _, ok := authorizedTransaction(h.db, w, r)
+	if !ok {
+		return
+	}
+
+	var req CreateReimbursementRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+
+	if !expense.IsReimbursable || expense.Amount >= 0 {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "transaction is not a reimbursable expense"})
+		return
+	}
+
+	var incoming models.Transaction
+	if err := h.db.First(&incoming, "id = ?", req.ReimbursementTransactionID).Error; err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "reimbursement transaction not found"})
+		return
+	}
+	if incoming.BudgetID != expense.BudgetID {
+		respondJSON(w, http.StatusForbidden, map[string]string{"error": "access denied"})
+		return
+	}
+	if incoming.Amount <= 0 {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "reimbursement transaction must be incoming money"})
+		return
+	}
+	if incoming.IsRefund {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "refunds already reduce spending and cannot be linked as a reimbursement"})
+		return
+	}
+	if incoming.Currency != expense.Currency {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "reimbursement must be in the expense's currency"})
+		return
+	}
+
+	var existing int64
+	if err := h.db.Model(&models.Reimbursement{}).
+		Where("expense_transaction_id = ? AND reimbursement_transaction_id = ?", expense.ID, incoming.ID).
+		Count(&existing).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to check reimbursements"})
+		return
+	}
+	if existing > 0 {
+		respondJSON(w, http.StatusConflict, map[string]string{"error": "transaction is already linked to this expense"})
+		return
+	}
+
+	reimbursed, err := linkedAmounts(h.db, "expense_transaction_id", []uuid.UUID{expense.ID})
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch reimbursements"})
+		return
+	}
+	allocated, err := linkedAmounts(h.db, "reimbursement_transaction_id", []uuid.UUID{incoming.ID})
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch reimbursements"})
+		return
+	}
+	outstanding := -expense.Amount - reimbursed[expense.ID]
+	unallocated := incoming.Amount - allocated[incoming.ID]
+
+	amount := min(outstanding, unallocated)
+	if req.Amount != nil {
+		amount = *req.Amount
+	}
+	if amount <= 0 || amount > outstanding || amount > unallocated {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "amount must be positive and no more than the outstanding expense or the unallocated reimbursement"})
+		return
+	}
+
+	link := models.Reimbursement{
+		BudgetID:                   expense.BudgetID,
+		ExpenseTransactionID:       expense.ID,
+		ReimbursementTransactionID: incoming.ID,
+		Amount:                     amount,
+	}
+	if err := h.db.Create(&link).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to link reimbursement"})
+		return
+	}
+
+	status, err := h.reimbursementStatus(expense)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch reimbursements"})
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, map[string]interface{}{
+		"data":    status,
+		"message": "Reimbursement linked successfully",
+	})
+}
+
+func (h *ReimbursementHandler) DeleteReimbursement(w http.ResponseWriter, r *http.Request) {
+	expense, _, ok := authorizedTransaction(h.db, w, r)
+	if !ok {
+		return
+	}
+
+	var link models.Reimbursement
+	if err := h.db.First(&link, "id = ? AND expense_transaction_id = ?", chi.URLParam(r, "reimbursementId"), expense.ID).Error; err != nil {
+		respondJSON(w, http.StatusNotFound, map[string]string{"error": "reimbursement not found"})
+		return
+	}
+
+	if err := h.db.Delete(&link).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to unlink reimbursement"})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"message": "Reimbursement unlinked successfully",
+	})
+}
+
+// ListOutstandingReimbursements lists reimbursable expenses not yet fully paid
+// back, oldest first, with totals in the base currency by age and payer
+func (h *ReimbursementHandler) ListOutstandingReimbursements(w http.ResponseWriter, r *http.Request) {
+	budgetID, ok := userBudgetID(h.db, w, r)
+	if !ok {
+		return
+	}
+
+	var budget models.Budget
+	if err := h.db.First(&budget, "id = ?", budgetID).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch budget"})
+		return
+	}
+
+	query := h.db.Where("budget_id = ? AND is_reimbursable = ? AND amount < 0", budgetID, true)
+	if payer := strings.TrimSpace(r.URL.Query().Get("payer")); payer != "" {
+		query = query.Where("LOWER(reimbursement_payer) = ?", strings.ToLower(payer))
+	}
+
+	var expenses []models.Transaction
+	if err := query.Order("date ASC, created_at ASC").Find(&expenses).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch transactions"})
+		return
+	}
+
+	response, err := outstandingReimbursements(h.db, expenses, budget.BaseCurrency, currentDate())
+	if err != nil {
+		respondConversionError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"data": response})
+}
+
+// UpdateReimbursementSettings chooses whether money still owed back on
+// reimbursable expenses counts as category spending
+func (h *ReimbursementHandler) UpdateReimbursementSettings(w http.ResponseWriter, r *http.Request) {
+	budgetID, ok := userBudgetID(h.db, w, r)
+	if !ok {
+		return
+	}
+
+	var req UpdateReimbursementSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+
+	var budget models.Budget
+	if err := h.db.First(&budget, "id = ?", budgetID).Error; err != nil {
+		respondJSON(w, http.StatusNotFound, map[string]string{"error": "budget not found"})
+		return
+	}
+
+	if err := h.db.Model(&budget).Update("exclude_pending_reimbursements", req.ExcludePendingReimbursements).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to update reimbursement settings"})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data":    budget,
+		"message": "Reimbursement settings updated successfully",
+	})
+}
+
+func (h *ReimbursementHandler) reimbursementStatus(expense models.Transaction) (ReimbursementStatus, error) {
+	links := []models.Reimbursement{}
+	if err := h.db.Where("expense_transaction_id = ?", expense.ID).Order("created_at ASC").Find(&links).Error; err != nil {
+		return ReimbursementStatus{}, err
+	}
+
+	status := ReimbursementStatus{
+		TransactionID:  expense.ID.String(),
+		Payer:          expense.ReimbursementPayer,
+		Currency:       expense.Currency,
+		Amount:         -expense.Amount,
+		Reimbursements: links,
+	}
+	for _, link := range links {
+		status.Reimbursed += link.Amount
+	}
+	status.Outstanding = max(status.Amount-status.Reimbursed, 0)
+	status.Status = reimbursementState(status.Reimbursed, status.Outstanding)
+	return status, nil
+}
+
+func outstandingReimbursements(db *gorm.DB, expenses []models.Transaction, baseCurrency string, today time.Time) (OutstandingReimbursementsResponse, error) {
+	response := OutstandingReimbursementsResponse{
+		BaseCurrency: baseCurrency,
+		Aging:        make([]ReimbursementTotal, len(reimbursementAgingBuckets)),
+		ByPayer:      []ReimbursementTotal{},
+		Items:        []OutstandingReimbursement{},
+	}
+	for i, bucket := range reimbursementAgingBuckets {
+		response.Aging[i].Name = bucket.Name
+	}
+
+	ids := make([]uuid.UUID, len(expenses))
+	for i, expense := range expenses {
+		ids[i] = expense.ID
+	}
+	reimbursed, err := linkedAmounts(db, "expense_transaction_id", ids)
+	if err != nil {
+		return response, err
+	}
+
+	converter := currency.NewConverter(db)
+	payerIndex := make(map[string]int)
+	for _, expense := range expenses {
+		outstanding := -expense.Amount - reimbursed[expense.ID]
+		if outstanding <= 0 {
+			continue
+		}
+		converted, err := converter.Convert(outstanding, expense.Currency, baseCurrency, expense.Date)
+		if err != nil {
+			return response, err
+		}
+
+		ageDays := int(today.Sub(expense.Date).Hours() / 24)
+		bucket := 0
+		for bucket < len(reimbursementAgingBuckets)-1 && ageDays > reimbursementAgingBuckets[bucket].MaxDays {
+			bucket++
+		}
+
+		response.Items = append(response.Items, OutstandingReimbursement{
+			TransactionID: expense.ID.String(),
+			Description:   expense.Description,
+			Payer:         expense.ReimbursementPayer,
+			Date:          expense.Date.Format("2006-01-02"),
+			Currency:      expense.Currency,
+			Amount:        -expense.Amount,
+			Reimbursed:    reimbursed[expense.ID],
+			Outstanding:   outstanding,
+			AgeDays:       ageDays,
+			AgingBucket:   reimbursementAgingBuckets[bucket].Name,
+		})
+
+		response.TotalOutstanding += converted
+		response.Aging[bucket].Outstanding += converted
+		response.Aging[bucket].Count++
+
+		i, ok := payerIndex[expense.ReimbursementPayer]
+		if !ok {
+			i = len(response.ByPayer)
+			payerIndex[expense.ReimbursementPayer] = i
+			response.ByPayer = append(response.ByPayer, ReimbursementTotal{Name: expense.ReimbursementPayer})
+		}
+		response.ByPayer[i].Outstanding += converted
+		response.ByPayer[i].Count++
+	}
+
+	sort.SliceStable(response.ByPayer, func(i, j int) bool {
+		return response.ByPayer[i].Outstanding > response.ByPayer[j].Outstanding
+	})
+	return response, nil
+}
+
+// reimbursableAdjustments returns how much less each reimbursable expense
+// counts as spending, in the transactions' (converted) amounts: what has been
+// paid back, plus what is still owed when the budget excludes pending
+// reimbursements. originals holds the pre-conversion amounts of converted
+// transactions, as returned by convertTransactions.
+func reimbursableAdjustments(db *gorm.DB, transactions []models.Transaction, originals map[uuid.UUID]int, excludePending bool) (map[uuid.UUID]int, error) {
+	var ids []uuid.UUID
+	for _, tx := range transactions {
+		if tx.IsReimbursable && tx.Amount < 0 {
+			ids = append(ids, tx.ID)
+		}
+	}
+	adjustments := make(map[uuid.UUID]int)
+	if len(ids) == 0 {
+		return adjustments, nil
+	}
+
+	reimbursed, err := linkedAmounts(db, "expense_transaction_id", ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tx := range transactions {
+		if !tx.IsReimbursable || tx.Amount >= 0 {
+			continue
+		}
+		original, converted := tx.Amount, false
+		if amount, ok := originals[tx.ID]; ok {
+			original, converted = amount, true
+		}
+
+		adjustment := min(reimbursed[tx.ID], -original)
+		if excludePending {
+			adjustment = -original
+		}
+		if converted && original != 0 {
+			adjustment = int(math.Round(float64(adjustment) * float64(tx.Amount) / float64(original)))
+		}
+		adjustments[tx.ID] = adjustment
+	}
+	return adjustments, nil
+}
+
+// linkedAmounts sums reimbursement link amounts by the given side's
+// transaction ID
+func linkedAmounts(db *gorm.DB, column string, ids []uuid.UUID) (map[uuid.UUID]int, error) {
+	amounts := make(map[uuid.UUID]int)
+	if len(ids) == 0 {
+		return amounts, nil
+	}
+
+	var links []models.Reimbursement
+	if err := db.Where(column+" IN ?", ids).Find(&links).Error; err != nil {
+		return nil, err
+	}
+	for _, link := range links {
+		if column == "expense_transaction_id" {
+			amounts[link.ExpenseTransactionID] += link.Amount
+		} else {
+			amounts[link.ReimbursementTransactionID] += link.Amount
+		}
+	}
+	return amounts, nil
+}
+
+// validateReimbursementLinks checks a transaction as updated still covers its
+// reimbursement links: an expense what has been paid back on it, and incoming
+// money what it has paid back, both in the currency of the other side
+func validateReimbursementLinks(db *gorm.DB, transaction models.Transaction) (int, string) {
+	ids := []uuid.UUID{transaction.ID}
+	reimbursed, err := linkedAmounts(db, "expense_transaction_id", ids)
+	if err != nil {
+		return http.StatusInternalServerError, "failed to fetch reimbursements"
+	}
+	allocated, err := linkedAmounts(db, "reimbursement_transaction_id", ids)
+	if err != nil {
+		return http.StatusInternalServerError, "failed to fetch reimbursements"
+	}
+	if reimbursed[transaction.ID] == 0 && allocated[transaction.ID] == 0 {
+		return 0, ""
+	}
+
+	var others []models.Transaction
+	if err := db.Where("id IN (?) OR id IN (?)",
+		db.Model(&models.Reimbursement{}).Select("reimbursement_transaction_id").Where("expense_transaction_id = ?", transaction.ID),
+		db.Model(&models.Reimbursement{}).Select("expense_transaction_id").Where("reimbursement_transaction_id = ?", transaction.ID)).
+		Find(&others).Error; err != nil {
+		return http.StatusInternalServerError, "failed to fetch reimbursements"
+	}
+	for _, other := range others {
+		if other.Currency != transaction.Currency {
+			return http.StatusConflict, "transaction has reimbursement links in another currency; unlink them first"
+		}
+	}
+
+	if reimbursed[transaction.ID] > 0 && -transaction.Amount < reimbursed[transaction.ID] {
+		return http.StatusConflict, "expense amount is less than has been reimbursed; unlink reimbursements first"
+	}
+	if allocated[transaction.ID] > 0 && transaction.Amount < allocated[transaction.ID] {
+		return http.StatusConflict, "amount is less than it has reimbursed; unlink reimbursements first"
+	}
+	return 0, ""
+}
+
+func reimbursementState(reimbursed, outstanding int) string {
+	switch {
+	case outstanding == 0:
+		return "reimbursed"
+	case reimbursed > 0:
+		return "partial"
+	default:
+		return "pending"
+	}
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/yourusername/folda-finances/internal/models"
+)
+
+func TestReimbursements(t *testing.T) {
+	db := setupTestDB(t)
+	handler := NewReimbursementHandler(db)
+	spendingHandler := NewSpendingHandler(db)
+
+	user, budget := createTestUser(t, db, "test@example.com")
+	travel := createTestCategory(t, db, budget.ID, "Travel")
+	income := createTestCategory(t, db, budget.ID, "Income")
+	db.Create(&models.CategoryBudget{ID: uuid.New(), BudgetID: budget.ID, CategoryID: travel.ID, Amount: 100000, AllocationType: "pooled"})
+
+	today := time.Now()
+	hotel := &models.Transaction{ID: uuid.New(), UserID: user.ID, BudgetID: budget.ID, CategoryID: travel.ID, Amount: -20000, Currency: "USD", Date: today, IsReimbursable: true, ReimbursementPayer: "Acme Corp"}
+	taxi := &models.Transaction{ID: uuid.New(), UserID: user.ID, BudgetID: budget.ID, CategoryID: travel.ID, Amount: -3000, Currency: "USD", Date: today}
+	payback := &models.Transaction{ID: uuid.New(), UserID: user.ID, BudgetID: budget.ID, CategoryID: income.ID, Amount: 15000, Currency: "USD", Date: today}
+	db.Create(hotel)
+	db.Create(taxi)
+	db.Create(payback)
+	// Last year, so it stays out of this period's spending
+	refund := &models.Transaction{ID: uuid.New(), UserID: user.ID, BudgetID: budget.ID, CategoryID: travel.ID, Amount: 2000, Currency: "USD", Date: today.AddDate(-1, 0, 0), IsRefund: true}
+	db.Create(refund)
+
+	link := func(body CreateReimbursementRequest) *httptest.ResponseRecorder {
+		data, _ := json.Marshal(body)
+		req := httptest.NewRequest("POST", "/transactions/"+hotel.ID.String()+"/reimbursements", bytes.NewBuffer(data))
+		req = req.WithContext(setUserIDContext(req, user.ID))
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", hotel.ID.String())
+		req = req.WithContext(setRouteContext(req, rctx))
+		w := httptest.NewRecorder()
+		handler.CreateReimbursement(w, req)
+		return w
+	}
+
+	// The link defaults to as much as the incoming money covers
+	w := link(CreateReimbursementRequest{ReimbursementTransactionID: payback.ID.String()})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var response struct {
+		Data ReimbursementStatus `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&response)
+	if response.Data.Reimbursed != 15000 || response.Data.Outstanding != 5000 || response.Data.Status != "partial" {
+		t.Errorf("Unexpected status: %+v", response.Data)
+	}
+
+	if w := link(CreateReimbursementRequest{ReimbursementTransactionID: payback.ID.String()}); w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 linking the same transaction twice, got %d", w.Code)
+	}
+	if w := link(CreateReimbursementRequest{ReimbursementTransactionID: taxi.ID.String()}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 linking an expense as a reimbursement, got %d", w.Code)
+	}
+	if w := link(CreateReimbursementRequest{ReimbursementTransactionID: refund.ID.String()}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 linking a refund as a reimbursement, got %d", w.Code)
+	}
+
+	spent := func() int {
+		req := httptest.NewRequest("GET", "/spending/available", nil)
+		req = req.WithContext(setUserIDContext(req, user.ID))
+		w := httptest.NewRecorder()
+		spendingHandler.GetSpendingAvailable(w, req)
+		var response struct {
+			Data SpendingAvailableResponse `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+		return response.Data.Categories[0].Spent
+	}
+
+	// The reimbursed part nets out; the rest counts until configured otherwise
+	if got := spent(); got != 8000 {
+		t.Errorf("Expected 8000 spent with the reimbursement netted, got %d", got)
+	}
+	db.Model(budget).Update("exclude_pending_reimbursements", true)
+	if got := spent(); got != 3000 {
+		t.Errorf("Expected only the taxi spent when excluding pending reimbursements, got %d", got)
+	}
+}
+
+func TestUpdateReimbursedTransactions(t *testing.T) {
+	db := setupTestDB(t)
+	handler := NewTransactionHandler(db)
+
+	user, budget := createTestUser(t, db, "test@example.com")
+	travel := createTestCategory(t, db, budget.ID, "Travel")
+	income := createTestCategory(t, db, budget.ID, "Income")
+	db.Model(income).Update("kind", categoryKindIncome)
+
+	today := time.Now()
+	hotel := &models.Transaction{ID: uuid.New(), UserID: user.ID, BudgetID: budget.ID, CategoryID: travel.ID, Amount: -20000, Currency: "USD", Date: today, IsReimbursable: true, ReimbursementPayer: "Acme Corp"}
+	payback := &models.Transaction{ID: uuid.New(), UserID: user.ID, BudgetID: budget.ID, CategoryID: income.ID, Amount: 15000, Currency: "USD", Date: today}
+	db.Create(hotel)
+	db.Create(payback)
+	db.Create(&models.Reimbursement{ID: uuid.New(), BudgetID: budget.ID, ExpenseTransactionID: hotel.ID, ReimbursementTransactionID: payback.ID, Amount: 12000})
+
+	update := func(transaction *models.Transaction, body UpdateTransactionRequest) int {
+		w := httptest.NewRecorder()
+		handler.UpdateTransaction(w, testRequest("PUT", "/transactions", body, user.ID, map[string]string{"id": transaction.ID.String()}))
+		return w.Code
+	}
+
+	refund := true
+	tests := []struct {
+		name        string
+		transaction *models.Transaction
+		body        UpdateTransactionRequest
+		expected    int
+	}{
+		{"expense below reimbursed", hotel, UpdateTransactionRequest{Amount: intPtr(-10000)}, http.StatusConflict},
+		{"expense in another currency", hotel, UpdateTransactionRequest{Currency: stringPtr("EUR")}, http.StatusConflict},
+		{"reimbursement below allocated", payback, UpdateTransactionRequest{Amount: intPtr(10000)}, http.StatusConflict},
+		{"reimbursement flipped negative", payback, UpdateTransactionRequest{Amount: intPtr(-15000)}, http.StatusConflict},
+		{"reimbursement in another currency", payback, UpdateTransactionRequest{Currency: stringPtr("EUR")}, http.StatusConflict},
+		{"reimbursement marked as a refund", payback, UpdateTransactionRequest{IsRefund: &refund}, http.StatusConflict},
+		{"expense still covering", hotel, UpdateTransactionRequest{Amount: intPtr(-12000)}, http.StatusOK},
+		{"reimbursement still covering", payback, UpdateTransactionRequest{Amount: intPtr(12000)}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := update(tt.transaction, tt.body); code != tt.expected {
+				t.Errorf("Expected status %d, got %d", tt.expected, code)
+			}
+		})
+	}
+}
+
+func TestOutstandingReimbursements(t *testing.T) {
+	db := setupTestDB(t)
+	_, budget := createTestUser(t, db, "test@example.com")
+
+	expenses := []models.Transaction{
+		{ID: uuid.New(), BudgetID: budget.ID, Amount: -12000, Currency: "USD", Date: testDate("2025-01-05"), IsReimbursable: true, ReimbursementPayer: "Acme Corp"},
+		{ID: uuid.New(), BudgetID: budget.ID, Amount: -4000, Currency: "USD", Date: testDate("2025-03-01"), IsReimbursable: true, ReimbursementPayer: "Mom"},
+		{ID: uuid.New(), BudgetID: budget.ID, Amount: -6000, Currency: "USD", Date: testDate("2025-03-20"), IsReimbursable: true, ReimbursementPayer: "Acme Corp"},
+		{ID: uuid.New(), BudgetID: budget.ID, Amount: -1000, Currency: "USD", Date: testDate("2025-03-25"), IsReimbursable: true, ReimbursementPayer: "Mom"},
+	}
+	// The last one has been paid back in full
+	db.Create(&models.Reimbursement{BudgetID: budget.ID, ExpenseTransactionID: expenses[3].ID, ReimbursementTransactionID: uuid.New(), Amount: 1000})
+
+	response, err := outstandingReimbursements(db, expenses, "USD", testDate("2025-04-01"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(response.Items) != 3 || response.TotalOutstanding != 22000 {
+		t.Fatalf("Expected 3 outstanding totalling 22000, got %d totalling %d", len(response.Items), response.TotalOutstanding)
+	}
+	if item := response.Items[0]; item.AgeDays != 86 || item.AgingBucket != "61-90" {
+		t.Errorf("Expected the January expense 86 days old, got %+v", item)
+	}
+	if aging := response.Aging; aging[0].Outstanding != 6000 || aging[1].Outstanding != 4000 || aging[2].Outstanding != 12000 || aging[3].Count != 0 {
+		t.Errorf("Unexpected aging totals: %+v", aging)
+	}
+	if payer := response.ByPayer[0]; payer.Name != "Acme Corp" || payer.Outstanding != 18000 || payer.Count != 2 {
+		t.Errorf("Expected Acme Corp to owe the most, got %+v", payer)
+	}
+}
@@ -1,10 +1,14 @@
 package handlers
 
 import (
+	"errors"
 	"math"
 	"net/http"
+	"sort"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/yourusername/folda-finances/internal/currency"
 	"github.com/yourusername/folda-finances/internal/middleware"
 	"github.com/yourusername/folda-finances/internal/models"
 	"gorm.io/gorm"
@@ -30,20 +34,73 @@ type CategorySpending struct {
 	CategoryName   string  `json:"category_name"`
 	CategoryIcon   string  `json:"category_icon"`
 	CategoryColor  string  `json:"category_color"`
+	ParentID       string  `json:"parent_id,omitempty"` // group the category is in
 	Budgeted       int     `json:"budgeted"`
 	Spent          int     `json:"spent"`
 	Available      int     `json:"available"`
 	PercentageUsed float64 `json:"percentage_used"`
 	Status         string  `json:"status"`
+	IsFixedExpense bool    `json:"is_fixed_expense"`
 	IsSplit        bool    `json:"is_split"`
 	MyAllocation   *int    `json:"my_allocation,omitempty"`
 	MyAvailable    *int    `json:"my_available,omitempty"`
+
+	// Pace forecast for the rest of the period
+	ExpectedToDate   int    `json:"expected_to_date"`
+	ProjectedSpend   int    `json:"projected_spend"`
+	DailySafeToSpend int    `json:"daily_safe_to_spend"`
+	PaceStatus       string `json:"pace_status"`
+	PaceBasis        string `json:"pace_basis"` // linear or historical
+
+	SinkingFund *SinkingFundStatus `json:"sinking_fund,omitempty"`
+
+	// Spending in other currencies, included in Spent after conversion
+	ForeignSpending []CurrencyAmount `json:"foreign_spending,omitempty"`
+}
+
+// CurrencyAmount is spending in a currency other than the budget's base
+// currency, with its value in the base currency at the transaction dates
+type CurrencyAmount struct {
+	Currency  string `json:"currency"`
+	Amount    int    `json:"amount"`
+	Converted int    `json:"converted"`
+}
+
+// SinkingFundStatus reports the accumulated balance of a sinking fund. Its
+// category's budgeted amount is the contribution and available is the balance.
+type SinkingFundStatus struct {
+	Balance             int     `json:"balance"`
+	TargetAmount        int     `json:"target_amount"`
+	TargetDate          string  `json:"target_date"`
+	MonthlyContribution int     `json:"monthly_contribution"`
+	PercentageFunded    float64 `json:"percentage_funded"`
+}
+
+// CategoryGroupSpending rolls a category group up. A group budgeted as a
+// whole reports its own budget, which covers its subcategories' spending;
+// otherwise budgeted and available add up the subcategories' budgets.
+type CategoryGroupSpending struct {
+	CategoryID     string   `json:"category_id"`
+	CategoryName   string   `json:"category_name"`
+	CategoryIcon   string   `json:"category_icon"`
+	CategoryColor  string   `json:"category_color"`
+	BudgetedAt     string   `json:"budgeted_at"` // group or subcategories
+	Budgeted       int      `json:"budgeted"`
+	Spent          int      `json:"spent"`
+	Available      int      `json:"available"`
+	PercentageUsed float64  `json:"percentage_used"`
+	Status         string   `json:"status"`
+	Subcategories  []string `json:"subcategories"` // budgeted subcategories listed in categories
 }
 
 type SpendingAvailableResponse struct {
-	Period   SpendingPeriod     `json:"period"`
-	Summary  SpendingSummary    `json:"summary"`
-	Categories []CategorySpending `json:"categories"`
+	Period       SpendingPeriod          `json:"period"`
+	BaseCurrency string                  `json:"base_currency"`
+	Summary      SpendingSummary         `json:"summary"`
+	Categories   []CategorySpending      `json:"categories"`
+	Groups       []CategoryGroupSpending `json:"groups,omitempty"`
+
+	UpcomingCardPayments []CardPayment `json:"upcoming_card_payments,omitempty"`
 }
 
 type SpendingSummary struct {
@@ -60,8 +117,8 @@ func (h *SpendingHandler) GetSpendingAvailable(w http.ResponseWriter, r *http.Re
 	}
 
 	// Get user with budget
-	var user models.User
-	if err := h.db.First(&user, "id = ?", userID).Error; err != nil {
+	user, err := requestUser(h.db, r, userID)
+	if err != nil {
 		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch user"})
 		return
 	}
@@ -70,7 +127,7 @@ func (h *SpendingHandler) GetSpendingAvailable(w http.ResponseWriter, r *http.Re
 		respondJSON(w, http.StatusOK, map[string]interface{}{
 			"data": SpendingAvailableResponse{
 				Period: SpendingPeriod{
-					Type:          user.ViewPeriod,
+					Type:          effectiveViewPeriod(user, models.Budget{}),
 					StartDate:     time.Now().Format("2006-01-02"),
 					EndDate:       time.Now().AddDate(0, 1, 0).Format("2006-01-02"),
 					DaysRemaining: 30,
@@ -86,12 +143,30 @@ func (h *SpendingHandler) GetSpendingAvailable(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	// Calculate current period
-	startDate := time.Now()
+	var budget models.Budget
+	if err := h.db.First(&budget, "id = ?", user.BudgetID).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch budget"})
+		return
+	}
+
+	// Calculate the current period, or the one containing ?date= for
+	// historical views
+	now := time.Now()
+	reference := now
+	if dateParam := r.URL.Query().Get("date"); dateParam != "" {
+		reference, err = time.Parse("2006-01-02", dateParam)
+		if err != nil {
+			respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid date format"})
+			return
+		}
+	}
+	startDate := weekStart(now, budget.WeekStartDay)
 	if user.PeriodStartDate != nil {
 		startDate = *user.PeriodStartDate
 	}
-	period := calculatePeriod(user.ViewPeriod, startDate)
+	viewPeriod := effectiveViewPeriod(user, budget)
+	period := calculatePeriod(viewPeriod, startDate, reference)
+	progress := newPeriodProgress(period, now)
 
 	// Get category budgets for this budget
 	var categoryBudgets []models.CategoryBudget
@@ -100,11 +175,39 @@ func (h *SpendingHandler) GetSpendingAvailable(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	// Get the amount history so past periods use the amounts in force then
+	budgetAmounts, err := categoryBudgetAmounts(h.db, categoryBudgets)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch budget amounts"})
+		return
+	}
+
+	// Get category groups; a group's budget covers its subcategories
+	parents, err := categoryParents(h.db, *user.BudgetID)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch category groups"})
+		return
+	}
+	groupMembers := make(map[uuid.UUID]map[uuid.UUID]bool)
+	for childID, parentID := range parents {
+		if groupMembers[parentID] == nil {
+			groupMembers[parentID] = map[uuid.UUID]bool{parentID: true}
+		}
+		groupMembers[parentID][childID] = true
+	}
+	budgetedCategories := make(map[uuid.UUID]bool)
+	for _, cb := range categoryBudgets {
+		budgetedCategories[cb.CategoryID] = true
+	}
+
 	// Get categories
 	var categories []models.Category
-	categoryIDs := make([]string, len(categoryBudgets))
-	for i, cb := range categoryBudgets {
-		categoryIDs[i] = cb.CategoryID.String()
+	categoryIDs := make([]string, 0, len(categoryBudgets)+len(groupMembers))
+	for _, cb := range categoryBudgets {
+		categoryIDs = append(categoryIDs, cb.CategoryID.String())
+	}
+	for groupID := range groupMembers {
+		categoryIDs = append(categoryIDs, groupID.String())
 	}
 	if err := h.db.Where("id IN ?", categoryIDs).Find(&categories).Error; err != nil {
 		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch categories"})
@@ -125,27 +228,136 @@ func (h *SpendingHandler) GetSpendingAvailable(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	// Get transactions from previous periods to build per-category spending curves
+	history := previousPeriods(viewPeriod, progress.Start, paceHistoryPeriods)
+	var historyTransactions []models.Transaction
+	if err := h.db.Where("budget_id = ? AND date >= ? AND date < ? AND amount < 0",
+		user.BudgetID, history[len(history)-1].Start.Format("2006-01-02"), period.StartDate).
+		Find(&historyTransactions).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch spending history"})
+		return
+	}
+
+	// Everything below is summed in the budget's base currency
+	converter := currency.NewConverter(h.db)
+	originalAmounts, err := convertTransactions(converter, transactions, budget.BaseCurrency)
+	if err != nil {
+		respondConversionError(w, err)
+		return
+	}
+	if _, err := convertTransactions(converter, historyTransactions, budget.BaseCurrency); err != nil {
+		respondConversionError(w, err)
+		return
+	}
+
+	// Reimbursed expenses were not really ours to spend
+	reimbursable, err := reimbursableAdjustments(h.db, transactions, originalAmounts, budget.ExcludePendingReimbursements)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch reimbursements"})
+		return
+	}
+
+	// Refunds net against their category's spending rather than count as income
+	refunds, laterRefunds, err := periodRefunds(h.db, budget, transactions, progress.End)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch refunds"})
+		return
+	}
+	laterOriginals, err := convertTransactions(converter, laterRefunds, budget.BaseCurrency)
+	if err != nil {
+		respondConversionError(w, err)
+		return
+	}
+	for id, amount := range laterOriginals {
+		originalAmounts[id] = amount
+	}
+	refunds = append(refunds, laterRefunds...)
+
+	// spentIn totals the period's spending across a set of categories
+	spentIn := func(categoryIDs map[uuid.UUID]bool) (int, []CurrencyAmount) {
+		spent := 0
+		var foreignSpending []CurrencyAmount
+		for _, tx := range transactions {
+			if categoryIDs[tx.CategoryID] && tx.Amount < 0 {
+				spent += int(math.Abs(float64(tx.Amount))) - reimbursable[tx.ID]
+				if original, ok := originalAmounts[tx.ID]; ok {
+					foreignSpending = addCurrencyAmount(foreignSpending, tx.Currency, -original, -tx.Amount)
+				}
+			}
+		}
+		for _, tx := range refunds {
+			if categoryIDs[tx.CategoryID] {
+				spent -= tx.Amount
+				if original, ok := originalAmounts[tx.ID]; ok {
+					foreignSpending = addCurrencyAmount(foreignSpending, tx.Currency, -original, -tx.Amount)
+				}
+			}
+		}
+		return spent, foreignSpending
+	}
+
+	// Sinking funds accumulate from the month they were created, so load the
+	// spending of them all since the earliest, by category
+	fundTransactions := make(map[uuid.UUID][]models.Transaction)
+	var fundCategoryIDs []uuid.UUID
+	var fundsStart time.Time
+	for _, categoryBudget := range categoryBudgets {
+		if !isSinkingFund(categoryBudget) {
+			continue
+		}
+		members := groupMembers[categoryBudget.CategoryID]
+		if members == nil {
+			members = map[uuid.UUID]bool{categoryBudget.CategoryID: true}
+		}
+		for id := range members {
+			fundCategoryIDs = append(fundCategoryIDs, id)
+		}
+		if start := monthStart(categoryBudget.CreatedAt); fundsStart.IsZero() || start.Before(fundsStart) {
+			fundsStart = start
+		}
+	}
+	if len(fundCategoryIDs) > 0 {
+		var transactions []models.Transaction
+		if err := h.db.Where("budget_id = ? AND category_id IN ? AND date >= ? AND date <= ? AND (amount < 0 OR is_refund = ?)",
+			user.BudgetID, fundCategoryIDs, fundsStart.Format("2006-01-02"), period.EndDate, true).
+			Find(&transactions).Error; err != nil {
+			respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch sinking fund transactions"})
+			return
+		}
+		if _, err := convertTransactions(converter, transactions, budget.BaseCurrency); err != nil {
+			respondConversionError(w, err)
+			return
+		}
+		for _, tx := range transactions {
+			fundTransactions[tx.CategoryID] = append(fundTransactions[tx.CategoryID], tx)
+		}
+	}
+
 	// Calculate spending per category
 	categorySpendingList := []CategorySpending{}
 	totalBudgeted := 0
 	totalSpent := 0
+	totalAvailable := 0
 
 	for _, categoryBudget := range categoryBudgets {
+		// Income and transfers are never spending, even if budgeted before
+		// categories had kinds
 		category, ok := categoryMap[categoryBudget.CategoryID.String()]
-		if !ok {
+		if !ok || category.Kind == categoryKindIncome || category.Kind == categoryKindTransfer {
 			continue
 		}
 
 		// Pro-rate monthly budget to view period
-		proratedBudget := prorateBudget(categoryBudget.Amount, user.ViewPeriod)
-
-		// Calculate spent in this period for this category
-		spent := 0
-		for _, tx := range transactions {
-			if tx.CategoryID == categoryBudget.CategoryID && tx.Amount < 0 {
-				spent += int(math.Abs(float64(tx.Amount)))
-			}
+		monthlyAmount := amountForMonth(categoryBudget, budgetAmounts[categoryBudget.ID], progress.Start)
+		proratedBudget := prorateBudget(monthlyAmount, viewPeriod)
+
+		// Calculate spent in this period for this category, including its
+		// subcategories when it is a group
+		members := groupMembers[categoryBudget.CategoryID]
+		if members == nil {
+			members = map[uuid.UUID]bool{categoryBudget.CategoryID: true}
 		}
+		spent, foreignSpending := spentIn(members)
 
 		available := proratedBudget - spent
 		percentageUsed := 0.0
@@ -153,56 +365,233 @@ func (h *SpendingHandler) GetSpendingAvailable(w http.ResponseWriter, r *http.Re
 			percentageUsed = (float64(spent) / float64(proratedBudget)) * 100
 		}
 
-		status := getStatus(percentageUsed)
+		status := getStatus(percentageUsed, categoryThresholds(budget, categoryBudget))
+
+		var categoryHistory []models.Transaction
+		for _, tx := range historyTransactions {
+			if members[tx.CategoryID] {
+				categoryHistory = append(categoryHistory, tx)
+			}
+		}
+		forecast := forecastSpending(proratedBudget, spent, progress, history, categoryHistory)
+		paceStatus := getPaceStatus(proratedBudget, spent, forecast.ProjectedSpend, categoryBudget.IsFixedExpense)
+
+		// Sinking funds draw spending from their accumulated balance, so they
+		// are only over budget once the fund is overdrawn
+		var sinkingFund *SinkingFundStatus
+		if isSinkingFund(categoryBudget) {
+			// Months before the fund was created are not replayed
+			var memberTransactions []models.Transaction
+			for id := range members {
+				memberTransactions = append(memberTransactions, fundTransactions[id]...)
+			}
+
+			fund := simulateSinkingFund(categoryBudget, memberTransactions, progress.End)
+			sinkingFund = &fund
+			proratedBudget = prorateBudget(fund.MonthlyContribution, viewPeriod)
+			available = fund.Balance
+			percentageUsed = 0
+			if fund.Balance+spent > 0 {
+				percentageUsed = (float64(spent) / float64(fund.Balance+spent)) * 100
+			}
+			status = "on_track"
+			if fund.Balance < 0 {
+				status = "over_budget"
+			}
+			paceStatus = status
+		}
 
 		categorySpendingList = append(categorySpendingList, CategorySpending{
 			CategoryID:     category.ID.String(),
 			CategoryName:   category.Name,
 			CategoryIcon:   category.Icon,
 			CategoryColor:  category.Color,
+			ParentID:       parentIDString(parents, category.ID),
 			Budgeted:       proratedBudget,
 			Spent:          spent,
 			Available:      available,
 			PercentageUsed: percentageUsed,
 			Status:         status,
+			IsFixedExpense: categoryBudget.IsFixedExpense,
 			IsSplit:        categoryBudget.AllocationType != "pooled",
+
+			ExpectedToDate:   forecast.ExpectedToDate,
+			ProjectedSpend:   forecast.ProjectedSpend,
+			DailySafeToSpend: forecast.DailySafeToSpend,
+			PaceStatus:       paceStatus,
+			PaceBasis:        forecast.Basis,
+
+			SinkingFund:     sinkingFund,
+			ForeignSpending: foreignSpending,
 		})
 
+		// A subcategory of a budgeted group is part of the group's budget
+		if parentID, ok := parents[categoryBudget.CategoryID]; ok && budgetedCategories[parentID] {
+			continue
+		}
 		totalBudgeted += proratedBudget
 		totalSpent += spent
+		totalAvailable += available
 	}
 
-	totalAvailable := totalBudgeted - totalSpent
+	// Roll subcategories up to their groups
+	var groupSpendingList []CategoryGroupSpending
+	for groupID, members := range groupMembers {
+		group, ok := categoryMap[groupID.String()]
+		if !ok || group.Kind == categoryKindIncome || group.Kind == categoryKindTransfer {
+			continue
+		}
+		groupSpending := CategoryGroupSpending{
+			CategoryID:    group.ID.String(),
+			CategoryName:  group.Name,
+			CategoryIcon:  group.Icon,
+			CategoryColor: group.Color,
+			BudgetedAt:    "subcategories",
+			Subcategories: []string{},
+		}
+		budgetedSpent := 0
+		for _, categorySpending := range categorySpendingList {
+			switch {
+			case categorySpending.CategoryID == groupSpending.CategoryID:
+				groupSpending.BudgetedAt = "group"
+				groupSpending.Budgeted = categorySpending.Budgeted
+				groupSpending.Spent = categorySpending.Spent
+				groupSpending.Available = categorySpending.Available
+				groupSpending.PercentageUsed = categorySpending.PercentageUsed
+				groupSpending.Status = categorySpending.Status
+			case categorySpending.ParentID == groupSpending.CategoryID:
+				groupSpending.Subcategories = append(groupSpending.Subcategories, categorySpending.CategoryID)
+				if groupSpending.BudgetedAt == "subcategories" {
+					groupSpending.Budgeted += categorySpending.Budgeted
+					groupSpending.Available += categorySpending.Available
+					budgetedSpent += categorySpending.Spent
+				}
+			}
+		}
+		if groupSpending.BudgetedAt == "subcategories" {
+			if len(groupSpending.Subcategories) == 0 {
+				continue
+			}
+			// Spending in the group itself or unbudgeted subcategories
+			// comes out of what the budgeted subcategories have left
+			groupSpending.Spent, _ = spentIn(members)
+			groupSpending.Available -= groupSpending.Spent - budgetedSpent
+			if groupSpending.Budgeted > 0 {
+				groupSpending.PercentageUsed = (float64(groupSpending.Spent) / float64(groupSpending.Budgeted)) * 100
+			}
+			groupSpending.Status = getStatus(groupSpending.PercentageUsed, categoryThresholds(budget, models.CategoryBudget{}))
+		}
+		groupSpendingList = append(groupSpendingList, groupSpending)
+	}
+	sort.Slice(groupSpendingList, func(i, j int) bool {
+		return groupSpendingList[i].CategoryName < groupSpendingList[j].CategoryName
+	})
 
 	response := SpendingAvailableResponse{
-		Period: period,
+		Period:       period,
+		BaseCurrency: budget.BaseCurrency,
 		Summary: SpendingSummary{
 			TotalAvailable: totalAvailable,
 			TotalBudgeted:  totalBudgeted,
 			TotalSpent:     totalSpent,
 		},
 		Categories: categorySpendingList,
+		Groups:     groupSpendingList,
+	}
+
+	cardPayments, err := upcomingCardPayments(h.db, budget.ID, time.Now())
+	if errors.Is(err, currency.ErrNoRate) {
+		respondConversionError(w, err)
+		return
+	}
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to calculate card payments"})
+		return
 	}
+	response.UpcomingCardPayments = cardPayments
 
 	respondJSON(w, http.StatusOK, map[string]interface{}{"data": response})
 }
 
-func calculatePeriod(viewPeriod string, startDate time.Time) SpendingPeriod {
-	now := time.Now()
+// parentIDString returns the ID of the group a category is in, if any
+func parentIDString(parents map[uuid.UUID]uuid.UUID, categoryID uuid.UUID) string {
+	if parentID, ok := parents[categoryID]; ok {
+		return parentID.String()
+	}
+	return ""
+}
+
+// convertTransactions converts amounts to the base currency at each
+// transaction's date, in place, and returns the original amounts of those that
+// were in another currency by transaction ID
+func convertTransactions(converter *currency.Converter, transactions []models.Transaction, base string) (map[uuid.UUID]int, error) {
+	originals := make(map[uuid.UUID]int)
+	for i, tx := range transactions {
+		if tx.Currency == "" || tx.Currency == base {
+			continue
+		}
+		converted, err := converter.Convert(tx.Amount, tx.Currency, base, tx.Date)
+		if err != nil {
+			return nil, err
+		}
+		originals[tx.ID] = tx.Amount
+		transactions[i].Amount = converted
+	}
+	return originals, nil
+}
+
+// addCurrencyAmount adds spending to its currency's running total
+func addCurrencyAmount(amounts []CurrencyAmount, code string, amount, converted int) []CurrencyAmount {
+	for i := range amounts {
+		if amounts[i].Currency == code {
+			amounts[i].Amount += amount
+			amounts[i].Converted += converted
+			return amounts
+		}
+	}
+	amounts = append(amounts, CurrencyAmount{Currency: code, Amount: amount, Converted: converted})
+	sort.Slice(amounts, func(i, j int) bool { return amounts[i].Currency < amounts[j].Currency })
+	return amounts
+}
+
+// respondConversionError reports a missing exchange rate as unprocessable and
+// anything else as a server error
+func respondConversionError(w http.ResponseWriter, err error) {
+	if errors.Is(err, currency.ErrNoRate) {
+		respondJSON(w, http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+		return
+	}
+	respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to convert currencies"})
+}
+
+// effectiveViewPeriod is the user's own view period, or their budget's default
+// when they have none
+func effectiveViewPeriod(user models.User, budget models.Budget) string {
+	if user.ViewPeriod != "" {
+		return user.ViewPeriod
+	}
+	if budget.DefaultViewPeriod != "" {
+		return budget.DefaultViewPeriod
+	}
+	return "monthly"
+}
+
+// calculatePeriod returns the view period containing now
+func calculatePeriod(viewPeriod string, startDate time.Time, now time.Time) SpendingPeriod {
 	var periodStart, periodEnd time.Time
 
 	switch viewPeriod {
 	case "weekly":
 		// Find most recent start day
-		daysSinceStart := int(now.Sub(startDate).Hours() / 24)
-		weeksPassed := daysSinceStart / 7
+		daysSinceStart := now.Sub(startDate).Hours() / 24
+		weeksPassed := int(math.Floor(daysSinceStart / 7))
 		periodStart = startDate.AddDate(0, 0, weeksPassed*7)
 		periodEnd = periodStart.AddDate(0, 0, 7).Add(-time.Second)
 
 	case "biweekly":
 		// Find most recent biweekly boundary
-		daysSinceStart := int(now.Sub(startDate).Hours() / 24)
-		periodsPassed := daysSinceStart / 14
+		daysSinceStart := now.Sub(startDate).Hours() / 24
+		periodsPassed := int(math.Floor(daysSinceStart / 14))
 		periodStart = startDate.AddDate(0, 0, periodsPassed*14)
 		periodEnd = periodStart.AddDate(0, 0, 14).Add(-time.Second)
 
@@ -230,6 +619,13 @@ func calculatePeriod(viewPeriod string, startDate time.Time) SpendingPeriod {
 	}
 }
 
+// weekStart returns the start of the week containing t, for weeks starting on
+// startDay (0 Sunday to 6 Saturday)
+func weekStart(t time.Time, startDay int) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return day.AddDate(0, 0, -((int(day.Weekday()) - startDay + 7) % 7))
+}
+
 func prorateBudget(monthlyAmount int, viewPeriod string) int {
 	// Calculate how many periods fit in a month and divide budget accordingly
 	switch viewPeriod {
@@ -246,12 +642,258 @@ func prorateBudget(monthlyAmount int, viewPeriod string) int {
 	}
 }
 
-func getStatus(percentageUsed float64) string {
-	if percentageUsed > 100 {
+const (
+	defaultWarningThreshold    = 75.0
+	defaultOverBudgetThreshold = 100.0
+)
+
+// statusThresholds are the percentage-used levels that drive getStatus
+type statusThresholds struct {
+	Warning    float64
+	OverBudget float64
+}
+
+// categoryThresholds resolves a category's thresholds, preferring its own
+// overrides over the budget-wide settings
+func categoryThresholds(budget models.Budget, categoryBudget models.CategoryBudget) statusThresholds {
+	thresholds := statusThresholds{
+		Warning:    budget.WarningThreshold,
+		OverBudget: budget.OverBudgetThreshold,
+	}
+	if thresholds.Warning <= 0 {
+		thresholds.Warning = defaultWarningThreshold
+	}
+	if thresholds.OverBudget <= 0 {
+		thresholds.OverBudget = defaultOverBudgetThreshold
+	}
+	if categoryBudget.WarningThreshold != nil {
+		thresholds.Warning = *categoryBudget.WarningThreshold
+	}
+	if categoryBudget.OverBudgetThreshold != nil {
+		thresholds.OverBudget = *categoryBudget.OverBudgetThreshold
+	}
+	return thresholds
+}
+
+// getStatus classifies percentage used against the thresholds. A warning
+// threshold at or above the over-budget threshold disables warnings, which
+// suits categories like rent that are fully spent on day one.
+func getStatus(percentageUsed float64, thresholds statusThresholds) string {
+	if percentageUsed > thresholds.OverBudget {
 		return "over_budget"
 	}
-	if percentageUsed >= 75 {
+	if thresholds.Warning < thresholds.OverBudget && percentageUsed >= thresholds.Warning {
 		return "warning"
 	}
 	return "on_track"
 }
+
+// paceHistoryPeriods is how many previous periods feed a category's spending curve
+const paceHistoryPeriods = 3
+
+// dateRange is an inclusive range of calendar days
+type dateRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+func (d dateRange) days() int {
+	return int(d.End.Sub(d.Start).Hours()/24) + 1
+}
+
+// periodProgress describes how far into the current period today is
+type periodProgress struct {
+	dateRange
+	ElapsedDays int // including today
+	TotalDays   int
+}
+
+func newPeriodProgress(period SpendingPeriod, now time.Time) periodProgress {
+	start, _ := time.Parse("2006-01-02", period.StartDate)
+	end, _ := time.Parse("2006-01-02", period.EndDate)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	progress := periodProgress{dateRange: dateRange{Start: start, End: end}}
+	progress.TotalDays = progress.days()
+	progress.ElapsedDays = int(today.Sub(start).Hours()/24) + 1
+	if progress.ElapsedDays < 1 {
+		progress.ElapsedDays = 1
+	}
+	if progress.ElapsedDays > progress.TotalDays {
+		progress.ElapsedDays = progress.TotalDays
+	}
+	return progress
+}
+
+// fraction returns the share of the period that has elapsed, including today
+func (p periodProgress) fraction() float64 {
+	if p.TotalDays <= 0 {
+		return 1
+	}
+	return float64(p.ElapsedDays) / float64(p.TotalDays)
+}
+
+// previousPeriods returns the n periods immediately before the one starting at
+// start, most recent first
+func previousPeriods(viewPeriod string, start time.Time, n int) []dateRange {
+	periods := make([]dateRange, 0, n)
+	for i := 1; i <= n; i++ {
+		var periodStart, periodEnd time.Time
+		switch viewPeriod {
+		case "weekly":
+			periodStart = start.AddDate(0, 0, -7*i)
+			periodEnd = periodStart.AddDate(0, 0, 6)
+		case "biweekly":
+			periodStart = start.AddDate(0, 0, -14*i)
+			periodEnd = periodStart.AddDate(0, 0, 13)
+		default:
+			periodStart = start.AddDate(0, -i, 0)
+			periodEnd = periodStart.AddDate(0, 1, -1)
+		}
+		periods = append(periods, dateRange{Start: periodStart, End: periodEnd})
+	}
+	return periods
+}
+
+// spendingCurve returns the share of a period's spending that has historically
+// happened by the given fraction of the period. It reports false when there is
+// no history to learn from.
+func spendingCurve(history []dateRange, transactions []models.Transaction, elapsed float64) (float64, bool) {
+	total := 0
+	byNow := 0
+	for _, period := range history {
+		periodDays := float64(period.days())
+		for _, tx := range transactions {
+			if tx.Amount >= 0 || tx.Date.Before(period.Start) || tx.Date.After(period.End) {
+				continue
+			}
+			amount := -tx.Amount
+			total += amount
+			day := float64(int(tx.Date.Sub(period.Start).Hours()/24) + 1)
+			if day/periodDays <= elapsed {
+				byNow += amount
+			}
+		}
+	}
+	if total == 0 {
+		return elapsed, false
+	}
+	return float64(byNow) / float64(total), true
+}
+
+type spendingForecast struct {
+	ExpectedToDate   int
+	ProjectedSpend   int
+	DailySafeToSpend int
+	Basis            string
+}
+
+// forecastSpending projects a category's end-of-period spend from its pace so
+// far. Categories with spending history follow their own curve (rent lands on
+// day one, groceries trickle in); the rest assume linear spending.
+func forecastSpending(budgeted, spent int, progress periodProgress, history []dateRange, transactions []models.Transaction) spendingForecast {
+	basis := "linear"
+	expectedShare, ok := spendingCurve(history, transactions, progress.fraction())
+	if ok {
+		basis = "historical"
+	}
+
+	projected := spent + budgeted
+	if expectedShare > 0 {
+		projected = int(math.Round(float64(spent) / expectedShare))
+	}
+	if projected < spent {
+		projected = spent
+	}
+
+	dailySafeToSpend := 0
+	daysLeft := progress.TotalDays - progress.ElapsedDays + 1
+	if available := budgeted - spent; available > 0 && daysLeft > 0 {
+		dailySafeToSpend = available / daysLeft
+	}
+
+	return spendingForecast{
+		ExpectedToDate:   int(math.Round(float64(budgeted) * expectedShare)),
+		ProjectedSpend:   projected,
+		DailySafeToSpend: dailySafeToSpend,
+		Basis:            basis,
+	}
+}
+
+// getPaceStatus warns as soon as the projected end-of-period spend exceeds the
+// budget, rather than waiting for the warning threshold used by getStatus.
+// Fixed expenses never get pace warnings.
+func getPaceStatus(budgeted, spent, projected int, isFixedExpense bool) string {
+	if spent > budgeted {
+		return "over_budget"
+	}
+	if projected > budgeted && !isFixedExpense {
+		return "warning"
+	}
+	return "on_track"
+}
+
+func isSinkingFund(categoryBudget models.CategoryBudget) bool {
+	return categoryBudget.BudgetType == "sinking_fund" &&
+		categoryBudget.TargetAmount != nil && categoryBudget.TargetDate != nil
+}
+
+// monthsUntil counts the months from one month start to another, inclusive
+func monthsUntil(from, to time.Time) int {
+	return (to.Year()-from.Year())*12 + int(to.Month()) - int(from.Month()) + 1
+}
+
+// sinkingFundContribution spreads what is still missing from the target
+// evenly over the months left until the due date, including this one
+func sinkingFundContribution(target, balance int, month, due time.Time) int {
+	needed := target - balance
+	if needed <= 0 {
+		return 0
+	}
+	months := monthsUntil(month, monthStart(due))
+	if months < 1 {
+		months = 1
+	}
+	return (needed + months - 1) / months
+}
+
+// simulateSinkingFund replays a sinking fund month by month from the month it
+// was created through the month of through. Each month adds the contribution
+// needed to reach the target by the due date and subtracts that month's
+// spending net of refunds; once a due date has passed the next one is a year
+// later.
+func simulateSinkingFund(categoryBudget models.CategoryBudget, transactions []models.Transaction, through time.Time) SinkingFundStatus {
+	target := *categoryBudget.TargetAmount
+	due := *categoryBudget.TargetDate
+
+	spendingByMonth := make(map[time.Time]int)
+	for _, tx := range transactions {
+		if (tx.Amount < 0 || tx.IsRefund) && !tx.Date.After(through) {
+			spendingByMonth[monthStart(tx.Date)] += -tx.Amount
+		}
+	}
+
+	balance := 0
+	contribution := 0
+	last := monthStart(through)
+	for month := monthStart(categoryBudget.CreatedAt); !month.After(last); month = month.AddDate(0, 1, 0) {
+		for monthStart(due).Before(month) {
+			due = due.AddDate(1, 0, 0)
+		}
+		contribution = sinkingFundContribution(target, balance, month, due)
+		balance += contribution - spendingByMonth[month]
+	}
+
+	percentageFunded := 0.0
+	if target > 0 {
+		percentageFunded = (float64(balance) / float64(target)) * 100
+	}
+
+	return SinkingFundStatus{
+		Balance:             balance,
+		TargetAmount:        target,
+		TargetDate:          due.Format("2006-01-02"),
+		MonthlyContribution: contribution,
+		PercentageFunded:    percentageFunded,
+	}
+}
@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yourusername/folda-finances/internal/models"
+)
+
+func testDate(s string) time.Time {
+	d, _ := time.Parse("2006-01-02", s)
+	return d
+}
+
+func TestForecastSpending_Linear(t *testing.T) {
+	period := SpendingPeriod{Type: "monthly", StartDate: "2025-04-01", EndDate: "2025-04-30"}
+	progress := newPeriodProgress(period, testDate("2025-04-10"))
+
+	if progress.ElapsedDays != 10 || progress.TotalDays != 30 {
+		t.Fatalf("Expected 10 of 30 days elapsed, got %d of %d", progress.ElapsedDays, progress.TotalDays)
+	}
+
+	// $150 spent of $300 after a third of the month projects to $450
+	forecast := forecastSpending(30000, 15000, progress, previousPeriods("monthly", progress.Start, 3), nil)
+
+	if forecast.Basis != "linear" {
+		t.Errorf("Expected linear basis without history, got %s", forecast.Basis)
+	}
+	if forecast.ExpectedToDate != 10000 {
+		t.Errorf("Expected 10000 expected to date, got %d", forecast.ExpectedToDate)
+	}
+	if forecast.ProjectedSpend != 45000 {
+		t.Errorf("Expected projected spend 45000, got %d", forecast.ProjectedSpend)
+	}
+	// $150 left over the 21 days including today
+	if forecast.DailySafeToSpend != 714 {
+		t.Errorf("Expected daily safe-to-spend 714, got %d", forecast.DailySafeToSpend)
+	}
+
+	// Only 50% used, so getStatus is happy, but the pace already warns
+	if status := getStatus(50, statusThresholds{Warning: 75, OverBudget: 100}); status != "on_track" {
+		t.Errorf("Expected getStatus on_track, got %s", status)
+	}
+	if status := getPaceStatus(30000, 15000, forecast.ProjectedSpend, false); status != "warning" {
+		t.Errorf("Expected pace status warning, got %s", status)
+	}
+}
+
+func TestForecastSpending_HistoricalCurve(t *testing.T) {
+	period := SpendingPeriod{Type: "monthly", StartDate: "2025-04-01", EndDate: "2025-04-30"}
+	progress := newPeriodProgress(period, testDate("2025-04-05"))
+	history := previousPeriods("monthly", progress.Start, 3)
+
+	// Rent has landed on the first of every previous month
+	var transactions []models.Transaction
+	for _, period := range history {
+		transactions = append(transactions, models.Transaction{Amount: -150000, Date: period.Start})
+	}
+
+	forecast := forecastSpending(150000, 150000, progress, history, transactions)
+
+	if forecast.Basis != "historical" {
+		t.Errorf("Expected historical basis, got %s", forecast.Basis)
+	}
+	if forecast.ExpectedToDate != 150000 {
+		t.Errorf("Expected full budget expected to date, got %d", forecast.ExpectedToDate)
+	}
+	if forecast.ProjectedSpend != 150000 {
+		t.Errorf("Expected projected spend 150000, got %d", forecast.ProjectedSpend)
+	}
+	if status := getPaceStatus(150000, 150000, forecast.ProjectedSpend, false); status != "on_track" {
+		t.Errorf("Expected pace status on_track, got %s", status)
+	}
+}
+
+func TestPreviousPeriods(t *testing.T) {
+	periods := previousPeriods("monthly", testDate("2025-03-01"), 2)
+	if len(periods) != 2 {
+		t.Fatalf("Expected 2 periods, got %d", len(periods))
+	}
+	if !periods[0].Start.Equal(testDate("2025-02-01")) || !periods[0].End.Equal(testDate("2025-02-28")) {
+		t.Errorf("Unexpected first period: %v - %v", periods[0].Start, periods[0].End)
+	}
+	if !periods[1].Start.Equal(testDate("2025-01-01")) || !periods[1].End.Equal(testDate("2025-01-31")) {
+		t.Errorf("Unexpected second period: %v - %v", periods[1].Start, periods[1].End)
+	}
+
+	weekly := previousPeriods("weekly", testDate("2025-03-15"), 1)
+	if !weekly[0].Start.Equal(testDate("2025-03-08")) || !weekly[0].End.Equal(testDate("2025-03-14")) {
+		t.Errorf("Unexpected weekly period: %v - %v", weekly[0].Start, weekly[0].End)
+	}
+}
+
+func TestCategoryThresholds(t *testing.T) {
+	budget := models.Budget{WarningThreshold: 80, OverBudgetThreshold: 100}
+
+	// Budget-wide thresholds apply without overrides
+	thresholds := categoryThresholds(budget, models.CategoryBudget{})
+	if status := getStatus(78, thresholds); status != "on_track" {
+		t.Errorf("Expected on_track below the budget warning threshold, got %s", status)
+	}
+	if status := getStatus(85, thresholds); status != "warning" {
+		t.Errorf("Expected warning above the budget warning threshold, got %s", status)
+	}
+
+	// Rent never warns: its warning threshold equals the over-budget one
+	full := 100.0
+	rent := models.CategoryBudget{WarningThreshold: &full, IsFixedExpense: true}
+	if status := getStatus(100, categoryThresholds(budget, rent)); status != "on_track" {
+		t.Errorf("Expected fully spent rent to be on_track, got %s", status)
+	}
+	if status := getPaceStatus(150000, 150000, 300000, rent.IsFixedExpense); status != "on_track" {
+		t.Errorf("Expected fixed expense to skip pace warnings, got %s", status)
+	}
+
+	// Zero-valued budget settings fall back to the defaults
+	thresholds = categoryThresholds(models.Budget{}, models.CategoryBudget{})
+	if thresholds.Warning != 75 || thresholds.OverBudget != 100 {
+		t.Errorf("Expected default thresholds 75/100, got %v/%v", thresholds.Warning, thresholds.OverBudget)
+	}
+}
+
+func TestSimulateSinkingFund(t *testing.T) {
+	target := 120000
+	due := testDate("2025-06-15")
+	categoryBudget := models.CategoryBudget{
+		BudgetType:   "sinking_fund",
+		TargetAmount: &target,
+		TargetDate:   &due,
+		CreatedAt:    testDate("2025-01-10"),
+	}
+
+	// Six months to save $1200 is $200 a month
+	fund := simulateSinkingFund(categoryBudget, nil, testDate("2025-03-31"))
+	if fund.MonthlyContribution != 20000 {
+		t.Errorf("Expected contribution 20000, got %d", fund.MonthlyContribution)
+	}
+	if fund.Balance != 60000 {
+		t.Errorf("Expected balance 60000 after three months, got %d", fund.Balance)
+	}
+
+	// Paying the bill in June draws the fund down instead of going over budget,
+	// and the next year's saving starts in July
+	transactions := []models.Transaction{{Amount: -120000, Date: testDate("2025-06-15")}}
+	fund = simulateSinkingFund(categoryBudget, transactions, testDate("2025-07-31"))
+	if fund.Balance != 10000 {
+		t.Errorf("Expected balance 10000 after the bill and a new contribution, got %d", fund.Balance)
+	}
+	if fund.TargetDate != "2026-06-15" {
+		t.Errorf("Expected due date to roll to 2026-06-15, got %s", fund.TargetDate)
+	}
+	if fund.MonthlyContribution != 10000 {
+		t.Errorf("Expected contribution 10000 towards next year, got %d", fund.MonthlyContribution)
+	}
+}
+
+func TestGetSpendingAvailable_SinkingFunds(t *testing.T) {
+	db := setupTestDB(t)
+	handler := NewSpendingHandler(db)
+
+	user, budget := createTestUser(t, db, "test@example.com")
+	insurance := createTestCategory(t, db, budget.ID, "Car Insurance")
+	gifts := createTestCategory(t, db, budget.ID, "Gifts")
+
+	now := time.Now()
+	due := now.AddDate(0, 6, 0)
+	target := 120000
+	for _, category := range []models.Category{*insurance, *gifts} {
+		db.Create(&models.CategoryBudget{ID: uuid.New(), BudgetID: budget.ID, CategoryID: category.ID, AllocationType: "pooled",
+			BudgetType: "sinking_fund", TargetAmount: &target, TargetDate: &due})
+	}
+	db.Create(&models.Transaction{ID: uuid.New(), UserID: user.ID, BudgetID: budget.ID, CategoryID: gifts.ID, Amount: -5000, Currency: "USD", Date: now})
+
+	req := httptest.NewRequest("GET", "/spending/available", nil)
+	req = req.WithContext(setUserIDContext(req, user.ID))
+	w := httptest.NewRecorder()
+
+	handler.GetSpendingAvailable(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response struct {
+		Data SpendingAvailableResponse `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&response)
+
+	// Each fund only draws on its own category's spending
+	contribution := sinkingFundContribution(target, 0, monthStart(now), monthStart(due))
+	expected := map[string]int{insurance.ID.String(): contribution, gifts.ID.String(): contribution - 5000}
+	for _, category := range response.Data.Categories {
+		if category.SinkingFund == nil {
+			t.Fatalf("Expected %s to be a sinking fund", category.CategoryName)
+		}
+		if category.SinkingFund.Balance != expected[category.CategoryID] {
+			t.Errorf("Expected %s balance %d, got %d", category.CategoryName, expected[category.CategoryID], category.SinkingFund.Balance)
+		}
+	}
+	if len(response.Data.Categories) != 2 {
+		t.Errorf("Expected 2 categories, got %d", len(response.Data.Categories))
+	}
+}
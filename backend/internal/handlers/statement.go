@@ -0,0 +1,298 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yourusername/folda-finances/internal/currency"
+	"github.com/yourusername/folda-finances/internal/models"
+	"gorm.io/gorm"
+)
+
+// Minimum due when a card has no minimum_payment set: the greater of 2% of
+// the statement balance and $25, never more than the balance
+const (
+	defaultMinimumDuePercent = 2
+	defaultMinimumDueFloor   = 2500
+)
+
+var errNoStatementCycle = errors.New("statement_closing_day and payment_due_day are required")
+
+type StatementCycle struct {
+	StartDate        string `json:"start_date"`
+	ClosingDate      string `json:"closing_date"`
+	DueDate          string `json:"due_date"`
+	PreviousBalance  int    `json:"previous_balance"`
+	Charges          int    `json:"charges"` // purchases, fees and interest
+	Credits          int    `json:"credits"` // payments and refunds
+	StatementBalance int    `json:"statement_balance"`
+	MinimumDue       int    `json:"minimum_due"`
+}
+
+type StatementSummary struct {
+	AccountID        string          `json:"account_id"`
+	AccountName      string          `json:"account_name"`
+	ClosingDay       int             `json:"closing_day"`
+	PaymentDueDay    int             `json:"payment_due_day"`
+	LastStatement    *StatementCycle `json:"last_statement"` // null before the first cycle closes
+	PaidSinceClose   int             `json:"paid_since_close"`
+	RemainingDue     int             `json:"remaining_due"`     // statement balance not yet paid
+	RemainingMinimum int             `json:"remaining_minimum"` // minimum due not yet paid
+	DaysUntilDue     int             `json:"days_until_due"`
+	CurrentCycle     StatementCycle  `json:"current_cycle"` // open cycle, balance so far
+}
+
+// CardPayment is a credit card payment still owed on its last statement, shown
+// alongside spending so it can be planned for
+type CardPayment struct {
+	AccountID        string `json:"account_id"`
+	AccountName      string `json:"account_name"`
+	StatementBalance int    `json:"statement_balance"`
+	RemainingDue     int    `json:"remaining_due"`
+	MinimumDue       int    `json:"minimum_due"`
+	DueDate          string `json:"due_date"`
+	DaysUntilDue     int    `json:"days_until_due"`
+}
+
+// GetStatement returns a credit card's latest closed statement, what is still
+// due on it, and the open cycle's activity so far
+func (h *AccountHandler) GetStatement(w http.ResponseWriter, r *http.Request) {
+	account, _, ok := h.authorizedAccount(w, r)
+	if !ok {
+		return
+	}
+
+	transactions, ok := h.cardTransactions(w, account)
+	if !ok {
+		return
+	}
+
+	_, summary, err := calculateStatements(account, transactions, time.Now())
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"data": summary})
+}
+
+// ListStatements returns a credit card's closed statement cycles, newest first
+func (h *AccountHandler) ListStatements(w http.ResponseWriter, r *http.Request) {
+	account, _, ok := h.authorizedAccount(w, r)
+	if !ok {
+		return
+	}
+
+	transactions, ok := h.cardTransactions(w, account)
+	if !ok {
+		return
+	}
+
+	cycles, _, err := calculateStatements(account, transactions, time.Now())
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	statements := make([]StatementCycle, 0, len(cycles))
+	for i := len(cycles) - 1; i >= 0; i-- {
+		statements = append(statements, cycles[i])
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"data": statements})
+}
+
+func (h *AccountHandler) cardTransactions(w http.ResponseWriter, account models.Account) ([]models.Transaction, bool) {
+	if account.Type != "credit_card" {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "account is not a credit card"})
+		return nil, false
+	}
+
+	transactions, err := loadCardTransactions(h.db, account)
+	if errors.Is(err, currency.ErrNoRate) {
+		respondConversionError(w, err)
+		return nil, false
+	}
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch transactions"})
+		return nil, false
+	}
+	return transactions, true
+}
+
+// loadCardTransactions fetches a card's transactions oldest first, with
+// amounts in other currencies converted to the card's at their dates, the
+// way the issuer bills them
+func loadCardTransactions(db *gorm.DB, account models.Account) ([]models.Transaction, error) {
+	var transactions []models.Transaction
+	if err := db.Where("account_id = ?", account.ID).Order("date ASC").Find(&transactions).Error; err != nil {
+		return nil, err
+	}
+	if account.Currency != "" {
+		if _, err := convertTransactions(currency.NewConverter(db), transactions, account.Currency); err != nil {
+			return nil, err
+		}
+	}
+	return transactions, nil
+}
+
+// upcomingCardPayments lists unpaid statement balances on a budget's active
+// credit cards with statement cycles set up, soonest due first
+func upcomingCardPayments(db *gorm.DB, budgetID uuid.UUID, today time.Time) ([]CardPayment, error) {
+	var cards []models.Account
+	if err := db.Where("budget_id = ? AND type = ? AND is_active = ? AND statement_closing_day IS NOT NULL AND payment_due_day IS NOT NULL",
+		budgetID, "credit_card", true).Find(&cards).Error; err != nil {
+		return nil, err
+	}
+
+	payments := []CardPayment{}
+	for _, card := range cards {
+		transactions, err := loadCardTransactions(db, card)
+		if err != nil {
+			return nil, err
+		}
+		_, summary, err := calculateStatements(card, transactions, today)
+		if err != nil {
+			return nil, err
+		}
+		if summary.LastStatement == nil || summary.RemainingDue == 0 {
+			continue
+		}
+		payments = append(payments, CardPayment{
+			AccountID:        summary.AccountID,
+			AccountName:      summary.AccountName,
+			StatementBalance: summary.LastStatement.StatementBalance,
+			RemainingDue:     summary.RemainingDue,
+			MinimumDue:       summary.RemainingMinimum,
+			DueDate:          summary.LastStatement.DueDate,
+			DaysUntilDue:     summary.DaysUntilDue,
+		})
+	}
+
+	sort.Slice(payments, func(i, j int) bool { return payments[i].DueDate < payments[j].DueDate })
+	return payments, nil
+}
+
+// calculateStatements replays a card's posted transactions, oldest first, into
+// monthly cycles ending on the closing day. Negative amounts are charges and
+// positive ones credits; the first cycle opens with nothing owed. It returns
+// the closed cycles in date order and a summary as of today.
+func calculateStatements(account models.Account, transactions []models.Transaction, today time.Time) ([]StatementCycle, StatementSummary, error) {
+	if account.StatementClosingDay == nil || account.PaymentDueDay == nil {
+		return nil, StatementSummary{}, errNoStatementCycle
+	}
+	closingDay, dueDay := *account.StatementClosingDay, *account.PaymentDueDay
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+
+	// The latest closing date on or before today, and where cycles start
+	lastClose := dueDate(today, closingDay)
+	if lastClose.After(today) {
+		lastClose = dueDate(today.AddDate(0, 0, -today.Day()), closingDay)
+	}
+	closing := dueDate(monthStart(lastClose).AddDate(0, 1, 0), closingDay)
+	if len(transactions) > 0 {
+		closing = dueDate(transactions[0].Date, closingDay)
+		if closing.Before(transactions[0].Date) {
+			closing = dueDate(monthStart(transactions[0].Date).AddDate(0, 1, 0), closingDay)
+		}
+	}
+
+	cycles := []StatementCycle{}
+	balance := 0
+	next := 0
+	for !closing.After(lastClose) {
+		cycle, consumed := statementCycle(account, transactions[next:], closing, balance)
+		next += consumed
+		cycles = append(cycles, cycle)
+		balance = cycle.StatementBalance
+		closing = dueDate(monthStart(closing).AddDate(0, 1, 0), closingDay)
+	}
+
+	// Activity since the last close makes up the open cycle
+	current, _ := statementCycle(account, transactions[next:], closing, balance)
+	summary := StatementSummary{
+		AccountID:     account.ID.String(),
+		AccountName:   account.Name,
+		ClosingDay:    closingDay,
+		PaymentDueDay: dueDay,
+		CurrentCycle:  current,
+	}
+
+	if len(cycles) > 0 {
+		last := cycles[len(cycles)-1]
+		summary.LastStatement = &last
+		summary.PaidSinceClose = current.Credits
+		summary.RemainingDue = maxInt(last.StatementBalance-current.Credits, 0)
+		summary.RemainingMinimum = maxInt(last.MinimumDue-current.Credits, 0)
+		due, _ := time.Parse("2006-01-02", last.DueDate)
+		summary.DaysUntilDue = int(due.Sub(today).Hours() / 24)
+	}
+
+	return cycles, summary, nil
+}
+
+// statementCycle totals the date-ordered transactions up to closing and
+// reports how many it used
+func statementCycle(account models.Account, transactions []models.Transaction, closing time.Time, previousBalance int) (StatementCycle, int) {
+	previousClose := dueDate(monthStart(closing).AddDate(0, 0, -1), *account.StatementClosingDay)
+	cycle := StatementCycle{
+		StartDate:       previousClose.AddDate(0, 0, 1).Format("2006-01-02"),
+		ClosingDate:     closing.Format("2006-01-02"),
+		DueDate:         statementDueDate(closing, *account.PaymentDueDay).Format("2006-01-02"),
+		PreviousBalance: previousBalance,
+	}
+
+	consumed := 0
+	for _, tx := range transactions {
+		if tx.Date.After(closing) {
+			break
+		}
+		if tx.Amount < 0 {
+			cycle.Charges += -tx.Amount
+		} else {
+			cycle.Credits += tx.Amount
+		}
+		consumed++
+	}
+
+	cycle.StatementBalance = previousBalance + cycle.Charges - cycle.Credits
+	cycle.MinimumDue = minimumDue(account, cycle.StatementBalance)
+	return cycle, consumed
+}
+
+// statementDueDate is the first payment due day after a closing date
+func statementDueDate(closing time.Time, dueDay int) time.Time {
+	due := dueDate(closing, dueDay)
+	if !due.After(closing) {
+		due = dueDate(monthStart(closing).AddDate(0, 1, 0), dueDay)
+	}
+	return due
+}
+
+func minimumDue(account models.Account, statementBalance int) int {
+	if statementBalance <= 0 {
+		return 0
+	}
+	minimum := int(math.Round(float64(statementBalance) * defaultMinimumDuePercent / 100))
+	if minimum < defaultMinimumDueFloor {
+		minimum = defaultMinimumDueFloor
+	}
+	if account.MinimumPayment != nil {
+		minimum = *account.MinimumPayment
+	}
+	if minimum > statementBalance {
+		minimum = statementBalance
+	}
+	return minimum
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/yourusername/folda-finances/internal/currency"
+	"github.com/yourusername/folda-finances/internal/models"
+)
+
+func TestCalculateStatements(t *testing.T) {
+	closingDay, dueDay := 20, 15
+	card := models.Account{ID: uuid.New(), Name: "Rewards Card", Type: "credit_card", StatementClosingDay: &closingDay, PaymentDueDay: &dueDay}
+	transactions := []models.Transaction{
+		{Amount: -10000, Date: testDate("2025-01-05")},
+		{Amount: -50000, Date: testDate("2025-01-25")},
+		{Amount: 10000, Date: testDate("2025-02-10")}, // pays the January statement
+		{Amount: -3000, Date: testDate("2025-02-22")},
+	}
+
+	cycles, summary, err := calculateStatements(card, transactions, testDate("2025-03-01"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(cycles) != 2 {
+		t.Fatalf("Expected 2 closed cycles, got %d", len(cycles))
+	}
+	first, second := cycles[0], cycles[1]
+	if first.StartDate != "2024-12-21" || first.ClosingDate != "2025-01-20" || first.DueDate != "2025-02-15" {
+		t.Errorf("Unexpected first cycle dates: %+v", first)
+	}
+	if first.StatementBalance != 10000 || first.MinimumDue != 2500 {
+		t.Errorf("Expected balance 10000 with minimum 2500, got %+v", first)
+	}
+	if second.PreviousBalance != 10000 || second.Charges != 50000 || second.Credits != 10000 || second.StatementBalance != 50000 {
+		t.Errorf("Unexpected second cycle totals: %+v", second)
+	}
+
+	if summary.LastStatement == nil || summary.LastStatement.ClosingDate != "2025-02-20" {
+		t.Fatalf("Expected the February statement, got %+v", summary.LastStatement)
+	}
+	if summary.RemainingDue != 50000 || summary.RemainingMinimum != 2500 || summary.DaysUntilDue != 14 {
+		t.Errorf("Unexpected amounts due: %+v", summary)
+	}
+	if summary.CurrentCycle.StartDate != "2025-02-21" || summary.CurrentCycle.Charges != 3000 || summary.CurrentCycle.StatementBalance != 53000 {
+		t.Errorf("Unexpected open cycle: %+v", summary.CurrentCycle)
+	}
+
+	// Payments after the close count against what is due
+	transactions = append(transactions, models.Transaction{Amount: 20000, Date: testDate("2025-02-28")})
+	_, summary, _ = calculateStatements(card, transactions, testDate("2025-03-01"))
+	if summary.PaidSinceClose != 20000 || summary.RemainingDue != 30000 || summary.RemainingMinimum != 0 {
+		t.Errorf("Expected 30000 remaining with the minimum met, got %+v", summary)
+	}
+}
+
+func TestCalculateStatements_ShortMonth(t *testing.T) {
+	closingDay, dueDay, minimum := 31, 25, 4000
+	card := models.Account{ID: uuid.New(), Type: "credit_card", StatementClosingDay: &closingDay, PaymentDueDay: &dueDay, MinimumPayment: &minimum}
+	transactions := []models.Transaction{{Amount: -300000, Date: testDate("2025-02-03")}}
+
+	cycles, summary, err := calculateStatements(card, transactions, testDate("2025-03-05"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// A closing day past the end of the month closes on the last day
+	if len(cycles) != 1 || cycles[0].ClosingDate != "2025-02-28" || cycles[0].DueDate != "2025-03-25" {
+		t.Fatalf("Unexpected cycles: %+v", cycles)
+	}
+	if summary.RemainingMinimum != 4000 {
+		t.Errorf("Expected the card's minimum payment, got %d", summary.RemainingMinimum)
+	}
+
+	card.StatementClosingDay = nil
+	if _, _, err := calculateStatements(card, transactions, testDate("2025-03-05")); err == nil {
+		t.Error("Expected an error without a closing day")
+	}
+}
+
+func TestUpcomingCardPayments(t *testing.T) {
+	db := setupTestDB(t)
+	user, budget := createTestUser(t, db, "test@example.com")
+	category := createTestCategory(t, db, budget.ID, "Groceries")
+
+	closingDay, dueDay := 20, 15
+	card := &models.Account{ID: uuid.New(), BudgetID: budget.ID, Name: "Rewards Card", Type: "credit_card", IsActive: true, StatementClosingDay: &closingDay, PaymentDueDay: &dueDay}
+	paidOff := &models.Account{ID: uuid.New(), BudgetID: budget.ID, Name: "Store Card", Type: "credit_card", IsActive: true, StatementClosingDay: &closingDay, PaymentDueDay: &dueDay}
+	db.Create(card)
+	db.Create(paidOff)
+
+	for _, tx := range []models.Transaction{
+		{AccountID: &card.ID, Amount: -45000, Date: testDate("2025-02-02")},
+		{AccountID: &paidOff.ID, Amount: -8000, Date: testDate("2025-02-02")},
+		{AccountID: &paidOff.ID, Amount: 8000, Date: testDate("2025-02-25")},
+	} {
+		tx.ID = uuid.New()
+		tx.UserID = user.ID
+		tx.BudgetID = budget.ID
+		tx.CategoryID = category.ID
+		db.Create(&tx)
+	}
+
+	payments, err := upcomingCardPayments(db, budget.ID, testDate("2025-03-01"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(payments) != 1 {
+		t.Fatalf("Expected only the unpaid card, got %+v", payments)
+	}
+	if payment := payments[0]; payment.AccountName != "Rewards Card" || payment.RemainingDue != 45000 || payment.DueDate != "2025-03-15" {
+		t.Errorf("Unexpected card payment: %+v", payment)
+	}
+}
+
+func TestLoadCardTransactions(t *testing.T) {
+	db := setupTestDB(t)
+	user, budget := createTestUser(t, db, "test@example.com")
+	category := createTestCategory(t, db, budget.ID, "Travel")
+
+	closingDay, dueDay := 20, 15
+	card := models.Account{ID: uuid.New(), BudgetID: budget.ID, Name: "Travel Card", Type: "credit_card", Currency: "USD", IsActive: true, StatementClosingDay: &closingDay, PaymentDueDay: &dueDay}
+	db.Create(&card)
+	db.Create(&models.ExchangeRate{ID: uuid.New(), Date: testDate("2025-02-01"), BaseCurrency: "EUR", QuoteCurrency: "USD", Rate: 1.1, Source: "csv"})
+
+	for _, tx := range []models.Transaction{
+		{Amount: -45000, Currency: "USD", Date: testDate("2025-02-02")},
+		{Amount: -10000, Currency: "EUR", Date: testDate("2025-02-05")},
+	} {
+		tx.ID = uuid.New()
+		tx.UserID = user.ID
+		tx.BudgetID = budget.ID
+		tx.CategoryID = category.ID
+		tx.AccountID = &card.ID
+		db.Create(&tx)
+	}
+
+	transactions, err := loadCardTransactions(db, card)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The €100 charge is billed as $110
+	_, summary, err := calculateStatements(card, transactions, testDate("2025-03-01"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if summary.RemainingDue != 56000 {
+		t.Errorf("Expected 56000 due, got %d", summary.RemainingDue)
+	}
+
+	// Without a rate the charge cannot be billed
+	db.Create(&models.Transaction{ID: uuid.New(), UserID: user.ID, BudgetID: budget.ID, CategoryID: category.ID, AccountID: &card.ID, Amount: -5000, Currency: "GBP", Date: testDate("2025-02-06")})
+	if _, err := loadCardTransactions(db, card); !errors.Is(err, currency.ErrNoRate) {
+		t.Errorf("Expected a missing rate error, got %v", err)
+	}
+}
@@ -0,0 +1,420 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/yourusername/folda-finances/internal/currency"
+	"github.com/yourusername/folda-finances/internal/models"
+	"gorm.io/gorm"
+)
+
+const maxTagLength = 50
+
+type TagHandler struct {
+	db *gorm.DB
+}
+
+func NewTagHandler(db *gorm.DB) *TagHandler {
+	return &TagHandler{db: db}
+}
+
+type CreateTagRequest struct {
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+type UpdateTagRequest struct {
+	Name  *string `json:"name"`
+	Color *string `json:"color"`
+}
+
+type TagListItem struct {
+	models.Tag
+	TransactionCount int64 `json:"transaction_count"`
+}
+
+type TagSpending struct {
+	TagID            string `json:"tag_id"`
+	Name             string `json:"name"`
+	Color            string `json:"color"`
+	Spent            int    `json:"spent"`  // expenses, as a positive amount
+	Income           int    `json:"income"` // refunds and other credits
+	Net              int    `json:"net"`    // income minus spent
+	TransactionCount int    `json:"transaction_count"`
+}
+
+func (h *TagHandler) ListTags(w http.ResponseWriter, r *http.Request) {
+	budgetID, ok := userBudgetID(h.db, w, r)
+	if !ok {
+		return
+	}
+
+	var tags []models.Tag
+	if err := h.db.Where("budget_id = ?", budgetID).Order("name ASC").Find(&tags).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch tags"})
+		return
+	}
+
+	var counts []struct {
+		TagID uuid.UUID
+		Count int64
+	}
+	if err := h.db.Table("transaction_tags").
+		Select("transaction_tags.tag_id, COUNT(*) AS count").
+		Joins("JOIN tags ON tags.id = transaction_tags.tag_id").
+		Where("tags.budget_id = ?", budgetID).
+		Group("transaction_tags.tag_id").
+		Scan(&counts).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to count tagged transactions"})
+		return
+	}
+	countByTag := make(map[uuid.UUID]int64)
+	for _, c := range counts {
+		countByTag[c.TagID] = c.Count
+	}
+
+	items := make([]TagListItem, 0, len(tags))
+	for _, tag := range tags {
+		items = append(items, TagListItem{Tag: tag, TransactionCount: countByTag[tag.ID]})
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"data": items})
+}
+
+func (h *TagHandler) CreateTag(w http.ResponseWriter, r *http.Request) {
+	budgetID, ok := userBudgetID(h.db, w, r)
+	if !ok {
+		return
+	}
+
+	var req CreateTagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+
+	name, err := normalizeTagName(req.Name)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	var existing int64
+	if err := h.db.Model(&models.Tag{}).Where("budget_id = ? AND name = ?", budgetID, name).Count(&existing).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to check tags"})
+		return
+	}
+	if existing > 0 {
+		respondJSON(w, http.StatusConflict, map[string]string{"error": "tag already exists"})
+		return
+	}
+
+	tag := models.Tag{BudgetID: budgetID, Name: name, Color: req.Color}
+	if err := h.db.Create(&tag).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to create tag"})
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, map[string]interface{}{
+		"data":    tag,
+		"message": "Tag created successfully",
+	})
+}
+
+func (h *TagHandler) UpdateTag(w http.ResponseWriter, r *http.Request) {
+	tag, ok := h.authorizedTag(w, r)
+	if !ok {
+		return
+	}
+
+	var req UpdateTagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if req.Name != nil {
+		name, err := normalizeTagName(*req.Name)
+		if err != nil {
+			respondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		var existing int64
+		if err := h.db.Model(&models.Tag{}).Where("budget_id = ? AND name = ? AND id <> ?", tag.BudgetID, name, tag.ID).Count(&existing).Error; err != nil {
+			respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to check tags"})
+			return
+		}
+		if existing > 0 {
+			respondJSON(w, http.StatusConflict, map[string]string{"error": "tag already exists"})
+			return
+		}
+		updates["name"] = name
+	}
+	if req.Color != nil {
+		updates["color"] = *req.Color
+	}
+
+	if err := h.db.Model(&tag).Updates(updates).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to update tag"})
+		return
+	}
+
+	if err := h.db.First(&tag, "id = ?", tag.ID).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch updated tag"})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data":    tag,
+		"message": "Tag updated successfully",
+	})
+}
+
+// DeleteTag removes a tag from every transaction and deletes it
+func (h *TagHandler) DeleteTag(w http.ResponseWriter, r *http.Request) {
+	tag, ok := h.authorizedTag(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tag_id = ?", tag.ID).Delete(&models.TransactionTag{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&tag).Error
+	})
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to delete tag"})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"message": "Tag deleted successfully",
+	})
+}
+
+// GetTagSpending totals each tag's transactions in the budget's base currency,
+// optionally between start_date and end_date
+func (h *TagHandler) GetTagSpending(w http.ResponseWriter, r *http.Request) {
+	budgetID, ok := userBudgetID(h.db, w, r)
+	if !ok {
+		return
+	}
+
+	var budget models.Budget
+	if err := h.db.First(&budget, "id = ?", budgetID).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch budget"})
+		return
+	}
+
+	var tags []models.Tag
+	if err := h.db.Where("budget_id = ?", budgetID).Find(&tags).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch tags"})
+		return
+	}
+
+	// Transfers only move money around, so they are neither spent nor income
+	query := h.db.Where("budget_id = ? AND id IN (?)", budgetID, h.db.Model(&models.TransactionTag{}).Select("transaction_id")).
+		Where("category_id NOT IN (?)", h.db.Model(&models.Category{}).Select("id").Where("kind = ?", categoryKindTransfer))
+	for param, condition := range map[string]string{"start_date": "date >= ?", "end_date": "date <= ?"} {
+		value := r.URL.Query().Get(param)
+		if value == "" {
+			continue
+		}
+		date, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid " + param + " format"})
+			return
+		}
+		query = query.Where(condition, date)
+	}
+
+	var transactions []models.Transaction
+	if err := query.Find(&transactions).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch transactions"})
+		return
+	}
+	if _, err := convertTransactions(currency.NewConverter(h.db), transactions, budget.BaseCurrency); err != nil {
+		respondConversionError(w, err)
+		return
+	}
+	if err := loadTransactionTags(h.db, transactions); err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch transaction tags"})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"base_currency": budget.BaseCurrency,
+			"tags":          tagSpending(tags, transactions),
+		},
+	})
+}
+
+// tagSpending totals transactions under every tag they carry, most spent first
+func tagSpending(tags []models.Tag, transactions []models.Transaction) []TagSpending {
+	byTag := make(map[uuid.UUID]*TagSpending, len(tags))
+	spending := make([]TagSpending, len(tags))
+	for i, tag := range tags {
+		spending[i] = TagSpending{TagID: tag.ID.String(), Name: tag.Name, Color: tag.Color}
+		byTag[tag.ID] = &spending[i]
+	}
+
+	for _, tx := range transactions {
+		for _, tag := range tx.Tags {
+			total, ok := byTag[tag.ID]
+			if !ok {
+				continue
+			}
+			if tx.Amount < 0 {
+				total.Spent += -tx.Amount
+			} else if tx.IsRefund {
+				total.Spent -= tx.Amount
+			} else {
+				total.Income += tx.Amount
+			}
+			total.Net += tx.Amount
+			total.TransactionCount++
+		}
+	}
+
+	sort.SliceStable(spending, func(i, j int) bool {
+		if spending[i].Spent != spending[j].Spent {
+			return spending[i].Spent > spending[j].Spent
+		}
+		return spending[i].Name < spending[j].Name
+	})
+	return spending
+}
+
+func (h *TagHandler) authorizedTag(w http.ResponseWriter, r *http.Request) (models.Tag, bool) {
+	var tag models.Tag
+
+	budgetID, ok := userBudgetID(h.db, w, r)
+	if !ok {
+		return tag, false
+	}
+
+	if err := h.db.First(&tag, "id = ?", chi.URLParam(r, "id")).Error; err != nil {
+		respondJSON(w, http.StatusNotFound, map[string]string{"error": "tag not found"})
+		return tag, false
+	}
+
+	if tag.BudgetID != budgetID {
+		respondJSON(w, http.StatusForbidden, map[string]string{"error": "access denied"})
+		return tag, false
+	}
+
+	return tag, true
+}
+
+// normalizeTagName trims and lowercases a tag so "Vacation-2026" and
+// "vacation-2026" are the same tag
+func normalizeTagName(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return "", errors.New("tag name is required")
+	}
+	if len(name) > maxTagLength {
+		return "", errors.New("tag name must be at most 50 characters")
+	}
+	return name, nil
+}
+
+// budgetTags finds the budget's tags by name, creating any that do not exist
+func budgetTags(tx *gorm.DB, budgetID uuid.UUID, names []string) ([]models.Tag, error) {
+	tags := []models.Tag{}
+	seen := make(map[string]bool)
+	for _, name := range names {
+		name, err := normalizeTagName(name)
+		if err != nil {
+			return nil, err
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		var tag models.Tag
+		err = tx.Where("budget_id = ? AND name = ?", budgetID, name).First(&tag).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			tag = models.Tag{BudgetID: budgetID, Name: name}
+			err = tx.Create(&tag).Error
+		}
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+// setTransactionTags replaces a transaction's tags
+func setTransactionTags(tx *gorm.DB, transactionID uuid.UUID, tags []models.Tag) error {
+	if err := tx.Where("transaction_id = ?", transactionID).Delete(&models.TransactionTag{}).Error; err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		if err := tx.Create(&models.TransactionTag{TransactionID: transactionID, TagID: tag.ID}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadTransactionTags fills in the tags of each transaction, in name order
+func loadTransactionTags(db *gorm.DB, transactions []models.Transaction) error {
+	if len(transactions) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, len(transactions))
+	for i, tx := range transactions {
+		ids[i] = tx.ID
+	}
+
+	var rows []struct {
+		TransactionID uuid.UUID
+		models.Tag
+	}
+	if err := db.Table("transaction_tags").
+		Select("transaction_tags.transaction_id, tags.*").
+		Joins("JOIN tags ON tags.id = transaction_tags.tag_id").
+		Where("transaction_tags.transaction_id IN ?", ids).
+		Order("tags.name ASC").
+		Scan(&rows).Error; err != nil {
+		return err
+	}
+
+	byTransaction := make(map[uuid.UUID][]models.Tag)
+	for _, row := range rows {
+		byTransaction[row.TransactionID] = append(byTransaction[row.TransactionID], row.Tag)
+	}
+	for i := range transactions {
+		transactions[i].Tags = byTransaction[transactions[i].ID]
+	}
+	return nil
+}
+
+// taggedWith limits a transaction query to those carrying every named tag
+func taggedWith(db, query *gorm.DB, budgetID uuid.UUID, names []string) (*gorm.DB, error) {
+	for _, name := range names {
+		name, err := normalizeTagName(name)
+		if err != nil {
+			return nil, err
+		}
+		tagged := db.Table("transaction_tags").
+			Select("transaction_tags.transaction_id").
+			Joins("JOIN tags ON tags.id = transaction_tags.tag_id").
+			Where("tags.budget_id = ? AND tags.name = ?", budgetID, name)
+		query = query.Where("id IN (?)", tagged)
+	}
+	return query, nil
+}
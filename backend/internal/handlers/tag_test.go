@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/yourusername/folda-finances/internal/models"
+)
+
+func TestTransactionTags(t *testing.T) {
+	db := setupTestDB(t)
+	handler := NewTransactionHandler(db)
+	tagHandler := NewTagHandler(db)
+
+	user, budget := createTestUser(t, db, "test@example.com")
+	travel := createTestCategory(t, db, budget.ID, "Travel")
+	dining := createTestCategory(t, db, budget.ID, "Dining")
+
+	create := func(req CreateTransactionRequest) models.Transaction {
+		t.Helper()
+		body, _ := json.Marshal(req)
+		httpReq := httptest.NewRequest("POST", "/transactions", bytes.NewBuffer(body))
+		httpReq = httpReq.WithContext(setUserIDContext(httpReq, user.ID))
+		w := httptest.NewRecorder()
+		handler.CreateTransaction(w, httpReq)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+		}
+		var response struct {
+			Data models.Transaction `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+		return response.Data
+	}
+
+	flight := create(CreateTransactionRequest{Amount: -45000, CategoryID: travel.ID.String(), Date: "2025-07-01", Tags: []string{"Maine-Trip", "maine-trip"}})
+	create(CreateTransactionRequest{Amount: -8000, CategoryID: dining.ID.String(), Date: "2025-07-04", Tags: []string{"maine-trip", "tax-deductible"}, Notes: "Client dinner"})
+	create(CreateTransactionRequest{Amount: 5000, CategoryID: travel.ID.String(), Date: "2025-07-10", Tags: []string{"maine-trip"}, IsRefund: true}) // partial refund
+	create(CreateTransactionRequest{Amount: -2000, CategoryID: dining.ID.String(), Date: "2025-07-12"})
+
+	// Tag names are normalized and deduplicated
+	if len(flight.Tags) != 1 || flight.Tags[0].Name != "maine-trip" {
+		t.Fatalf("Expected a single maine-trip tag, got %+v", flight.Tags)
+	}
+
+	// Every tag given must match
+	for query, expected := range map[string]int{"?tag=maine-trip": 3, "?tag=maine-trip&tag=tax-deductible": 1, "": 4} {
+		req := httptest.NewRequest("GET", "/transactions"+query, nil)
+		req = req.WithContext(setUserIDContext(req, user.ID))
+		w := httptest.NewRecorder()
+		handler.ListTransactions(w, req)
+
+		var response struct {
+			Data struct {
+				Data []models.Transaction `json:"data"`
+			} `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+		if len(response.Data.Data) != expected {
+			t.Errorf("%q: expected %d transactions, got %d", query, expected, len(response.Data.Data))
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/tags/spending?start_date=2025-07-01&end_date=2025-07-31", nil)
+	req = req.WithContext(setUserIDContext(req, user.ID))
+	w := httptest.NewRecorder()
+	tagHandler.GetTagSpending(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var response struct {
+		Data struct {
+			Tags []TagSpending `json:"tags"`
+		} `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&response)
+	if len(response.Data.Tags) != 2 {
+		t.Fatalf("Expected 2 tags, got %d", len(response.Data.Tags))
+	}
+	trip := response.Data.Tags[0]
+	if trip.Name != "maine-trip" || trip.Spent != 48000 || trip.Income != 0 || trip.Net != -48000 || trip.TransactionCount != 3 {
+		t.Errorf("Unexpected trip totals: %+v", trip)
+	}
+
+	// Replacing the tags on update
+	tags := []string{"vacation-2025"}
+	body, _ := json.Marshal(UpdateTransactionRequest{Tags: &tags})
+	req = httptest.NewRequest("PUT", "/transactions/"+flight.ID.String(), bytes.NewBuffer(body))
+	req = req.WithContext(setUserIDContext(req, user.ID))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", flight.ID.String())
+	req = req.WithContext(setRouteContext(req, rctx))
+	w = httptest.NewRecorder()
+	handler.UpdateTransaction(w, req)
+
+	var updated struct {
+		Data models.Transaction `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&updated)
+	if w.Code != http.StatusOK || len(updated.Data.Tags) != 1 || updated.Data.Tags[0].Name != "vacation-2025" {
+		t.Errorf("Expected the tags replaced, got %d: %+v", w.Code, updated.Data.Tags)
+	}
+}
@@ -0,0 +1,583 @@
+package handlers
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/yourusername/folda-finances/internal/middleware"
+	"github.com/yourusername/folda-finances/internal/models"
+	"gorm.io/gorm"
+)
+
+type TemplateHandler struct {
+	db *gorm.DB
+}
+
+func NewTemplateHandler(db *gorm.DB) *TemplateHandler {
+	return &TemplateHandler{db: db}
+}
+
+// templateAllocation is a built-in template's share of income for a system
+// category, by name
+type templateAllocation struct {
+	category   string
+	percentage float64
+}
+
+type builtInTemplate struct {
+	id          string
+	name        string
+	description string
+	allocations []templateAllocation
+}
+
+// builtInTemplates are offered to every budget. Each allocates all of the
+// monthly income across the seeded system categories.
+var builtInTemplates = []builtInTemplate{
+	{
+		id:          "50-30-20",
+		name:        "50/30/20",
+		description: "Half of income to needs, 30% to wants and 20% to savings and debt",
+		allocations: []templateAllocation{
+			{"Housing", 25}, {"Utilities", 5}, {"Groceries", 10}, {"Transportation", 6}, {"Insurance", 4},
+			{"Dining & Restaurants", 8}, {"Entertainment", 5}, {"Shopping", 7}, {"Personal Care", 3},
+			{"Subscriptions", 3}, {"Gifts & Donations", 2}, {"Miscellaneous", 2},
+			{"Savings", 15}, {"Debt Payments", 5},
+		},
+	},
+	{
+		id:          "zero-based",
+		name:        "Zero-based starter",
+		description: "Every dollar of income given a job, weighted towards essentials",
+		allocations: []templateAllocation{
+			{"Housing", 30}, {"Utilities", 6}, {"Groceries", 12}, {"Transportation", 10}, {"Healthcare", 5},
+			{"Insurance", 5}, {"Debt Payments", 8}, {"Savings", 10}, {"Dining & Restaurants", 5},
+			{"Entertainment", 3}, {"Personal Care", 2}, {"Subscriptions", 2}, {"Miscellaneous", 2},
+		},
+	},
+	{
+		id:          "student",
+		name:        "Student",
+		description: "Rent, food and course costs first, with a little put aside",
+		allocations: []templateAllocation{
+			{"Housing", 35}, {"Groceries", 15}, {"Education", 15}, {"Transportation", 8},
+			{"Dining & Restaurants", 7}, {"Entertainment", 5}, {"Subscriptions", 3}, {"Personal Care", 2},
+			{"Savings", 5}, {"Miscellaneous", 5},
+		},
+	},
+}
+
+type BudgetTemplateItemResponse struct {
+	CategoryID   string  `json:"category_id"`
+	CategoryName string  `json:"category_name"`
+	Percentage   float64 `json:"percentage"`
+	Amount       *int    `json:"amount,omitempty"` // with a monthly income
+}
+
+type BudgetTemplateResponse struct {
+	ID              string                       `json:"id"`
+	Name            string                       `json:"name"`
+	Description     string                       `json:"description"`
+	IsBuiltIn       bool                         `json:"is_built_in"`
+	TotalPercentage float64                      `json:"total_percentage"`
+	Items           []BudgetTemplateItemResponse `json:"items"`
+}
+
+type SaveBudgetTemplateRequest struct {
+	Name          string `json:"name"`
+	Description   string `json:"description"`
+	MonthlyIncome int    `json:"monthly_income"` // optional; defaults to the total allocated
+}
+
+type ApplyBudgetTemplateRequest struct {
+	MonthlyIncome   int  `json:"monthly_income"`
+	ReplaceExisting bool `json:"replace_existing"` // change categories already budgeted from this month
+}
+
+type SkippedTemplateItem struct {
+	CategoryID   string `json:"category_id"`
+	CategoryName string `json:"category_name"`
+	Reason       string `json:"reason"`
+}
+
+type ApplyBudgetTemplateResponse struct {
+	TemplateID    string                  `json:"template_id"`
+	MonthlyIncome int                     `json:"monthly_income"`
+	Created       []models.CategoryBudget `json:"created"`
+	Updated       []models.CategoryBudget `json:"updated"`
+	Skipped       []SkippedTemplateItem   `json:"skipped"`
+	Unallocated   int                     `json:"unallocated"` // income the template leaves unbudgeted
+}
+
+// ListBudgetTemplates returns the built-in templates followed by the budget's
+// saved ones. With ?monthly_income= each item includes the amount it would
+// budget.
+func (h *TemplateHandler) ListBudgetTemplates(w http.ResponseWriter, r *http.Request) {
+	budgetID, ok := userBudgetID(h.db, w, r)
+	if !ok {
+		return
+	}
+
+	income := 0
+	if value := r.URL.Query().Get("monthly_income"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			respondJSON(w, http.StatusBadRequest, map[string]string{"error": "monthly_income must be a positive amount"})
+			return
+		}
+		income = parsed
+	}
+
+	templates := make([]BudgetTemplateResponse, 0, len(builtInTemplates))
+	for _, builtIn := range builtInTemplates {
+		template, err := resolveBuiltInTemplate(h.db, builtIn)
+		if err != nil {
+			respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch templates"})
+			return
+		}
+		templates = append(templates, template)
+	}
+
+	var saved []models.BudgetTemplate
+	if err := h.db.Where("budget_id = ?", budgetID).Order("name ASC").Find(&saved).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch templates"})
+		return
+	}
+	for _, row := range saved {
+		template, err := resolveSavedTemplate(h.db, row)
+		if err != nil {
+			respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch templates"})
+			return
+		}
+		templates = append(templates, template)
+	}
+
+	if income > 0 {
+		for i := range templates {
+			amounts := templateAmounts(templates[i].Items, income)
+			for j := range templates[i].Items {
+				templates[i].Items[j].Amount = &amounts[j]
+			}
+		}
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"data": templates})
+}
+
+// SaveBudgetTemplate saves the budget's current category budget amounts as a
+// template, each as a share of monthly income. Sinking funds are left out.
+func (h *TemplateHandler) SaveBudgetTemplate(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserID(r)
+	if err != nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	budgetID, ok := userBudgetID(h.db, w, r)
+	if !ok {
+		return
+	}
+
+	var req SaveBudgetTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "name is required"})
+		return
+	}
+	if req.MonthlyIncome < 0 {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "monthly_income must be a positive amount"})
+		return
+	}
+
+	var categoryBudgets []models.CategoryBudget
+	if err := h.db.Where("budget_id = ?", budgetID).Find(&categoryBudgets).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch budgets"})
+		return
+	}
+	amounts, err := categoryBudgetAmounts(h.db, categoryBudgets)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch budget amounts"})
+		return
+	}
+
+	now := time.Now()
+	allocated := make(map[uuid.UUID]int)
+	total := 0
+	for _, cb := range categoryBudgets {
+		if isSinkingFund(cb) {
+			continue
+		}
+		if amount := amountForMonth(cb, amounts[cb.ID], now); amount > 0 {
+			allocated[cb.CategoryID] = amount
+			total += amount
+		}
+	}
+	if total == 0 {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "budget has no category budgets to save"})
+		return
+	}
+
+	income := req.MonthlyIncome
+	if income == 0 {
+		income = total
+	}
+	if total > income {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "category budgets add up to more than monthly_income"})
+		return
+	}
+
+	template := models.BudgetTemplate{
+		BudgetID:    budgetID,
+		Name:        req.Name,
+		Description: req.Description,
+		CreatedBy:   userID,
+	}
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&template).Error; err != nil {
+			return err
+		}
+		for categoryID, amount := range allocated {
+			item := models.BudgetTemplateItem{
+				TemplateID: template.ID,
+				CategoryID: categoryID,
+				Percentage: math.Round(float64(amount)*10000/float64(income)) / 100,
+			}
+			if err := tx.Create(&item).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to save template"})
+		return
+	}
+
+	response, err := resolveSavedTemplate(h.db, template)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch template"})
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, map[string]interface{}{
+		"data":    response,
+		"message": "Template saved successfully",
+	})
+}
+
+// DeleteBudgetTemplate deletes one of the budget's saved templates
+func (h *TemplateHandler) DeleteBudgetTemplate(w http.ResponseWriter, r *http.Request) {
+	budgetID, ok := userBudgetID(h.db, w, r)
+	if !ok {
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	if findBuiltInTemplate(id) != nil {
+		respondJSON(w, http.StatusForbidden, map[string]string{"error": "built-in templates cannot be deleted"})
+		return
+	}
+
+	var template models.BudgetTemplate
+	if err := h.db.First(&template, "id = ? AND budget_id = ?", id, budgetID).Error; err != nil {
+		respondJSON(w, http.StatusNotFound, map[string]string{"error": "template not found"})
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("template_id = ?", template.ID).Delete(&models.BudgetTemplateItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&template).Error
+	})
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to delete template"})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Template deleted successfully"})
+}
+
+// ApplyBudgetTemplate sets up category budgets from a template scaled to a
+// monthly income. Categories already budgeted are skipped unless
+// replace_existing is set, in which case their amount changes from this
+// month on; sinking funds and hidden categories are always skipped.
+func (h *TemplateHandler) ApplyBudgetTemplate(w http.ResponseWriter, r *http.Request) {
+	budgetID, ok := userBudgetID(h.db, w, r)
+	if !ok {
+		return
+	}
+
+	var req ApplyBudgetTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+	if req.MonthlyIncome <= 0 {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "monthly_income must be a positive amount"})
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	var template BudgetTemplateResponse
+	if builtIn := findBuiltInTemplate(id); builtIn != nil {
+		resolved, err := resolveBuiltInTemplate(h.db, *builtIn)
+		if err != nil {
+			respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch template"})
+			return
+		}
+		template = resolved
+	} else {
+		var row models.BudgetTemplate
+		if err := h.db.First(&row, "id = ? AND budget_id = ?", id, budgetID).Error; err != nil {
+			respondJSON(w, http.StatusNotFound, map[string]string{"error": "template not found"})
+			return
+		}
+		resolved, err := resolveSavedTemplate(h.db, row)
+		if err != nil {
+			respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch template"})
+			return
+		}
+		template = resolved
+	}
+	if len(template.Items) == 0 {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "template has no categories this budget can use"})
+		return
+	}
+
+	hidden, err := hiddenCategories(h.db, budgetID)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch categories"})
+		return
+	}
+	var existing []models.CategoryBudget
+	if err := h.db.Where("budget_id = ?", budgetID).Find(&existing).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch budgets"})
+		return
+	}
+	budgeted := make(map[string]models.CategoryBudget, len(existing))
+	for _, cb := range existing {
+		budgeted[cb.CategoryID.String()] = cb
+	}
+
+	response := ApplyBudgetTemplateResponse{
+		TemplateID:    template.ID,
+		MonthlyIncome: req.MonthlyIncome,
+		Created:       []models.CategoryBudget{},
+		Updated:       []models.CategoryBudget{},
+		Skipped:       []SkippedTemplateItem{},
+		Unallocated:   req.MonthlyIncome,
+	}
+	amounts := templateAmounts(template.Items, req.MonthlyIncome)
+	now := time.Now()
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		for i, item := range template.Items {
+			skip := func(reason string) {
+				response.Skipped = append(response.Skipped, SkippedTemplateItem{
+					CategoryID:   item.CategoryID,
+					CategoryName: item.CategoryName,
+					Reason:       reason,
+				})
+			}
+			categoryID := uuid.MustParse(item.CategoryID)
+			if hidden[categoryID] {
+				skip("category is hidden")
+				continue
+			}
+
+			if cb, ok := budgeted[item.CategoryID]; ok {
+				if !req.ReplaceExisting {
+					skip("category is already budgeted")
+					continue
+				}
+				if isSinkingFund(cb) {
+					skip("category is a sinking fund")
+					continue
+				}
+				current, err := setCategoryBudgetAmount(tx, cb, amounts[i], now, false)
+				if err != nil {
+					return err
+				}
+				if err := tx.Model(&cb).Update("amount", current).Error; err != nil {
+					return err
+				}
+				response.Updated = append(response.Updated, cb)
+				response.Unallocated -= amounts[i]
+				continue
+			}
+
+			cb := models.CategoryBudget{
+				BudgetID:       budgetID,
+				CategoryID:     categoryID,
+				Amount:         amounts[i],
+				AllocationType: "pooled",
+				BudgetType:     "standard",
+			}
+			if err := tx.Create(&cb).Error; err != nil {
+				return err
+			}
+			if err := tx.Create(&models.CategoryBudgetAmount{
+				CategoryBudgetID: cb.ID,
+				Amount:           cb.Amount,
+				EffectiveMonth:   monthStart(now),
+			}).Error; err != nil {
+				return err
+			}
+			response.Created = append(response.Created, cb)
+			response.Unallocated -= amounts[i]
+		}
+		return nil
+	})
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to apply template"})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data":    response,
+		"message": "Template applied successfully",
+	})
+}
+
+// findBuiltInTemplate returns the built-in template with the given ID, if any
+func findBuiltInTemplate(id string) *builtInTemplate {
+	for i := range builtInTemplates {
+		if builtInTemplates[i].id == id {
+			return &builtInTemplates[i]
+		}
+	}
+	return nil
+}
+
+// resolveBuiltInTemplate looks up a built-in template's system categories,
+// leaving out any that are not seeded
+func resolveBuiltInTemplate(db *gorm.DB, builtIn builtInTemplate) (BudgetTemplateResponse, error) {
+	template := BudgetTemplateResponse{
+		ID:          builtIn.id,
+		Name:        builtIn.name,
+		Description: builtIn.description,
+		IsBuiltIn:   true,
+		Items:       []BudgetTemplateItemResponse{},
+	}
+
+	names := make([]string, len(builtIn.allocations))
+	for i, allocation := range builtIn.allocations {
+		names[i] = allocation.category
+	}
+	var categories []models.Category
+	if err := db.Where("budget_id IS NULL AND is_system = ? AND kind = ? AND name IN ?", true, categoryKindExpense, names).
+		Find(&categories).Error; err != nil {
+		return template, err
+	}
+	byName := make(map[string]models.Category, len(categories))
+	for _, category := range categories {
+		byName[category.Name] = category
+	}
+
+	for _, allocation := range builtIn.allocations {
+		category, ok := byName[allocation.category]
+		if !ok {
+			continue
+		}
+		template.Items = append(template.Items, BudgetTemplateItemResponse{
+			CategoryID:   category.ID.String(),
+			CategoryName: category.Name,
+			Percentage:   allocation.percentage,
+		})
+		template.TotalPercentage += allocation.percentage
+	}
+	return template, nil
+}
+
+// resolveSavedTemplate loads a saved template's items, largest share first,
+// leaving out categories deleted since it was saved
+func resolveSavedTemplate(db *gorm.DB, row models.BudgetTemplate) (BudgetTemplateResponse, error) {
+	template := BudgetTemplateResponse{
+		ID:          row.ID.String(),
+		Name:        row.Name,
+		Description: row.Description,
+		Items:       []BudgetTemplateItemResponse{},
+	}
+
+	var items []models.BudgetTemplateItem
+	if err := db.Where("template_id = ?", row.ID).Find(&items).Error; err != nil {
+		return template, err
+	}
+	if len(items) == 0 {
+		return template, nil
+	}
+	categoryIDs := make([]uuid.UUID, len(items))
+	for i, item := range items {
+		categoryIDs[i] = item.CategoryID
+	}
+	var categories []models.Category
+	if err := db.Where("id IN ? AND ((budget_id IS NULL AND is_system = ?) OR budget_id = ?)", categoryIDs, true, row.BudgetID).
+		Find(&categories).Error; err != nil {
+		return template, err
+	}
+	byID := make(map[uuid.UUID]models.Category, len(categories))
+	for _, category := range categories {
+		byID[category.ID] = category
+	}
+
+	for _, item := range items {
+		category, ok := byID[item.CategoryID]
+		if !ok {
+			continue
+		}
+		template.Items = append(template.Items, BudgetTemplateItemResponse{
+			CategoryID:   category.ID.String(),
+			CategoryName: category.Name,
+			Percentage:   item.Percentage,
+		})
+		template.TotalPercentage += item.Percentage
+	}
+	sort.Slice(template.Items, func(i, j int) bool {
+		if template.Items[i].Percentage != template.Items[j].Percentage {
+			return template.Items[i].Percentage > template.Items[j].Percentage
+		}
+		return template.Items[i].CategoryName < template.Items[j].CategoryName
+	})
+	template.TotalPercentage = math.Round(template.TotalPercentage*100) / 100
+	return template, nil
+}
+
+// templateAmounts scales a template's shares to a monthly income in cents.
+// Leftover cents from rounding go to the items with the largest remainders,
+// so a template allocating 100% budgets exactly the income.
+func templateAmounts(items []BudgetTemplateItemResponse, income int) []int {
+	amounts := make([]int, len(items))
+	remainders := make([]float64, len(items))
+	exactTotal := 0.0
+	total := 0
+	for i, item := range items {
+		exact := float64(income) * item.Percentage / 100
+		amounts[i] = int(math.Floor(exact))
+		remainders[i] = exact - float64(amounts[i])
+		exactTotal += exact
+		total += amounts[i]
+	}
+
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return remainders[order[a]] > remainders[order[b]]
+	})
+	for _, i := range order[:min(len(order), int(math.Round(exactTotal))-total)] {
+		amounts[i]++
+	}
+	return amounts
+}
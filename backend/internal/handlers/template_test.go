@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/yourusername/folda-finances/internal/models"
+)
+
+func TestApplyBuiltInTemplate(t *testing.T) {
+	db := setupTestDB(t)
+	handler := NewTemplateHandler(db)
+
+	user, budget := createTestUser(t, db, "test@example.com")
+	for _, name := range []string{"Housing", "Groceries", "Savings", "Dining & Restaurants"} {
+		db.Create(&models.Category{ID: uuid.New(), Name: name, Color: "#000000", Icon: "📦", IsSystem: true, Kind: categoryKindExpense})
+	}
+	var groceries models.Category
+	db.First(&groceries, "name = ?", "Groceries")
+	existing := models.CategoryBudget{ID: uuid.New(), BudgetID: budget.ID, CategoryID: groceries.ID, Amount: 20000, AllocationType: "pooled"}
+	db.Create(&existing)
+
+	// Previewing scales each share to the income, leaving out unseeded categories
+	w := httptest.NewRecorder()
+	handler.ListBudgetTemplates(w, testRequest("GET", "/budget-templates?monthly_income=300000", nil, user.ID, nil))
+	var list struct {
+		Data []BudgetTemplateResponse `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&list)
+	if w.Code != http.StatusOK || len(list.Data) != len(builtInTemplates) {
+		t.Fatalf("Expected the built-in templates, got %d: %+v", w.Code, list.Data)
+	}
+	fiftyThirtyTwenty := list.Data[0]
+	if len(fiftyThirtyTwenty.Items) != 4 || fiftyThirtyTwenty.TotalPercentage != 58 || *fiftyThirtyTwenty.Items[0].Amount != 75000 {
+		t.Errorf("Expected Housing first at 75000 of 58%%, got %+v", fiftyThirtyTwenty)
+	}
+
+	apply := func(body ApplyBudgetTemplateRequest) ApplyBudgetTemplateResponse {
+		w := httptest.NewRecorder()
+		handler.ApplyBudgetTemplate(w, testRequest("POST", "/budget-templates/50-30-20/apply", body, user.ID, map[string]string{"id": "50-30-20"}))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var response struct {
+			Data ApplyBudgetTemplateResponse `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+		return response.Data
+	}
+
+	w = httptest.NewRecorder()
+	handler.ApplyBudgetTemplate(w, testRequest("POST", "/budget-templates/50-30-20/apply", ApplyBudgetTemplateRequest{}, user.ID, map[string]string{"id": "50-30-20"}))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 without an income, got %d", w.Code)
+	}
+
+	// Budgeted categories are left alone by default
+	applied := apply(ApplyBudgetTemplateRequest{MonthlyIncome: 300000})
+	if len(applied.Created) != 3 || len(applied.Skipped) != 1 || applied.Skipped[0].CategoryName != "Groceries" || applied.Unallocated != 156000 {
+		t.Errorf("Expected three created and Groceries skipped, got %+v", applied)
+	}
+
+	applied = apply(ApplyBudgetTemplateRequest{MonthlyIncome: 300000, ReplaceExisting: true})
+	if len(applied.Created) != 0 || len(applied.Updated) != 4 {
+		t.Errorf("Expected all four updated, got %+v", applied)
+	}
+	db.First(&existing, "id = ?", existing.ID)
+	if existing.Amount != 30000 {
+		t.Errorf("Expected Groceries raised to 30000, got %d", existing.Amount)
+	}
+	var count int64
+	db.Model(&models.CategoryBudget{}).Where("budget_id = ?", budget.ID).Count(&count)
+	if count != 4 {
+		t.Errorf("Expected 4 category budgets, got %d", count)
+	}
+}
+
+func TestSaveBudgetTemplate(t *testing.T) {
+	db := setupTestDB(t)
+	handler := NewTemplateHandler(db)
+
+	user, budget := createTestUser(t, db, "test@example.com")
+	other, _ := createTestUser(t, db, "other@example.com")
+	rent := createTestCategory(t, db, budget.ID, "Rent")
+	food := createTestCategory(t, db, budget.ID, "Food")
+	db.Create(&models.CategoryBudget{ID: uuid.New(), BudgetID: budget.ID, CategoryID: rent.ID, Amount: 120000, AllocationType: "pooled"})
+	db.Create(&models.CategoryBudget{ID: uuid.New(), BudgetID: budget.ID, CategoryID: food.ID, Amount: 60000, AllocationType: "pooled"})
+
+	save := func(body SaveBudgetTemplateRequest) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.SaveBudgetTemplate(w, testRequest("POST", "/budget-templates", body, user.ID, nil))
+		return w
+	}
+
+	if w := save(SaveBudgetTemplateRequest{Name: "Lean", MonthlyIncome: 100000}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 saving more than the income, got %d", w.Code)
+	}
+	w := save(SaveBudgetTemplateRequest{Name: "Lean", MonthlyIncome: 400000})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var saved struct {
+		Data BudgetTemplateResponse `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&saved)
+	if saved.Data.IsBuiltIn || saved.Data.TotalPercentage != 45 || saved.Data.Items[0].CategoryName != "Rent" || saved.Data.Items[0].Percentage != 30 {
+		t.Errorf("Expected Rent at 30%% of 45%%, got %+v", saved.Data)
+	}
+
+	// Applied at a higher income the allocations scale up
+	w = httptest.NewRecorder()
+	handler.ApplyBudgetTemplate(w, testRequest("POST", "/apply", ApplyBudgetTemplateRequest{MonthlyIncome: 500000, ReplaceExisting: true}, user.ID, map[string]string{"id": saved.Data.ID}))
+	var rentBudget models.CategoryBudget
+	db.First(&rentBudget, "category_id = ?", rent.ID)
+	if w.Code != http.StatusOK || rentBudget.Amount != 150000 {
+		t.Errorf("Expected Rent budgeted 150000, got %d: %d", w.Code, rentBudget.Amount)
+	}
+
+	// Saved templates belong to their budget
+	w = httptest.NewRecorder()
+	handler.ApplyBudgetTemplate(w, testRequest("POST", "/apply", ApplyBudgetTemplateRequest{MonthlyIncome: 500000}, other.ID, map[string]string{"id": saved.Data.ID}))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 applying another budget's template, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.DeleteBudgetTemplate(w, testRequest("DELETE", "/budget-templates/student", nil, user.ID, map[string]string{"id": "student"}))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 deleting a built-in template, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	handler.DeleteBudgetTemplate(w, testRequest("DELETE", "/budget-templates/"+saved.Data.ID, nil, user.ID, map[string]string{"id": saved.Data.ID}))
+	var count int64
+	db.Model(&models.BudgetTemplateItem{}).Count(&count)
+	if w.Code != http.StatusOK || count != 0 {
+		t.Errorf("Expected the template and its items deleted, got %d with %d items", w.Code, count)
+	}
+}
+
+func TestTemplateAmountsRounding(t *testing.T) {
+	items := []BudgetTemplateItemResponse{{Percentage: 33.33}, {Percentage: 33.33}, {Percentage: 33.34}}
+	amounts := templateAmounts(items, 1001)
+	if total := amounts[0] + amounts[1] + amounts[2]; total != 1001 {
+		t.Errorf("Expected the whole income allocated, got %v", amounts)
+	}
+}
@@ -1,8 +1,11 @@
 package handlers
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"net/http"
+	"net/http/httptest"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
@@ -18,3 +21,19 @@ func setUserIDContext(r *http.Request, userID uuid.UUID) context.Context {
 func setRouteContext(r *http.Request, rctx *chi.Context) context.Context {
 	return context.WithValue(r.Context(), chi.RouteCtxKey, rctx)
 }
+
+// testRequest builds a request from userID with body encoded as JSON, routed
+// with the given URL params
+func testRequest(method, target string, body interface{}, userID uuid.UUID, params map[string]string) *http.Request {
+	var data []byte
+	if body != nil {
+		data, _ = json.Marshal(body)
+	}
+	req := httptest.NewRequest(method, target, bytes.NewBuffer(data))
+	req = req.WithContext(setUserIDContext(req, userID))
+	rctx := chi.NewRouteContext()
+	for key, value := range params {
+		rctx.URLParams.Add(key, value)
+	}
+	return req.WithContext(setRouteContext(req, rctx))
+}
@@ -2,12 +2,14 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"github.com/yourusername/folda-finances/internal/currency"
 	"github.com/yourusername/folda-finances/internal/middleware"
 	"github.com/yourusername/folda-finances/internal/models"
 	"gorm.io/gorm"
@@ -22,17 +24,37 @@ func NewTransactionHandler(db *gorm.DB) *TransactionHandler {
 }
 
 type CreateTransactionRequest struct {
-	Amount      int    `json:"amount"`
-	Description string `json:"description"`
-	CategoryID  string `json:"category_id"`
-	Date        string `json:"date"`
+	Amount      int      `json:"amount"`
+	Currency    string   `json:"currency"` // defaults to the budget's base currency
+	Description string   `json:"description"`
+	CategoryID  string   `json:"category_id"`
+	AccountID   string   `json:"account_id"`
+	Date        string   `json:"date"`
+	Notes       string   `json:"notes"`
+	Tags        []string `json:"tags"` // tag names, created when new
+
+	IsReimbursable     bool   `json:"is_reimbursable"`
+	ReimbursementPayer string `json:"reimbursement_payer"`
+
+	IsRefund              bool   `json:"is_refund"`
+	RefundOfTransactionID string `json:"refund_of_transaction_id"` // purchase refunded, optional
 }
 
 type UpdateTransactionRequest struct {
-	Amount      *int    `json:"amount"`
-	Description *string `json:"description"`
-	CategoryID  *string `json:"category_id"`
-	Date        *string `json:"date"`
+	Amount      *int      `json:"amount"`
+	Currency    *string   `json:"currency"`
+	Description *string   `json:"description"`
+	CategoryID  *string   `json:"category_id"`
+	AccountID   *string   `json:"account_id"` // empty to unlink
+	Date        *string   `json:"date"`
+	Notes       *string   `json:"notes"`
+	Tags        *[]string `json:"tags"` // replaces the tags, empty to clear
+
+	IsReimbursable     *bool   `json:"is_reimbursable"`
+	ReimbursementPayer *string `json:"reimbursement_payer"`
+
+	IsRefund              *bool   `json:"is_refund"`
+	RefundOfTransactionID *string `json:"refund_of_transaction_id"` // empty to unlink
 }
 
 func (h *TransactionHandler) ListTransactions(w http.ResponseWriter, r *http.Request) {
@@ -43,8 +65,8 @@ func (h *TransactionHandler) ListTransactions(w http.ResponseWriter, r *http.Req
 	}
 
 	// Get user's budget_id
-	var user models.User
-	if err := h.db.First(&user, "id = ?", userID).Error; err != nil {
+	user, err := requestUser(h.db, r, userID)
+	if err != nil {
 		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch user"})
 		return
 	}
@@ -77,12 +99,23 @@ func (h *TransactionHandler) ListTransactions(w http.ResponseWriter, r *http.Req
 	if endDate := r.URL.Query().Get("end_date"); endDate != "" {
 		query = query.Where("date <= ?", endDate)
 	}
+	if tags := r.URL.Query()["tag"]; len(tags) > 0 {
+		query, err = taggedWith(h.db, query, *user.BudgetID, tags)
+		if err != nil {
+			respondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+	}
 
 	var transactions []models.Transaction
 	if err := query.Order("date DESC, created_at DESC").Find(&transactions).Error; err != nil {
 		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch transactions"})
 		return
 	}
+	if err := loadTransactionTags(h.db, transactions); err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch transaction tags"})
+		return
+	}
 
 	respondJSON(w, http.StatusOK, map[string]interface{}{
 		"data": map[string]interface{}{
@@ -109,8 +142,8 @@ func (h *TransactionHandler) CreateTransaction(w http.ResponseWriter, r *http.Re
 	}
 
 	// Get user's budget_id
-	var user models.User
-	if err := h.db.First(&user, "id = ?", userID).Error; err != nil {
+	user, err := requestUser(h.db, r, userID)
+	if err != nil {
 		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch user"})
 		return
 	}
@@ -120,7 +153,23 @@ func (h *TransactionHandler) CreateTransaction(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	// Parse category ID
+	var refundOf *uuid.UUID
+	if req.RefundOfTransactionID != "" {
+		id, err := uuid.Parse(req.RefundOfTransactionID)
+		if err != nil {
+			respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid refund_of_transaction_id"})
+			return
+		}
+		refundOf = &id
+	}
+
+	// Parse category ID; refunds default to the category of the purchase
+	if req.CategoryID == "" && refundOf != nil {
+		var original models.Transaction
+		if err := h.db.First(&original, "id = ? AND budget_id = ?", *refundOf, user.BudgetID).Error; err == nil {
+			req.CategoryID = original.CategoryID.String()
+		}
+	}
 	categoryID, err := uuid.Parse(req.CategoryID)
 	if err != nil {
 		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid category_id"})
@@ -134,6 +183,35 @@ func (h *TransactionHandler) CreateTransaction(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	var accountID *uuid.UUID
+	if req.AccountID != "" {
+		id, status, msg := budgetAccount(h.db, req.AccountID, *user.BudgetID)
+		if status == 0 {
+			status, msg = accountOpenOn(h.db, id, date)
+		}
+		if status != 0 {
+			respondJSON(w, status, map[string]string{"error": msg})
+			return
+		}
+		accountID = &id
+	}
+
+	// Default to the budget's base currency
+	transactionCurrency := req.Currency
+	if transactionCurrency == "" {
+		var budget models.Budget
+		if err := h.db.First(&budget, "id = ?", user.BudgetID).Error; err != nil {
+			respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch budget"})
+			return
+		}
+		transactionCurrency = budget.BaseCurrency
+	}
+	transactionCurrency, err = currency.Normalize(transactionCurrency)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
 	// Extract merchant name from description (simple version)
 	merchantName := extractMerchantName(req.Description)
 
@@ -141,13 +219,57 @@ func (h *TransactionHandler) CreateTransaction(w http.ResponseWriter, r *http.Re
 		UserID:       userID,
 		BudgetID:     *user.BudgetID,
 		Amount:       req.Amount,
+		Currency:     transactionCurrency,
 		Description:  req.Description,
 		MerchantName: merchantName,
 		CategoryID:   categoryID,
+		AccountID:    accountID,
 		Date:         date,
+		Notes:        req.Notes,
+
+		IsReimbursable:     req.IsReimbursable,
+		ReimbursementPayer: strings.TrimSpace(req.ReimbursementPayer),
+
+		IsRefund:              req.IsRefund,
+		RefundOfTransactionID: refundOf,
+	}
+	if err := validateReimbursable(transaction); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if status, msg := validateRefund(h.db, transaction); status != 0 {
+		respondJSON(w, status, map[string]string{"error": msg})
+		return
+	}
+	category, status, msg := budgetCategory(h.db, categoryID, *user.BudgetID)
+	if status != 0 {
+		respondJSON(w, status, map[string]string{"error": msg})
+		return
+	}
+	if err := validateCategoryAmount(category, transaction); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	for _, name := range req.Tags {
+		if _, err := normalizeTagName(name); err != nil {
+			respondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
 	}
 
-	if err := h.db.Create(&transaction).Error; err != nil {
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&transaction).Error; err != nil {
+			return err
+		}
+		tags, err := budgetTags(tx, transaction.BudgetID, req.Tags)
+		if err != nil {
+			return err
+		}
+		transaction.Tags = tags
+		return setTransactionTags(tx, transaction.ID, tags)
+	})
+	if err != nil {
 		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to create transaction"})
 		return
 	}
@@ -182,8 +304,8 @@ func (h *TransactionHandler) GetTransaction(w http.ResponseWriter, r *http.Reque
 	}
 
 	// Verify user has access to this transaction (same budget)
-	var user models.User
-	if err := h.db.First(&user, "id = ?", userID).Error; err != nil {
+	user, err := requestUser(h.db, r, userID)
+	if err != nil {
 		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch user"})
 		return
 	}
@@ -193,26 +315,37 @@ func (h *TransactionHandler) GetTransaction(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	respondJSON(w, http.StatusOK, map[string]interface{}{"data": transaction})
+	transactions := []models.Transaction{transaction}
+	if err := loadTransactionTags(h.db, transactions); err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch transaction tags"})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"data": transactions[0]})
 }
 
 func (h *TransactionHandler) UpdateTransaction(w http.ResponseWriter, r *http.Request) {
-	userID, err := middleware.GetUserID(r)
-	if err != nil {
-		respondJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	// The transaction has to be in the selected budget, whose role was checked
+	transaction, userID, ok := authorizedTransaction(h.db, w, r)
+	if !ok {
 		return
 	}
 
-	transactionID := chi.URLParam(r, "id")
-	var transaction models.Transaction
-	if err := h.db.First(&transaction, "id = ?", transactionID).Error; err != nil {
-		respondJSON(w, http.StatusNotFound, map[string]string{"error": "transaction not found"})
+	if editable, err := transactionEditable(h.db, transaction, userID); err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch budget membership"})
+		return
+	} else if !editable {
+		respondJSON(w, http.StatusForbidden, map[string]string{"error": "can only update your own transactions"})
 		return
 	}
 
-	// Verify ownership or budget access
-	if transaction.UserID != userID {
-		respondJSON(w, http.StatusForbidden, map[string]string{"error": "can only update your own transactions"})
+	// Loan payment legs were split from the loan's balance, which editing
+	// them would leave out of step
+	if inLoanPayment, err := loanPaymentTransaction(h.db, transaction.ID); err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to check loan payments"})
+		return
+	} else if inLoanPayment {
+		respondJSON(w, http.StatusConflict, map[string]string{"error": "transaction belongs to a loan payment; delete the payment instead"})
 		return
 	}
 
@@ -226,6 +359,15 @@ func (h *TransactionHandler) UpdateTransaction(w http.ResponseWriter, r *http.Re
 	if req.Amount != nil {
 		updates["amount"] = *req.Amount
 	}
+	if req.Currency != nil {
+		transactionCurrency, err := currency.Normalize(*req.Currency)
+		if err != nil {
+			respondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		transaction.Currency = transactionCurrency
+		updates["currency"] = transactionCurrency
+	}
 	if req.Description != nil {
 		updates["description"] = *req.Description
 		updates["merchant_name"] = extractMerchantName(*req.Description)
@@ -236,27 +378,180 @@ func (h *TransactionHandler) UpdateTransaction(w http.ResponseWriter, r *http.Re
 			respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid category_id"})
 			return
 		}
+		transaction.CategoryID = categoryID
 		updates["category_id"] = categoryID
 	}
+	date := transaction.Date
 	if req.Date != nil {
-		date, err := time.Parse("2006-01-02", *req.Date)
+		parsed, err := time.Parse("2006-01-02", *req.Date)
 		if err != nil {
 			respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid date format"})
 			return
 		}
+		date = parsed
+		transaction.Date = date
 		updates["date"] = date
 	}
+	accountID := transaction.AccountID
+	if req.AccountID != nil {
+		if *req.AccountID == "" {
+			accountID = nil
+			updates["account_id"] = nil
+		} else {
+			id, status, msg := budgetAccount(h.db, *req.AccountID, transaction.BudgetID)
+			if status != 0 {
+				respondJSON(w, status, map[string]string{"error": msg})
+				return
+			}
+			accountID = &id
+			updates["account_id"] = id
+		}
+	}
+
+	if req.Notes != nil {
+		updates["notes"] = *req.Notes
+	}
 
-	if err := h.db.Model(&transaction).Updates(updates).Error; err != nil {
+	// Validate the reimbursable flag against the transaction as updated
+	if req.Amount != nil {
+		transaction.Amount = *req.Amount
+	}
+	if req.IsReimbursable != nil {
+		if !*req.IsReimbursable && transaction.IsReimbursable {
+			var links int64
+			if err := h.db.Model(&models.Reimbursement{}).Where("expense_transaction_id = ?", transaction.ID).Count(&links).Error; err != nil {
+				respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to check reimbursements"})
+				return
+			}
+			if links > 0 {
+				respondJSON(w, http.StatusConflict, map[string]string{"error": "expense has linked reimbursements; unlink them first"})
+				return
+			}
+		}
+		transaction.IsReimbursable = *req.IsReimbursable
+		updates["is_reimbursable"] = *req.IsReimbursable
+		if !*req.IsReimbursable {
+			transaction.ReimbursementPayer = ""
+			updates["reimbursement_payer"] = ""
+		}
+	}
+	if req.ReimbursementPayer != nil {
+		transaction.ReimbursementPayer = strings.TrimSpace(*req.ReimbursementPayer)
+		updates["reimbursement_payer"] = transaction.ReimbursementPayer
+	}
+	if err := validateReimbursable(transaction); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	// Likewise the refund flag and the purchase it refunds
+	if req.IsRefund != nil {
+		// Money already paying back a reimbursable expense would be netted twice
+		if *req.IsRefund && !transaction.IsRefund {
+			var links int64
+			if err := h.db.Model(&models.Reimbursement{}).Where("reimbursement_transaction_id = ?", transaction.ID).Count(&links).Error; err != nil {
+				respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to check reimbursements"})
+				return
+			}
+			if links > 0 {
+				respondJSON(w, http.StatusConflict, map[string]string{"error": "transaction is linked as a reimbursement; unlink it first"})
+				return
+			}
+		}
+		transaction.IsRefund = *req.IsRefund
+		updates["is_refund"] = *req.IsRefund
+		if !*req.IsRefund {
+			transaction.RefundOfTransactionID = nil
+			updates["refund_of_transaction_id"] = nil
+		}
+	}
+	if req.RefundOfTransactionID != nil {
+		if *req.RefundOfTransactionID == "" {
+			transaction.RefundOfTransactionID = nil
+			updates["refund_of_transaction_id"] = nil
+		} else {
+			id, err := uuid.Parse(*req.RefundOfTransactionID)
+			if err != nil {
+				respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid refund_of_transaction_id"})
+				return
+			}
+			transaction.RefundOfTransactionID = &id
+			updates["refund_of_transaction_id"] = id
+		}
+	}
+	if status, msg := validateRefund(h.db, transaction); status != 0 {
+		respondJSON(w, status, map[string]string{"error": msg})
+		return
+	}
+	if req.Amount != nil || req.Currency != nil || req.Date != nil {
+		if status, msg := validatePurchaseRefunds(h.db, transaction); status != 0 {
+			respondJSON(w, status, map[string]string{"error": msg})
+			return
+		}
+	}
+	if req.Amount != nil || req.Currency != nil {
+		if status, msg := validateReimbursementLinks(h.db, transaction); status != 0 {
+			respondJSON(w, status, map[string]string{"error": msg})
+			return
+		}
+	}
+	if req.Amount != nil || req.CategoryID != nil || req.IsRefund != nil || req.IsReimbursable != nil {
+		category, status, msg := budgetCategory(h.db, transaction.CategoryID, transaction.BudgetID)
+		if status != 0 {
+			respondJSON(w, status, map[string]string{"error": msg})
+			return
+		}
+		if err := validateCategoryAmount(category, transaction); err != nil {
+			respondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+	}
+	if req.Tags != nil {
+		for _, name := range *req.Tags {
+			if _, err := normalizeTagName(name); err != nil {
+				respondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+		}
+	}
+
+	// Closed accounts take no transactions after their closing date
+	if accountID != nil && (req.AccountID != nil || req.Date != nil) {
+		if status, msg := accountOpenOn(h.db, *accountID, date); status != 0 {
+			respondJSON(w, status, map[string]string{"error": msg})
+			return
+		}
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&transaction).Updates(updates).Error; err != nil {
+			return err
+		}
+		if req.Tags == nil {
+			return nil
+		}
+		tags, err := budgetTags(tx, transaction.BudgetID, *req.Tags)
+		if err != nil {
+			return err
+		}
+		return setTransactionTags(tx, transaction.ID, tags)
+	})
+	if err != nil {
 		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to update transaction"})
 		return
 	}
 
 	// Fetch updated transaction
-	if err := h.db.First(&transaction, "id = ?", transactionID).Error; err != nil {
+	if err := h.db.First(&transaction, "id = ?", transaction.ID).Error; err != nil {
 		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch updated transaction"})
 		return
 	}
+	transactions := []models.Transaction{transaction}
+	if err := loadTransactionTags(h.db, transactions); err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch transaction tags"})
+		return
+	}
+	transaction = transactions[0]
 
 	respondJSON(w, http.StatusOK, map[string]interface{}{
 		"data":    transaction,
@@ -265,26 +560,44 @@ func (h *TransactionHandler) UpdateTransaction(w http.ResponseWriter, r *http.Re
 }
 
 func (h *TransactionHandler) DeleteTransaction(w http.ResponseWriter, r *http.Request) {
-	userID, err := middleware.GetUserID(r)
-	if err != nil {
-		respondJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	// The transaction has to be in the selected budget, whose role was checked
+	transaction, userID, ok := authorizedTransaction(h.db, w, r)
+	if !ok {
 		return
 	}
 
-	transactionID := chi.URLParam(r, "id")
-	var transaction models.Transaction
-	if err := h.db.First(&transaction, "id = ?", transactionID).Error; err != nil {
-		respondJSON(w, http.StatusNotFound, map[string]string{"error": "transaction not found"})
+	if editable, err := transactionEditable(h.db, transaction, userID); err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch budget membership"})
+		return
+	} else if !editable {
+		respondJSON(w, http.StatusForbidden, map[string]string{"error": "can only delete your own transactions"})
 		return
 	}
 
-	// Verify ownership
-	if transaction.UserID != userID {
-		respondJSON(w, http.StatusForbidden, map[string]string{"error": "can only delete your own transactions"})
+	// Loan payment legs go with their payment, which also restores the balance
+	if inLoanPayment, err := loanPaymentTransaction(h.db, transaction.ID); err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to check loan payments"})
+		return
+	} else if inLoanPayment {
+		respondJSON(w, http.StatusConflict, map[string]string{"error": "transaction belongs to a loan payment; delete the payment instead"})
 		return
 	}
 
-	if err := h.db.Delete(&transaction).Error; err != nil {
+	// Files in blob storage are removed through the attachment endpoints
+	var attachments int64
+	if err := h.db.Model(&models.Attachment{}).Where("transaction_id = ?", transaction.ID).Count(&attachments).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to check attachments"})
+		return
+	}
+	if attachments > 0 {
+		respondJSON(w, http.StatusConflict, map[string]string{"error": "transaction has attachments; delete them first"})
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		return deleteTransaction(tx, transaction.ID)
+	})
+	if err != nil {
 		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to delete transaction"})
 		return
 	}
@@ -294,6 +607,63 @@ func (h *TransactionHandler) DeleteTransaction(w http.ResponseWriter, r *http.Re
 	})
 }
 
+// deleteTransaction removes a transaction along with its tags and
+// reimbursement links. Callers check for attachments first.
+func deleteTransaction(tx *gorm.DB, transactionID uuid.UUID) error {
+	if err := tx.Where("transaction_id = ?", transactionID).Delete(&models.TransactionTag{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("expense_transaction_id = ? OR reimbursement_transaction_id = ?", transactionID, transactionID).
+		Delete(&models.Reimbursement{}).Error; err != nil {
+		return err
+	}
+	// Refunds of the purchase stay refunds, just no longer linked
+	if err := tx.Model(&models.Transaction{}).Where("refund_of_transaction_id = ?", transactionID).
+		Update("refund_of_transaction_id", nil).Error; err != nil {
+		return err
+	}
+	return tx.Delete(&models.Transaction{}, "id = ?", transactionID).Error
+}
+
+// loanPaymentTransaction reports whether a transaction is the interest or
+// principal part of a loan payment
+func loanPaymentTransaction(db *gorm.DB, transactionID uuid.UUID) (bool, error) {
+	var count int64
+	err := db.Model(&models.LoanPayment{}).
+		Where("interest_transaction_id = ? OR principal_transaction_id = ?", transactionID, transactionID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// transactionEditable reports whether the user may change a transaction in
+// their selected budget: their own, or one whose author has left the budget,
+// which any member with write access then looks after
+func transactionEditable(db *gorm.DB, transaction models.Transaction, userID uuid.UUID) (bool, error) {
+	if transaction.UserID == userID {
+		return true, nil
+	}
+	_, err := budgetMember(db, transaction.BudgetID, transaction.UserID)
+	if err == gorm.ErrRecordNotFound {
+		return true, nil
+	}
+	return false, err
+}
+
+// validateReimbursable checks only expenses are flagged reimbursable, and
+// only they name a payer
+func validateReimbursable(transaction models.Transaction) error {
+	if transaction.IsReimbursable && transaction.Amount >= 0 {
+		return errors.New("only expenses can be reimbursable")
+	}
+	if !transaction.IsReimbursable && transaction.ReimbursementPayer != "" {
+		return errors.New("reimbursement_payer requires is_reimbursable")
+	}
+	if len(transaction.ReimbursementPayer) > 255 {
+		return errors.New("reimbursement_payer must be at most 255 characters")
+	}
+	return nil
+}
+
 // extractMerchantName extracts a normalized merchant name from description
 func extractMerchantName(description string) string {
 	// Simple implementation: take first word, uppercase
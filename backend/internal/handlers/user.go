@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/yourusername/folda-finances/internal/middleware"
 	"github.com/yourusername/folda-finances/internal/models"
 	"gorm.io/gorm"
@@ -23,6 +24,7 @@ type UpdateUserRequest struct {
 	Name            *string `json:"name"`
 	ViewPeriod      *string `json:"view_period"`
 	PeriodStartDate *string `json:"period_start_date"`
+	BudgetID        *string `json:"budget_id"` // default budget, one the user belongs to
 }
 
 func (h *UserHandler) GetCurrentUser(w http.ResponseWriter, r *http.Request) {
@@ -46,7 +48,6 @@ func (h *UserHandler) GetCurrentUser(w http.ResponseWriter, r *http.Request) {
 				ID:              userID,
 				Email:           email, // Will be populated from Supabase metadata if available
 				Name:            "New User",
-				ViewPeriod:      "monthly",
 				PeriodStartDate: func() *time.Time { t := time.Now(); return &t }(),
 				BudgetID:        nil, // No budget yet
 			}
@@ -65,6 +66,10 @@ func (h *UserHandler) GetCurrentUser(w http.ResponseWriter, r *http.Request) {
 				respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to create budget"})
 				return
 			}
+			if err := h.db.Create(&models.BudgetMember{BudgetID: budget.ID, UserID: userID, Role: models.BudgetRoleOwner}).Error; err != nil {
+				respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to create budget"})
+				return
+			}
 
 			// Step 3: Update user with budget ID
 			user.BudgetID = &budget.ID
@@ -78,6 +83,18 @@ func (h *UserHandler) GetCurrentUser(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Users without a period of their own follow their budget's
+	if user.ViewPeriod == "" {
+		var budget models.Budget
+		if user.BudgetID != nil {
+			if err := h.db.First(&budget, "id = ?", *user.BudgetID).Error; err != nil {
+				respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch budget"})
+				return
+			}
+		}
+		user.ViewPeriod = effectiveViewPeriod(user, budget)
+	}
+
 	respondJSON(w, http.StatusOK, map[string]interface{}{"data": user})
 }
 
@@ -105,7 +122,8 @@ func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 		updates["name"] = *req.Name
 	}
 	if req.ViewPeriod != nil {
-		if *req.ViewPeriod != "weekly" && *req.ViewPeriod != "biweekly" && *req.ViewPeriod != "monthly" {
+		// An empty view_period follows the budget's default
+		if *req.ViewPeriod != "" && *req.ViewPeriod != "weekly" && *req.ViewPeriod != "biweekly" && *req.ViewPeriod != "monthly" {
 			respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid view_period"})
 			return
 		}
@@ -120,6 +138,22 @@ func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 		}
 		updates["period_anchor_day"] = anchorDay
 	}
+	if req.BudgetID != nil {
+		budgetID, err := uuid.Parse(*req.BudgetID)
+		if err != nil {
+			respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid budget_id"})
+			return
+		}
+		if _, err := budgetMember(h.db, budgetID, userID); err != nil {
+			if err == gorm.ErrRecordNotFound {
+				respondJSON(w, http.StatusBadRequest, map[string]string{"error": "you are not a member of this budget"})
+				return
+			}
+			respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch budget membership"})
+			return
+		}
+		updates["budget_id"] = budgetID
+	}
 
 	if err := h.db.Model(&user).Updates(updates).Error; err != nil {
 		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to update user"})
@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/yourusername/folda-finances/internal/models"
+	"gorm.io/gorm"
+)
+
+const BudgetIDKey contextKey = "budget_id"
+const BudgetRoleKey contextKey = "budget_role"
+
+// BudgetHeader selects the budget a request acts on when the path does not
+const BudgetHeader = "X-Budget-ID"
+
+type BudgetMiddleware struct {
+	db *gorm.DB
+}
+
+func NewBudgetMiddleware(db *gorm.DB) *BudgetMiddleware {
+	return &BudgetMiddleware{db: db}
+}
+
+// SelectBudget picks the budget a request acts on from the {budgetId} path
+// parameter or, failing that, the X-Budget-ID header, and stores it with the
+// user's role in it after checking they are a member. Requests selecting
+// neither act on the user's default budget.
+func (bm *BudgetMiddleware) SelectBudget(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, err := GetUserID(r)
+		if err != nil {
+			http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+
+		selected := chi.URLParam(r, "budgetId")
+		if selected == "" {
+			selected = r.Header.Get(BudgetHeader)
+		}
+		if selected == "" {
+			bm.defaultBudget(w, r, next, userID)
+			return
+		}
+
+		budgetID, err := uuid.Parse(selected)
+		if err != nil {
+			http.Error(w, `{"error":"invalid budget id"}`, http.StatusBadRequest)
+			return
+		}
+
+		bm.serveMember(w, r, next, budgetID, userID)
+	})
+}
+
+// defaultBudget acts on the user's default budget. Users not yet set up are
+// passed through for the handlers to deal with.
+func (bm *BudgetMiddleware) defaultBudget(w http.ResponseWriter, r *http.Request, next http.Handler, userID uuid.UUID) {
+	var user models.User
+	if err := bm.db.First(&user, "id = ?", userID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			next.ServeHTTP(w, r)
+			return
+		}
+		http.Error(w, `{"error":"failed to fetch user"}`, http.StatusInternalServerError)
+		return
+	}
+	if user.BudgetID == nil {
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	bm.serveMember(w, r, next, *user.BudgetID, userID)
+}
+
+// serveMember stores budgetID and the user's role in it, however the budget
+// was selected, once they are known to be a member of a budget still in use
+func (bm *BudgetMiddleware) serveMember(w http.ResponseWriter, r *http.Request, next http.Handler, budgetID, userID uuid.UUID) {
+	var member models.BudgetMember
+	if err := bm.db.First(&member, "budget_id = ? AND user_id = ?", budgetID, userID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			http.Error(w, `{"error":"you are not a member of this budget"}`, http.StatusForbidden)
+			return
+		}
+		http.Error(w, `{"error":"failed to fetch budget membership"}`, http.StatusInternalServerError)
+		return
+	}
+
+	// Deleted budgets wait out their grace period untouched
+	var budget models.Budget
+	if err := bm.db.Select("id", "is_active").First(&budget, "id = ?", budgetID).Error; err != nil {
+		http.Error(w, `{"error":"failed to fetch budget"}`, http.StatusInternalServerError)
+		return
+	}
+	if !budget.IsActive {
+		http.Error(w, `{"error":"this budget has been deleted"}`, http.StatusGone)
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), BudgetIDKey, member.BudgetID)
+	ctx = context.WithValue(ctx, BudgetRoleKey, member.Role)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// RequireWriteAccess stops read-only members changing the selected budget.
+// Only GET, HEAD and OPTIONS requests are let through for them.
+func RequireWriteAccess(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+		default:
+			if GetBudgetRole(r) == models.BudgetRoleReadOnly {
+				http.Error(w, `{"error":"read-only members cannot change this budget"}`, http.StatusForbidden)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// GetBudgetID returns the budget the request selected, if it selected one
+func GetBudgetID(r *http.Request) (uuid.UUID, bool) {
+	budgetID, ok := r.Context().Value(BudgetIDKey).(uuid.UUID)
+	return budgetID, ok
+}
+
+// GetBudgetRole returns the user's role in the budget the request selected
+func GetBudgetRole(r *http.Request) string {
+	role, _ := r.Context().Value(BudgetRoleKey).(string)
+	return role
+}
@@ -12,9 +12,9 @@ type User struct {
 	ID               uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
 	Email            string     `gorm:"type:varchar(255);unique;not null" json:"email"`
 	Name             string     `gorm:"type:varchar(255)" json:"name"`
-	BudgetID         *uuid.UUID `gorm:"type:uuid" json:"budget_id"`
+	BudgetID         *uuid.UUID `gorm:"type:uuid" json:"budget_id"` // default budget, see BudgetMember
 	BudgetRole       string     `gorm:"type:varchar(20);default:'read_write'" json:"budget_role"`
-	ViewPeriod       string     `gorm:"type:varchar(20);default:'monthly'" json:"view_period"`
+	ViewPeriod       string     `gorm:"type:varchar(20)" json:"view_period"` // empty follows the budget's default
 	PeriodStartDate  *time.Time `gorm:"type:date" json:"period_start_date"`
 	PeriodAnchorDay  *int64     `gorm:"type:bigint" json:"period_anchor_day"`
 	IsPremium        bool       `gorm:"default:false" json:"is_premium"`
@@ -33,6 +33,44 @@ type Budget struct {
 	IsActive   bool      `gorm:"default:true" json:"is_active"`
 	CreatedAt  time.Time `json:"created_at"`
 	UpdatedAt  time.Time `json:"updated_at"`
+
+	// Currency spending is reported in; other currencies are converted
+	BaseCurrency string `gorm:"type:varchar(3);not null;default:'USD'" json:"base_currency"`
+
+	// Percentage-used levels at which categories warn and go over budget
+	WarningThreshold    float64 `gorm:"type:decimal(5,2);default:75" json:"warning_threshold"`
+	OverBudgetThreshold float64 `gorm:"type:decimal(5,2);default:100" json:"over_budget_threshold"`
+
+	// Leave reimbursable expenses still owed back out of category spending
+	ExcludePendingReimbursements bool `gorm:"default:false" json:"exclude_pending_reimbursements"`
+
+	// Period refunds of a linked purchase net against: refund (the period the
+	// refund lands in) or original (the purchase's period)
+	RefundPeriod string `gorm:"type:varchar(20);not null;default:'refund'" json:"refund_period"`
+
+	// Period members who join by invitation start viewing spending by, and the
+	// day their weeks start on (0 Sunday to 6 Saturday)
+	DefaultViewPeriod string `gorm:"type:varchar(20);not null;default:'monthly'" json:"default_view_period"`
+	WeekStartDay      int    `gorm:"not null;default:0" json:"week_start_day"`
+
+	// Deleted budgets are inactive from this time until they are purged
+	DeactivatedAt *time.Time `gorm:"type:timestamp" json:"deactivated_at"`
+}
+
+// Roles a user can have in a budget
+const (
+	BudgetRoleOwner     = "owner"
+	BudgetRoleReadWrite = "read_write"
+	BudgetRoleReadOnly  = "read_only"
+)
+
+// BudgetMember gives a user access to a budget with a role. Users can belong
+// to several budgets; User.BudgetID is the one requests use by default.
+type BudgetMember struct {
+	BudgetID  uuid.UUID `gorm:"type:uuid;primary_key" json:"budget_id"`
+	UserID    uuid.UUID `gorm:"type:uuid;primary_key;index" json:"user_id"`
+	Role      string    `gorm:"type:varchar(20);not null;default:'read_write'" json:"role"` // owner, read_write, read_only
+	CreatedAt time.Time `json:"created_at"`
 }
 
 // Category represents an expense/income category
@@ -43,7 +81,28 @@ type Category struct {
 	Color     string     `gorm:"type:varchar(50);not null" json:"color"`
 	Icon      string     `gorm:"type:varchar(50);not null" json:"icon"`
 	IsSystem  bool       `gorm:"default:false" json:"is_system"`
+	Kind      string     `gorm:"type:varchar(20);not null;default:'expense'" json:"kind"` // expense, income, transfer
 	CreatedAt time.Time  `json:"created_at"`
+
+	IsHidden bool       `gorm:"-" json:"is_hidden,omitempty"` // from HiddenCategory
+	ParentID *uuid.UUID `gorm:"-" json:"parent_id"`           // group in the budget, from CategoryParent
+}
+
+// CategoryParent places a category in a group, another category, for one
+// budget, so shared system categories can be grouped differently per budget.
+// Groups are one level deep.
+type CategoryParent struct {
+	BudgetID   uuid.UUID `gorm:"type:uuid;primary_key" json:"budget_id"`
+	CategoryID uuid.UUID `gorm:"type:uuid;primary_key" json:"category_id"`
+	ParentID   uuid.UUID `gorm:"type:uuid;not null;index" json:"parent_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// HiddenCategory hides a system category from one budget's category lists
+type HiddenCategory struct {
+	BudgetID   uuid.UUID `gorm:"type:uuid;primary_key" json:"budget_id"`
+	CategoryID uuid.UUID `gorm:"type:uuid;primary_key" json:"category_id"`
+	CreatedAt  time.Time `json:"created_at"`
 }
 
 // Account represents a bank account, credit card, cash, etc.
@@ -51,29 +110,103 @@ type Account struct {
 	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
 	BudgetID  uuid.UUID `gorm:"type:uuid;not null" json:"budget_id"`
 	Name      string    `gorm:"type:varchar(255);not null" json:"name"`
-	Type      string    `gorm:"type:varchar(50);not null" json:"type"` // checking, savings, credit_card, cash, investment, other
+	Type      string    `gorm:"type:varchar(50);not null" json:"type"` // checking, savings, credit_card, loan, mortgage, cash, investment, other
 	Balance   int       `gorm:"not null;default:0" json:"balance"`     // in cents
 	Currency  string    `gorm:"type:varchar(3);not null;default:'USD'" json:"currency"`
 	IsActive  bool      `gorm:"default:true" json:"is_active"`
 	Notes     string    `gorm:"type:text" json:"notes"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+
+	// Closed accounts are inactive and kept for their history
+	ClosedAt *time.Time `gorm:"type:date" json:"closed_at"`
+
+	// Liability details, amount owed is the absolute balance
+	APR            *float64 `gorm:"type:decimal(6,3)" json:"apr"`        // annual percentage rate, e.g. 19.99
+	MinimumPayment *int     `gorm:"type:integer" json:"minimum_payment"` // in cents
+	PaymentDueDay  *int     `gorm:"type:integer" json:"payment_due_day"` // day of month
+
+	// Credit card statements close on this day of the month
+	StatementClosingDay *int `gorm:"type:integer" json:"statement_closing_day"`
+
+	// Loan terms, for loan and mortgage accounts; the monthly payment is the
+	// minimum payment
+	LoanPrincipal  *int       `gorm:"type:integer" json:"loan_principal"` // original amount borrowed, in cents
+	LoanTermMonths *int       `gorm:"type:integer" json:"loan_term_months"`
+	LoanStartDate  *time.Time `gorm:"type:date" json:"loan_start_date"`
 }
 
 // Transaction represents a financial transaction
 type Transaction struct {
-	ID                 uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	UserID             uuid.UUID  `gorm:"type:uuid;not null" json:"user_id"`
-	BudgetID           uuid.UUID  `gorm:"type:uuid;not null" json:"budget_id"`
-	AccountID          *uuid.UUID `gorm:"type:uuid" json:"account_id"`
-	Amount             int        `gorm:"not null" json:"amount"` // in cents
-	Description        string     `gorm:"type:text" json:"description"`
-	MerchantName       string     `gorm:"type:varchar(255)" json:"merchant_name"`
-	CategoryID         uuid.UUID  `gorm:"type:uuid;not null" json:"category_id"`
-	Date               time.Time  `gorm:"type:date;not null" json:"date"`
-	DetectedPatternID  *uuid.UUID `gorm:"type:uuid" json:"detected_pattern_id"`
-	CreatedAt          time.Time  `json:"created_at"`
-	UpdatedAt          time.Time  `json:"updated_at"`
+	ID                uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID            uuid.UUID  `gorm:"type:uuid;not null" json:"user_id"`
+	BudgetID          uuid.UUID  `gorm:"type:uuid;not null" json:"budget_id"`
+	AccountID         *uuid.UUID `gorm:"type:uuid" json:"account_id"`
+	Amount            int        `gorm:"not null" json:"amount"` // in cents
+	Currency          string     `gorm:"type:varchar(3);not null;default:'USD'" json:"currency"`
+	Description       string     `gorm:"type:text" json:"description"`
+	MerchantName      string     `gorm:"type:varchar(255)" json:"merchant_name"`
+	CategoryID        uuid.UUID  `gorm:"type:uuid;not null" json:"category_id"`
+	Date              time.Time  `gorm:"type:date;not null" json:"date"`
+	DetectedPatternID *uuid.UUID `gorm:"type:uuid" json:"detected_pattern_id"`
+	Notes             string     `gorm:"type:text" json:"notes"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+
+	// Expenses fronted for someone else, to be paid back by the payer
+	IsReimbursable     bool   `gorm:"default:false" json:"is_reimbursable"`
+	ReimbursementPayer string `gorm:"type:varchar(255)" json:"reimbursement_payer"`
+
+	// Money back from a merchant, netted against the category's spending
+	// rather than counted as income, optionally tied to the purchase
+	IsRefund              bool       `gorm:"default:false" json:"is_refund"`
+	RefundOfTransactionID *uuid.UUID `gorm:"type:uuid;index" json:"refund_of_transaction_id"`
+
+	Tags []Tag `gorm:"-" json:"tags,omitempty"` // loaded from TransactionTag
+}
+
+// Tag is a budget-wide label cutting across categories, e.g. "vacation-2026"
+type Tag struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	BudgetID  uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_tag_budget_name" json:"budget_id"`
+	Name      string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_tag_budget_name" json:"name"` // lowercase
+	Color     string    `gorm:"type:varchar(7)" json:"color"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TransactionTag links a transaction to one of its tags
+type TransactionTag struct {
+	TransactionID uuid.UUID `gorm:"type:uuid;primary_key" json:"transaction_id"`
+	TagID         uuid.UUID `gorm:"type:uuid;primary_key;index" json:"tag_id"`
+}
+
+// Attachment is a receipt or document kept in blob storage for a transaction
+type Attachment struct {
+	ID            uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	BudgetID      uuid.UUID `gorm:"type:uuid;not null" json:"budget_id"`
+	TransactionID uuid.UUID `gorm:"type:uuid;not null;index" json:"transaction_id"`
+	UploadedBy    uuid.UUID `gorm:"type:uuid;not null" json:"uploaded_by"`
+	FileName      string    `gorm:"type:varchar(255);not null" json:"file_name"`
+	ContentType   string    `gorm:"type:varchar(100);not null" json:"content_type"`
+	Size          int64     `gorm:"not null" json:"size"` // in bytes
+	StorageKey    string    `gorm:"type:varchar(255);not null" json:"-"`
+	ThumbnailKey  string    `gorm:"type:varchar(255)" json:"-"` // images only
+	CreatedAt     time.Time `json:"created_at"`
+
+	DownloadURL  string `gorm:"-" json:"download_url"`
+	ThumbnailURL string `gorm:"-" json:"thumbnail_url,omitempty"`
+}
+
+// Reimbursement links an incoming transaction to the reimbursable expense it
+// pays back, in whole or in part
+type Reimbursement struct {
+	ID                         uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	BudgetID                   uuid.UUID `gorm:"type:uuid;not null" json:"budget_id"`
+	ExpenseTransactionID       uuid.UUID `gorm:"type:uuid;not null;index" json:"expense_transaction_id"`
+	ReimbursementTransactionID uuid.UUID `gorm:"type:uuid;not null;index" json:"reimbursement_transaction_id"`
+	Amount                     int       `gorm:"not null" json:"amount"` // in cents of the expense's currency
+	CreatedAt                  time.Time `json:"created_at"`
 }
 
 // CategoryBudget represents a monthly budget for a category
@@ -85,6 +218,27 @@ type CategoryBudget struct {
 	AllocationType string    `gorm:"type:varchar(20);default:'pooled'" json:"allocation_type"`
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
+
+	// Per-category overrides of the budget's thresholds
+	WarningThreshold    *float64 `gorm:"type:decimal(5,2)" json:"warning_threshold"`
+	OverBudgetThreshold *float64 `gorm:"type:decimal(5,2)" json:"over_budget_threshold"`
+	IsFixedExpense      bool     `gorm:"default:false" json:"is_fixed_expense"` // excluded from pace warnings
+
+	// Sinking funds save towards a yearly target instead of a monthly limit
+	BudgetType   string     `gorm:"type:varchar(20);not null;default:'standard'" json:"budget_type"` // standard, sinking_fund
+	TargetAmount *int       `gorm:"type:integer" json:"target_amount"`                               // in cents
+	TargetDate   *time.Time `gorm:"type:date" json:"target_date"`                                    // next due date, rolls forward yearly
+}
+
+// CategoryBudgetAmount is a category budget amount in force from a given month,
+// so raising a budget does not rewrite earlier periods
+type CategoryBudgetAmount struct {
+	ID               uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	CategoryBudgetID uuid.UUID `gorm:"type:uuid;not null;index" json:"category_budget_id"`
+	Amount           int       `gorm:"not null" json:"amount"`                    // monthly amount in cents
+	EffectiveMonth   time.Time `gorm:"type:date;not null" json:"effective_month"` // first day of the month
+	OnlyThisMonth    bool      `gorm:"default:false" json:"only_this_month"`      // one-off override for a single month
+	CreatedAt        time.Time `json:"created_at"`
 }
 
 // CategoryBudgetSplit represents user-specific allocations for split budgets
@@ -98,6 +252,26 @@ type CategoryBudgetSplit struct {
 	UpdatedAt            time.Time `json:"updated_at"`
 }
 
+// BudgetTemplate is a budget's saved set of allocations, as shares of monthly
+// income, that can be applied to set up category budgets. Built-in templates
+// are defined in code rather than stored.
+type BudgetTemplate struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	BudgetID    uuid.UUID `gorm:"type:uuid;not null;index" json:"budget_id"`
+	Name        string    `gorm:"type:varchar(255);not null" json:"name"`
+	Description string    `gorm:"type:text" json:"description"`
+	CreatedBy   uuid.UUID `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// BudgetTemplateItem is one category's share of income in a budget template
+type BudgetTemplateItem struct {
+	TemplateID uuid.UUID `gorm:"type:uuid;primary_key" json:"template_id"`
+	CategoryID uuid.UUID `gorm:"type:uuid;primary_key" json:"category_id"`
+	Percentage float64   `gorm:"type:decimal(5,2);not null" json:"percentage"`
+}
+
 // ExpectedIncome represents expected/recurring income
 type ExpectedIncome struct {
 	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
@@ -125,6 +299,125 @@ type BudgetInvitation struct {
 	AcceptedAt   *time.Time `gorm:"type:timestamp" json:"accepted_at"`
 }
 
+// Goal represents a savings goal
+type Goal struct {
+	ID               uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	BudgetID         uuid.UUID  `gorm:"type:uuid;not null;index" json:"budget_id"`
+	Name             string     `gorm:"type:varchar(255);not null" json:"name"`
+	TargetAmount     int        `gorm:"not null" json:"target_amount"` // in cents
+	TargetDate       time.Time  `gorm:"type:date;not null" json:"target_date"`
+	AccountID        *uuid.UUID `gorm:"type:uuid" json:"account_id"`         // account the savings are kept in
+	CategoryBudgetID *uuid.UUID `gorm:"type:uuid" json:"category_budget_id"` // receives the required monthly amount
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// GoalContribution represents money put towards a savings goal, either
+// entered manually or linked to a transaction
+type GoalContribution struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	GoalID        uuid.UUID  `gorm:"type:uuid;not null;index" json:"goal_id"`
+	UserID        uuid.UUID  `gorm:"type:uuid;not null" json:"user_id"`
+	TransactionID *uuid.UUID `gorm:"type:uuid" json:"transaction_id"`
+	Amount        int        `gorm:"not null" json:"amount"` // in cents, negative for withdrawals
+	Date          time.Time  `gorm:"type:date;not null" json:"date"`
+	Note          string     `gorm:"type:text" json:"note"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// LoanPayment is a payment recorded against a loan or mortgage, split into
+// the interest and principal transactions it created
+type LoanPayment struct {
+	ID                     uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	AccountID              uuid.UUID  `gorm:"type:uuid;not null;index" json:"account_id"`
+	UserID                 uuid.UUID  `gorm:"type:uuid;not null" json:"user_id"`
+	Amount                 int        `gorm:"not null" json:"amount"`    // total paid, in cents
+	Interest               int        `gorm:"not null" json:"interest"`  // in cents
+	Principal              int        `gorm:"not null" json:"principal"` // in cents
+	Balance                int        `gorm:"not null" json:"balance"`   // account balance after the payment
+	Date                   time.Time  `gorm:"type:date;not null" json:"date"`
+	InterestTransactionID  *uuid.UUID `gorm:"type:uuid" json:"interest_transaction_id"`
+	PrincipalTransactionID *uuid.UUID `gorm:"type:uuid" json:"principal_transaction_id"`
+	CreatedAt              time.Time  `json:"created_at"`
+}
+
+// InvestmentLot is a purchase of a security in an investment account, sold
+// down first in, first out
+type InvestmentLot struct {
+	ID                uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	AccountID         uuid.UUID `gorm:"type:uuid;not null;index" json:"account_id"`
+	Symbol            string    `gorm:"type:varchar(20);not null" json:"symbol"`
+	Quantity          float64   `gorm:"type:decimal(20,8);not null" json:"quantity"`
+	RemainingQuantity float64   `gorm:"type:decimal(20,8);not null" json:"remaining_quantity"`
+	CostBasis         int       `gorm:"not null" json:"cost_basis"` // total paid including fees, in cents
+	AcquiredAt        time.Time `gorm:"type:date;not null" json:"acquired_at"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// InvestmentTransaction is a buy, sell or dividend in an investment account
+type InvestmentTransaction struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	AccountID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"account_id"`
+	UserID       uuid.UUID  `gorm:"type:uuid;not null" json:"user_id"`
+	Type         string     `gorm:"type:varchar(20);not null" json:"type"` // buy, sell, dividend
+	Symbol       string     `gorm:"type:varchar(20);not null" json:"symbol"`
+	Quantity     float64    `gorm:"type:decimal(20,8);not null;default:0" json:"quantity"`
+	Price        int        `gorm:"not null;default:0" json:"price"`   // per unit, in cents
+	Fees         int        `gorm:"not null;default:0" json:"fees"`    // in cents
+	Amount       int        `gorm:"not null" json:"amount"`            // cash paid for buys, received for sells and dividends
+	RealizedGain *int       `gorm:"type:integer" json:"realized_gain"` // sells only
+	LotID        *uuid.UUID `gorm:"type:uuid" json:"lot_id"`           // lot opened by a buy
+	Date         time.Time  `gorm:"type:date;not null" json:"date"`
+	Notes        string     `gorm:"type:text" json:"notes"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// SecurityPrice is a budget's stored closing price for a symbol on a day
+type SecurityPrice struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	BudgetID  uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_security_price_budget_symbol_date" json:"budget_id"`
+	Symbol    string    `gorm:"type:varchar(20);not null;uniqueIndex:idx_security_price_budget_symbol_date" json:"symbol"`
+	Date      time.Time `gorm:"type:date;not null;uniqueIndex:idx_security_price_budget_symbol_date" json:"date"`
+	Price     int       `gorm:"not null" json:"price"`                   // in cents
+	Source    string    `gorm:"type:varchar(20);not null" json:"source"` // api, csv
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ExchangeRate is how many units of QuoteCurrency one unit of BaseCurrency
+// bought on a day
+type ExchangeRate struct {
+	ID            uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Date          time.Time `gorm:"type:date;not null;uniqueIndex:idx_exchange_rate_date_pair" json:"date"`
+	BaseCurrency  string    `gorm:"type:varchar(3);not null;uniqueIndex:idx_exchange_rate_date_pair" json:"base_currency"`
+	QuoteCurrency string    `gorm:"type:varchar(3);not null;uniqueIndex:idx_exchange_rate_date_pair" json:"quote_currency"`
+	Rate          float64   `gorm:"type:decimal(18,8);not null" json:"rate"`
+	Source        string    `gorm:"type:varchar(20);not null" json:"source"` // csv, ecb
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// NetWorthSnapshot records a budget's net worth on a given day
+type NetWorthSnapshot struct {
+	ID          uuid.UUID              `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	BudgetID    uuid.UUID              `gorm:"type:uuid;not null;uniqueIndex:idx_net_worth_snapshot_budget_date" json:"budget_id"`
+	Date        time.Time              `gorm:"type:date;not null;uniqueIndex:idx_net_worth_snapshot_budget_date" json:"date"`
+	Assets      int                    `gorm:"not null" json:"assets"`      // in cents
+	Liabilities int                    `gorm:"not null" json:"liabilities"` // amount owed, in cents
+	NetWorth    int                    `gorm:"not null" json:"net_worth"`   // in cents
+	Items       []NetWorthSnapshotItem `gorm:"foreignKey:SnapshotID" json:"breakdown"`
+	CreatedAt   time.Time              `json:"created_at"`
+}
+
+// NetWorthSnapshotItem is the total of one account type within a snapshot
+type NetWorthSnapshotItem struct {
+	ID           uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"-"`
+	SnapshotID   uuid.UUID `gorm:"type:uuid;not null;index" json:"-"`
+	AccountType  string    `gorm:"type:varchar(50);not null" json:"account_type"`
+	IsLiability  bool      `gorm:"not null" json:"is_liability"`
+	Balance      int       `gorm:"not null" json:"balance"` // sum of account balances, in cents
+	AccountCount int       `gorm:"not null" json:"account_count"`
+}
+
 // BeforeCreate hooks for GORM
 func (u *User) BeforeCreate(tx *gorm.DB) error {
 	if u.ID == uuid.Nil {
@@ -161,6 +454,13 @@ func (cb *CategoryBudget) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+func (cba *CategoryBudgetAmount) BeforeCreate(tx *gorm.DB) error {
+	if cba.ID == uuid.Nil {
+		cba.ID = uuid.New()
+	}
+	return nil
+}
+
 func (ei *ExpectedIncome) BeforeCreate(tx *gorm.DB) error {
 	if ei.ID == uuid.Nil {
 		ei.ID = uuid.New()
@@ -174,3 +474,94 @@ func (bi *BudgetInvitation) BeforeCreate(tx *gorm.DB) error {
 	}
 	return nil
 }
+
+func (g *Goal) BeforeCreate(tx *gorm.DB) error {
+	if g.ID == uuid.Nil {
+		g.ID = uuid.New()
+	}
+	return nil
+}
+
+func (gc *GoalContribution) BeforeCreate(tx *gorm.DB) error {
+	if gc.ID == uuid.Nil {
+		gc.ID = uuid.New()
+	}
+	return nil
+}
+
+func (lp *LoanPayment) BeforeCreate(tx *gorm.DB) error {
+	if lp.ID == uuid.Nil {
+		lp.ID = uuid.New()
+	}
+	return nil
+}
+
+func (nws *NetWorthSnapshot) BeforeCreate(tx *gorm.DB) error {
+	if nws.ID == uuid.Nil {
+		nws.ID = uuid.New()
+	}
+	return nil
+}
+
+func (nwsi *NetWorthSnapshotItem) BeforeCreate(tx *gorm.DB) error {
+	if nwsi.ID == uuid.Nil {
+		nwsi.ID = uuid.New()
+	}
+	return nil
+}
+
+func (il *InvestmentLot) BeforeCreate(tx *gorm.DB) error {
+	if il.ID == uuid.Nil {
+		il.ID = uuid.New()
+	}
+	return nil
+}
+
+func (it *InvestmentTransaction) BeforeCreate(tx *gorm.DB) error {
+	if it.ID == uuid.Nil {
+		it.ID = uuid.New()
+	}
+	return nil
+}
+
+func (sp *SecurityPrice) BeforeCreate(tx *gorm.DB) error {
+	if sp.ID == uuid.Nil {
+		sp.ID = uuid.New()
+	}
+	return nil
+}
+
+func (er *ExchangeRate) BeforeCreate(tx *gorm.DB) error {
+	if er.ID == uuid.Nil {
+		er.ID = uuid.New()
+	}
+	return nil
+}
+
+func (t *Tag) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+func (a *Attachment) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
+func (r *Reimbursement) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+func (bt *BudgetTemplate) BeforeCreate(tx *gorm.DB) error {
+	if bt.ID == uuid.Nil {
+		bt.ID = uuid.New()
+	}
+	return nil
+}
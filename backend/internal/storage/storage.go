@@ -0,0 +1,43 @@
+// Package storage keeps uploaded files such as receipts behind a small blob
+// interface, on the local filesystem or in an S3-compatible bucket
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var ErrNotFound = errors.New("object not found")
+
+// Store saves and loads objects by key. Keys are slash-separated paths such
+// as "budgets/<id>/attachments/<id>".
+type Store interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+type Config struct {
+	Driver    string // local or s3
+	LocalPath string
+
+	S3Endpoint        string // e.g. https://s3.us-east-1.amazonaws.com or http://localhost:9000
+	S3Region          string
+	S3Bucket          string
+	S3AccessKeyID     string
+	S3SecretAccessKey string
+}
+
+// New opens the store the config names
+func New(config Config) (Store, error) {
+	switch config.Driver {
+	case "", "local":
+		return NewLocal(config.LocalPath)
+	case "s3":
+		return NewS3(config)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", config.Driver)
+	}
+}
@@ -5,28 +5,57 @@
 // MULTI-USER BUDGET TYPES
 // ============================================================================
 
-export type BudgetRole = 'owner' | 'admin' | 'read_write' | 'read_only';
+export type BudgetRole = 'owner' | 'read_write' | 'read_only';
 export type ViewPeriod = 'weekly' | 'biweekly' | 'monthly';
 export type AllocationType = 'pooled' | 'split';
+export type RefundPeriod = 'refund' | 'original'; // period linked refunds net against
 
 // Budget entity (shared by multiple users)
 export interface Budget {
   id: string;
   name: string;
   created_by: string;
-  max_members: number;
+  max_members: number; // member limit when the owner is premium; free budgets allow 2
   is_active: boolean;
+  base_currency: string; // spending summaries are converted to this currency
+  warning_threshold: number; // percentage used at which categories warn
+  over_budget_threshold: number; // percentage used at which categories are over budget
+  exclude_pending_reimbursements: boolean; // reimbursable expenses don't count towards spending
+  refund_period: RefundPeriod;
+  default_view_period: ViewPeriod; // for members without a view period of their own
+  week_start_day: number; // 0 Sunday to 6 Saturday
+  deactivated_at: string | null; // set while a deleted budget awaits purging
   created_at: string;
   updated_at: string;
 }
 
 export interface CreateBudgetRequest {
   name: string;
+  base_currency?: string; // defaults to USD
+}
+
+// A budget the user belongs to, from GET /api/budgets. Requests pick a budget
+// with /api/budgets/:budgetId/... or the X-Budget-ID header, falling back to
+// the default one.
+export interface UserBudget extends Budget {
+  role: BudgetRole;
+  is_default: boolean;
+}
+
+export interface UpdateBudgetRefundsRequest {
+  refund_period: RefundPeriod;
 }
 
 export interface UpdateBudgetRequest {
   name?: string;
-  max_members?: number;
+  base_currency?: string;
+  default_view_period?: ViewPeriod;
+  week_start_day?: number; // 0 Sunday to 6 Saturday
+}
+
+// Deleted budgets can be restored until purge_at
+export interface DeletedBudget extends Budget {
+  purge_at: string;
 }
 
 // Budget member info
@@ -60,6 +89,15 @@ export interface AcceptBudgetInvitationRequest {
   token: string;
 }
 
+export interface UpdateBudgetMemberRequest {
+  role: 'read_write' | 'read_only';
+}
+
+// The previous owner becomes read_write
+export interface TransferOwnershipRequest {
+  user_id: string;
+}
+
 // ============================================================================
 // USER TYPES
 // ============================================================================
@@ -68,9 +106,9 @@ export interface User {
   id: string;
   email: string;
   name: string;
-  budget_id: string | null;
-  budget_role: BudgetRole | null;
-  view_period: ViewPeriod;
+  budget_id: string | null; // default budget
+  budget_role: BudgetRole | null; // role in the default budget
+  view_period: ViewPeriod; // the budget's default unless the user chose one
   period_start_date: string;
   is_premium: boolean;
   created_at: string;
@@ -79,8 +117,9 @@ export interface User {
 
 export interface UpdateUserSettingsRequest {
   name?: string;
-  view_period?: ViewPeriod;
+  view_period?: ViewPeriod | ""; // empty follows the budget's default
   period_start_date?: string;
+  budget_id?: string; // change the default budget
 }
 
 // ============================================================================
@@ -93,6 +132,12 @@ export interface CategoryBudget {
   category_id: string;
   amount: number; // ALWAYS monthly amount in cents
   allocation_type: AllocationType;
+  warning_threshold: number | null; // overrides the budget's threshold
+  over_budget_threshold: number | null;
+  is_fixed_expense: boolean; // excluded from pace warnings
+  budget_type: 'standard' | 'sinking_fund';
+  target_amount: number | null; // sinking fund target in cents
+  target_date: string | null; // sinking fund due date, rolls forward yearly
   created_at: string;
   updated_at: string;
 }
@@ -126,11 +171,57 @@ export interface UpdateCategoryBudgetRequest {
   }[];
 }
 
+// ============================================================================
+// BUDGET TEMPLATE TYPES
+// ============================================================================
+
+// Built-in templates (50-30-20, zero-based, student) have slug IDs; saved
+// templates belong to a budget and have UUIDs
+export interface BudgetTemplate {
+  id: string;
+  name: string;
+  description: string;
+  is_built_in: boolean;
+  total_percentage: number; // share of income the template budgets
+  items: BudgetTemplateItem[];
+}
+
+export interface BudgetTemplateItem {
+  category_id: string;
+  category_name: string;
+  percentage: number; // share of monthly income, e.g. 25.00
+  amount?: number; // with ?monthly_income=, in cents
+}
+
+export interface SaveBudgetTemplateRequest {
+  name: string;
+  description?: string;
+  monthly_income?: number; // defaults to the total budgeted
+}
+
+export interface ApplyBudgetTemplateRequest {
+  monthly_income: number; // in cents
+  replace_existing?: boolean; // change budgeted categories from this month
+}
+
+export interface ApplyBudgetTemplateResponse {
+  template_id: string;
+  monthly_income: number;
+  created: CategoryBudget[];
+  updated: CategoryBudget[];
+  skipped: {
+    category_id: string;
+    category_name: string;
+    reason: string;
+  }[];
+  unallocated: number; // income left unbudgeted
+}
+
 // ============================================================================
 // ACCOUNT TYPES
 // ============================================================================
 
-export type AccountType = 'checking' | 'savings' | 'credit_card' | 'cash' | 'investment' | 'other';
+export type AccountType = 'checking' | 'savings' | 'credit_card' | 'loan' | 'mortgage' | 'cash' | 'investment' | 'other';
 
 export interface Account {
   id: string;
@@ -140,7 +231,15 @@ export interface Account {
   balance: number; // in cents
   currency: string;
   is_active: boolean;
+  closed_at: string | null; // set while closed
   notes: string;
+  apr: number | null; // liability accounts only
+  minimum_payment: number | null; // in cents
+  payment_due_day: number | null; // 1-31
+  statement_closing_day: number | null; // credit cards only, 1-31
+  loan_principal: number | null; // loans and mortgages only, in cents
+  loan_term_months: number | null;
+  loan_start_date: string | null;
   created_at: string;
   updated_at: string;
 }
@@ -149,8 +248,15 @@ export interface CreateAccountRequest {
   name: string;
   type: AccountType;
   balance?: number; // in cents, defaults to 0
-  currency?: string; // defaults to 'USD'
+  currency?: string; // defaults to the budget's base currency
   notes?: string;
+  apr?: number;
+  minimum_payment?: number;
+  payment_due_day?: number;
+  statement_closing_day?: number;
+  loan_principal?: number;
+  loan_term_months?: number;
+  loan_start_date?: string; // YYYY-MM-DD
 }
 
 export interface UpdateAccountRequest {
@@ -160,6 +266,25 @@ export interface UpdateAccountRequest {
   currency?: string;
   is_active?: boolean;
   notes?: string;
+  apr?: number;
+  minimum_payment?: number;
+  payment_due_day?: number;
+  statement_closing_day?: number;
+  loan_principal?: number;
+  loan_term_months?: number;
+  loan_start_date?: string; // YYYY-MM-DD
+}
+
+export interface CloseAccountRequest {
+  closed_at?: string; // YYYY-MM-DD, defaults to today
+}
+
+export interface AccountDependents {
+  transactions: number;
+  goals: number;
+  loan_payments: number;
+  investment_lots: number;
+  investment_transactions: number;
 }
 
 // ============================================================================
@@ -172,35 +297,184 @@ export interface Transaction {
   budget_id: string;
   account_id: string | null;
   amount: number;
+  currency: string;
   description: string;
   category_id: string;
   date: string;
   merchant_name: string; // normalized merchant name for pattern detection
   detected_pattern_id: string | null;
+  notes: string;
+  tags?: Tag[];
+  is_reimbursable: boolean;
+  reimbursement_payer: string;
+  is_refund: boolean; // nets against the category's spending
+  refund_of_transaction_id: string | null; // purchase refunded
   created_at: string;
   updated_at: string;
 }
 
 export interface CreateTransactionRequest {
   amount: number;
+  currency?: string; // defaults to the budget's base currency
   description: string;
   category_id: string;
   date: string;
   account_id?: string | null;
+  notes?: string;
+  tags?: string[]; // tag names, created when new
+  is_reimbursable?: boolean; // expenses only
+  reimbursement_payer?: string;
+  is_refund?: boolean; // positive amounts only
+  refund_of_transaction_id?: string; // category defaults to the purchase's
 }
 
 export interface UpdateTransactionRequest {
   amount?: number;
+  currency?: string;
   description?: string;
   category_id?: string;
   date?: string;
   account_id?: string | null;
+  notes?: string;
+  tags?: string[]; // replaces the tags, [] clears them
+  is_reimbursable?: boolean;
+  reimbursement_payer?: string;
+  is_refund?: boolean;
+  refund_of_transaction_id?: string; // '' unlinks
+}
+
+// ============================================================================
+// ATTACHMENT TYPES
+// ============================================================================
+
+export type AttachmentContentType = 'image/jpeg' | 'image/png' | 'image/gif' | 'image/webp' | 'application/pdf';
+
+export interface Attachment {
+  id: string;
+  budget_id: string;
+  transaction_id: string;
+  uploaded_by: string;
+  file_name: string;
+  content_type: AttachmentContentType;
+  size: number; // in bytes, at most 10 MB
+  created_at: string;
+  download_url: string; // API path, needs the Authorization header
+  thumbnail_url?: string; // JPEG, PNG and GIF images only
+}
+
+// ============================================================================
+// REIMBURSEMENT TYPES
+// ============================================================================
+
+export type ReimbursementState = 'pending' | 'partial' | 'reimbursed';
+export type AgingBucket = '0-30' | '31-60' | '61-90' | 'over_90';
+
+export interface Reimbursement {
+  id: string;
+  budget_id: string;
+  expense_transaction_id: string;
+  reimbursement_transaction_id: string;
+  amount: number; // in the expense's currency
+  created_at: string;
+}
+
+export interface ReimbursementStatus {
+  transaction_id: string;
+  payer: string;
+  currency: string;
+  amount: number; // expense, as a positive amount
+  reimbursed: number;
+  outstanding: number;
+  status: ReimbursementState;
+  reimbursements: Reimbursement[];
+}
+
+export interface CreateReimbursementRequest {
+  reimbursement_transaction_id: string;
+  amount?: number; // defaults to as much as both sides allow
+}
+
+export interface UpdateReimbursementSettingsRequest {
+  exclude_pending_reimbursements: boolean;
+}
+
+export interface OutstandingReimbursement {
+  transaction_id: string;
+  description: string;
+  payer: string;
+  date: string;
+  currency: string;
+  amount: number;
+  reimbursed: number;
+  outstanding: number;
+  age_days: number;
+  aging_bucket: AgingBucket;
+}
+
+export interface ReimbursementTotal {
+  name: string; // aging bucket or payer
+  outstanding: number;
+  count: number;
+}
+
+export interface OutstandingReimbursementsResponse {
+  base_currency: string;
+  total_outstanding: number;
+  aging: ReimbursementTotal[];
+  by_payer: ReimbursementTotal[];
+  items: OutstandingReimbursement[];
+}
+
+// ============================================================================
+// TAG TYPES
+// ============================================================================
+
+export interface Tag {
+  id: string;
+  budget_id: string;
+  name: string; // lowercase
+  color: string;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface TagListItem extends Tag {
+  transaction_count: number;
+}
+
+export interface CreateTagRequest {
+  name: string;
+  color?: string;
+}
+
+export interface UpdateTagRequest {
+  name?: string;
+  color?: string;
+}
+
+export interface TagSpending {
+  tag_id: string;
+  name: string;
+  color: string;
+  spent: number;
+  income: number;
+  net: number; // income minus spent
+  transaction_count: number;
+}
+
+export interface TagSpendingResponse {
+  base_currency: string;
+  tags: TagSpending[];
 }
 
 // ============================================================================
 // CATEGORY TYPES
 // ============================================================================
 
+// Expense transactions are negative (refunds aside), income positive, and
+// transfers either; only expense categories are budgeted
+export type CategoryKind = 'expense' | 'income' | 'transfer';
+
 export interface Category {
   id: string;
   budget_id: string | null; // null for system categories
@@ -208,13 +482,37 @@ export interface Category {
   color: string;
   icon: string;
   is_system: boolean;
+  kind: CategoryKind;
   created_at: string;
+  is_hidden?: boolean; // with ?include_hidden=true
+  parent_id: string | null; // group the category is in for the budget
 }
 
 export interface CreateCategoryRequest {
   name: string;
   color: string;
   icon: string;
+  kind?: CategoryKind; // defaults to expense
+  parent_id?: string; // group to place the category in
+}
+
+export interface SetCategoryParentRequest {
+  parent_id: string; // same kind, not a subcategory; empty to ungroup
+}
+
+export interface UpdateCategoryRequest {
+  name?: string;
+  color?: string;
+  icon?: string;
+}
+
+export interface MergeCategoryRequest {
+  target_category_id: string; // same kind
+}
+
+export interface MergeCategoryResponse {
+  category: Category;
+  transactions_moved: number;
 }
 
 // ============================================================================
@@ -269,25 +567,76 @@ export interface CategorySpending {
   category_name: string;
   category_icon: string;
   category_color: string;
+  parent_id?: string; // group the category is in
   budgeted: number; // pro-rated to user's view period
   spent: number; // actual spending in current period
   available: number; // budgeted - spent
   percentage_used: number;
   status: 'on_track' | 'warning' | 'over_budget';
+  is_fixed_expense: boolean; // excluded from pace warnings
   // For split budgets (premium):
   is_split: boolean;
   my_allocation?: number; // user's allocated portion (if split)
   my_available?: number; // user's available amount (if split)
+  // Pace forecast:
+  expected_to_date: number; // amount expected to be spent by today
+  projected_spend: number; // end-of-period spend at the current pace
+  daily_safe_to_spend: number; // available / days left (including today)
+  pace_status: 'on_track' | 'warning' | 'over_budget';
+  pace_basis: 'linear' | 'historical';
+  sinking_fund?: SinkingFundStatus;
+  foreign_spending?: CurrencyAmount[]; // included in spent after conversion
+}
+
+export interface CategoryGroupSpending {
+  category_id: string;
+  category_name: string;
+  category_icon: string;
+  category_color: string;
+  budgeted_at: 'group' | 'subcategories';
+  budgeted: number; // the group's budget, or its subcategories' together
+  spent: number; // across the group and all its subcategories
+  available: number;
+  percentage_used: number;
+  status: 'on_track' | 'warning' | 'over_budget';
+  subcategories: string[]; // budgeted subcategories in categories
+}
+
+export interface CurrencyAmount {
+  currency: string;
+  amount: number; // in the original currency
+  converted: number; // in the budget's base currency
+}
+
+export interface SinkingFundStatus {
+  balance: number; // accumulated balance in cents
+  target_amount: number;
+  target_date: string;
+  monthly_contribution: number;
+  percentage_funded: number;
 }
 
 export interface SpendingAvailableResponse {
   period: SpendingPeriod;
+  base_currency: string;
   summary: {
     total_available: number;
     total_budgeted: number;
     total_spent: number;
   };
   categories: CategorySpending[];
+  groups?: CategoryGroupSpending[];
+  upcoming_card_payments?: CardPayment[];
+}
+
+export interface CardPayment {
+  account_id: string;
+  account_name: string;
+  statement_balance: number;
+  remaining_due: number;
+  minimum_due: number; // left of the statement minimum
+  due_date: string;
+  days_until_due: number;
 }
 
 // ============================================================================
@@ -424,6 +773,237 @@ export interface UpdateGoalRequest {
   target_date?: string;
 }
 
+// ============================================================================
+// LOAN TYPES
+// ============================================================================
+
+export interface AmortizationPayment {
+  number: number;
+  date: string;
+  payment: number;
+  interest: number;
+  principal: number;
+  balance: number; // owed after the payment
+}
+
+export interface LoanPayment {
+  id: string;
+  account_id: string;
+  user_id: string;
+  amount: number;
+  interest: number;
+  principal: number;
+  balance: number; // account balance after the payment
+  date: string;
+  interest_transaction_id: string | null;
+  principal_transaction_id: string | null;
+  created_at: string;
+}
+
+export interface AmortizationSchedule {
+  account_id: string;
+  monthly_payment: number;
+  total_interest: number;
+  total_paid: number;
+  payoff_date: string;
+  scheduled_balance: number;
+  current_balance: number;
+  schedule: AmortizationPayment[];
+  payments: LoanPayment[];
+}
+
+export interface CreateLoanPaymentRequest {
+  amount: number;
+  date: string;
+  interest_category_id?: string;
+  principal_category_id?: string;
+}
+
+// ============================================================================
+// CREDIT CARD STATEMENT TYPES
+// ============================================================================
+
+export interface StatementCycle {
+  start_date: string;
+  closing_date: string;
+  due_date: string;
+  previous_balance: number;
+  charges: number;
+  credits: number; // payments and refunds
+  statement_balance: number;
+  minimum_due: number;
+}
+
+export interface StatementSummary {
+  account_id: string;
+  account_name: string;
+  closing_day: number;
+  payment_due_day: number;
+  last_statement: StatementCycle | null;
+  paid_since_close: number;
+  remaining_due: number;
+  remaining_minimum: number;
+  days_until_due: number;
+  current_cycle: StatementCycle;
+}
+
+// ============================================================================
+// DEBT PAYOFF TYPES
+// ============================================================================
+
+export type PayoffStrategy = 'avalanche' | 'snowball';
+
+export interface DebtPayment {
+  account_id: string;
+  payment: number;
+  interest: number;
+  principal: number;
+  balance: number; // owed after the payment
+}
+
+export interface PayoffMonth {
+  month: string; // YYYY-MM
+  payments: DebtPayment[];
+  total_payment: number;
+  remaining_balance: number;
+}
+
+export interface DebtPayoff {
+  account_id: string;
+  account_name: string;
+  start_balance: number;
+  payoff_date: string;
+  interest_paid: number;
+  payoff_order: number;
+  months_to_payoff: number;
+}
+
+export interface PayoffPlan {
+  strategy: PayoffStrategy;
+  months: number;
+  payoff_date: string;
+  total_interest: number;
+  total_paid: number;
+  debts: DebtPayoff[];
+  schedule: PayoffMonth[];
+}
+
+export interface DebtPayoffResponse {
+  extra_payment: number;
+  avalanche: PayoffPlan;
+  snowball: PayoffPlan;
+}
+
+// ============================================================================
+// INVESTMENT TYPES
+// ============================================================================
+
+export type InvestmentTransactionType = 'buy' | 'sell' | 'dividend';
+
+export interface InvestmentLot {
+  id: string;
+  account_id: string;
+  symbol: string;
+  quantity: number;
+  remaining_quantity: number;
+  cost_basis: number; // in cents, including fees
+  acquired_at: string;
+  created_at: string;
+}
+
+export interface InvestmentTransaction {
+  id: string;
+  account_id: string;
+  user_id: string;
+  type: InvestmentTransactionType;
+  symbol: string;
+  quantity: number;
+  price: number; // per unit, in cents
+  fees: number;
+  amount: number;
+  realized_gain: number | null;
+  lot_id: string | null;
+  date: string;
+  notes: string;
+  created_at: string;
+}
+
+export interface CreateInvestmentTransactionRequest {
+  type: InvestmentTransactionType;
+  symbol: string;
+  quantity?: number;
+  price?: number;
+  fees?: number;
+  amount?: number; // dividends only
+  date: string;
+  notes?: string;
+}
+
+export interface Holding {
+  symbol: string;
+  quantity: number;
+  cost_basis: number;
+  average_cost: number;
+  price: number;
+  price_date: string | null;
+  market_value: number;
+  unrealized_gain: number;
+  unrealized_gain_percent: number;
+  allocation: number; // percentage of the account's market value
+  lots: InvestmentLot[];
+}
+
+export interface HoldingsSummary {
+  account_id: string;
+  market_value: number;
+  cost_basis: number;
+  unrealized_gain: number;
+  unrealized_gain_percent: number;
+  holdings: Holding[];
+}
+
+export interface SecurityPrice {
+  id: string;
+  budget_id: string;
+  symbol: string;
+  date: string;
+  price: number; // in cents
+  source: 'api' | 'csv';
+  created_at: string;
+  updated_at: string;
+}
+
+// ============================================================================
+// NET WORTH TYPES
+// ============================================================================
+
+export interface NetWorthBreakdown {
+  account_type: AccountType;
+  is_liability: boolean;
+  balance: number; // sum of account balances, in cents
+  account_count: number;
+}
+
+export interface NetWorthSummary {
+  date: string;
+  base_currency: string;
+  assets: number;
+  liabilities: number;
+  net_worth: number;
+  breakdown: NetWorthBreakdown[];
+}
+
+export interface NetWorthSnapshot {
+  id: string;
+  budget_id: string;
+  date: string;
+  assets: number;
+  liabilities: number;
+  net_worth: number;
+  breakdown: NetWorthBreakdown[];
+  created_at: string;
+}
+
 // ============================================================================
 // API RESPONSE TYPES
 // ============================================================================